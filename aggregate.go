@@ -0,0 +1,139 @@
+package raven
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// aggregatedPacket is the first packet of a group of identical packets,
+// held back until the aggregation window of its group is over.
+type aggregatedPacket struct {
+	packet      *Packet
+	ch          chan error
+	count       int
+	first, last time.Time
+}
+
+// aggregator holds back the aggregated packets of a client by aggregationKey
+type aggregator struct {
+	mu      sync.Mutex
+	pending map[string]*aggregatedPacket
+}
+
+// SetAggregation makes given client collapse identical packets captured
+// within window into the first one, which is sent once window is over with
+// the extras "times_seen", "first_seen" and "last_seen". It reduces the
+// volume of error storms while keeping their signal. Packets are identical
+// when they have the same fingerprint, or when no fingerprint is set, the
+// same level, logger, culprit, message and exceptions. Capture returns the
+// event id of the first packet for the collapsed ones, whose channels
+// receive nil right away. Synchronous clients don't aggregate. A window of
+// zero disables aggregation and sends the packets held back.
+func (client *Client) SetAggregation(window time.Duration) {
+	client.mu.Lock()
+	client.aggregationWindow = window
+	client.mu.Unlock()
+
+	if window <= 0 {
+		client.flushAggregates()
+	}
+}
+
+// SetAggregation collapses identical packets of the default *Client captured within window
+func SetAggregation(window time.Duration) { DefaultClient.SetAggregation(window) }
+
+// aggregationKey identifies identical packets
+func aggregationKey(packet *Packet) string {
+	if len(packet.Fingerprint) > 0 {
+		return "fingerprint\x00" + strings.Join(packet.Fingerprint, "\x00")
+	}
+	parts := []string{string(packet.Level), packet.Logger, packet.Culprit, packet.Message}
+	for _, inter := range packet.Interfaces {
+		switch e := inter.(type) {
+		case *Exception:
+			parts = append(parts, e.Module, e.Type, e.Value)
+		case *Exceptions:
+			for _, value := range e.Values {
+				parts = append(parts, value.Module, value.Type, value.Value)
+			}
+		case Exceptions:
+			for _, value := range e.Values {
+				parts = append(parts, value.Module, value.Type, value.Value)
+			}
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// aggregate holds back an initialized packet as the first of its group, or
+// collapses it into the first one, and returns the event id of the group.
+// The caller must have added packet to client.wg.
+func (client *Client) aggregate(packet *Packet, ch chan error, window time.Duration) EventID {
+	key := aggregationKey(packet)
+	seen := time.Time(packet.Timestamp)
+
+	aggregates := &client.aggregates
+	aggregates.mu.Lock()
+	if first, ok := aggregates.pending[key]; ok {
+		first.count++
+		first.last = seen
+		eventID := EventID(first.packet.EventID)
+		aggregates.mu.Unlock()
+
+		if packet.pooled {
+			ReleasePacket(packet)
+		}
+		ch <- nil
+		client.wg.Done()
+		return eventID
+	}
+
+	if aggregates.pending == nil {
+		aggregates.pending = make(map[string]*aggregatedPacket)
+	}
+	first := &aggregatedPacket{packet: packet, ch: ch, count: 1, first: seen, last: seen}
+	aggregates.pending[key] = first
+	eventID := EventID(packet.EventID)
+	aggregates.mu.Unlock()
+
+	time.AfterFunc(window, func() {
+		aggregates.mu.Lock()
+		if aggregates.pending[key] != first {
+			// already sent by flushAggregates
+			aggregates.mu.Unlock()
+			return
+		}
+		delete(aggregates.pending, key)
+		aggregates.mu.Unlock()
+		client.enqueueAggregated(first)
+	})
+	return eventID
+}
+
+// flushAggregates sends all packets held back, e.g. by Wait
+func (client *Client) flushAggregates() {
+	aggregates := &client.aggregates
+	aggregates.mu.Lock()
+	pending := aggregates.pending
+	aggregates.pending = nil
+	aggregates.mu.Unlock()
+
+	for _, first := range pending {
+		client.enqueueAggregated(first)
+	}
+}
+
+// enqueueAggregated records the occurrences of a group on its first packet and queues it
+func (client *Client) enqueueAggregated(first *aggregatedPacket) {
+	packet := first.packet
+	if first.count > 1 {
+		if packet.Extra == nil {
+			packet.Extra = Extra{}
+		}
+		packet.Extra["times_seen"] = first.count
+		packet.Extra["first_seen"] = first.first.UTC().Format(time.RFC3339Nano)
+		packet.Extra["last_seen"] = first.last.UTC().Format(time.RFC3339Nano)
+	}
+	client.enqueue(packet, first.ch)
+}
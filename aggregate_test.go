@@ -0,0 +1,64 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAggregation(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetAggregation(time.Hour)
+
+	var eventIDs []EventID
+	for i := 0; i < 3; i++ {
+		eventID, ch := client.Capture(NewPacket("storm", NewException(errors.New("timeout"), nil)), nil)
+		eventIDs = append(eventIDs, eventID)
+		if i > 0 {
+			if err := <-ch; err != nil {
+				t.Errorf("expected collapsed packets to succeed, got %v", err)
+			}
+		}
+	}
+	client.Capture(NewPacket("storm", NewException(errors.New("refused"), nil)), nil)
+	if eventIDs[1] != eventIDs[0] || eventIDs[2] != eventIDs[0] {
+		t.Errorf("expected the event id of the first packet, got %v", eventIDs)
+	}
+	if len(transport.packets) != 0 {
+		t.Fatalf("expected packets to be held back, got %d", len(transport.packets))
+	}
+
+	client.Wait()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	for _, packet := range transport.packets {
+		if EventID(packet.EventID) == eventIDs[0] {
+			if packet.Extra["times_seen"] != 3 || packet.Extra["first_seen"] == nil || packet.Extra["last_seen"] == nil {
+				t.Errorf("expected occurrences of the storm, got %v", packet.Extra)
+			}
+		} else if _, ok := packet.Extra["times_seen"]; ok {
+			t.Errorf("expected no occurrences of a single packet, got %v", packet.Extra)
+		}
+	}
+}
+
+func TestAggregationWindow(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetAggregation(10 * time.Millisecond)
+
+	_, ch := client.Capture(NewPacket("storm"), nil)
+	client.Capture(NewPacket("storm"), nil)
+	if err := <-ch; err != nil {
+		t.Fatal(err)
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 || transport.packets[0].Extra["times_seen"] != 2 {
+		t.Errorf("expected the storm to be sent once its window is over, got %v", transport.packets)
+	}
+}
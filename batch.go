@@ -0,0 +1,104 @@
+package raven
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchTransport is implemented by transports able to deliver several
+// packets in a single operation. When batching is enabled with
+// SetBatching, the worker hands coalesced packets to SendBatch, other
+// transports keep receiving them one by one through Send. SendBatch must
+// honor the cancellation and deadline of ctx, like TransportV2, and may
+// return a BatchError to report the outcome of every packet.
+type BatchTransport interface {
+	Transport
+	SendBatch(ctx context.Context, url, authHeader string, packets []*Packet) error
+}
+
+// BatchError holds the error of every packet of a partially failed batch, in
+// the order of the packets given to SendBatch.
+type BatchError []error
+
+func (e BatchError) Error() string {
+	var failed int
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("raven: %d of %d packets failed: %v", failed, len(e), first)
+}
+
+// SendBatch sends packets concurrently over the pooled connections of the
+// transport, bounded by ctx. Sentry accepts a single event per request, so
+// the batch doesn't save requests, it only spares waiting for one response
+// before sending the next packet.
+func (t *HTTPTransport) SendBatch(ctx context.Context, url, authHeader string, packets []*Packet) error {
+	errs := make(BatchError, len(packets))
+	var wg sync.WaitGroup
+	for i, packet := range packets {
+		wg.Add(1)
+		go func(i int, packet *Packet) {
+			defer wg.Done()
+			errs[i] = t.SendWithContext(ctx, url, authHeader, packet)
+		}(i, packet)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return errs
+		}
+	}
+	return nil
+}
+
+// SetBatching makes the worker of given client coalesce up to size queued
+// packets, waiting at most window for the batch to fill up, before handing
+// them to a BatchTransport at once. The WriterTransport writes a batch in a
+// single write, the HTTPTransport still sends one request per packet, but
+// concurrently. A size of 1 or less disables batching.
+func (client *Client) SetBatching(size int, window time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.batchSize = size
+	client.batchWindow = window
+}
+
+// SetBatching configures packet coalescing on the default *Client
+func SetBatching(size int, window time.Duration) { DefaultClient.SetBatching(size, window) }
+
+// collectBatch gathers packets following first from queue until the
+// batch is full, the batch window elapsed or the queue was closed.
+func (client *Client) collectBatch(queue chan *outgoingPacket, first *outgoingPacket) []*outgoingPacket {
+	client.mu.RLock()
+	size, window := client.batchSize, client.batchWindow
+	client.mu.RUnlock()
+
+	batch := []*outgoingPacket{first}
+	if size <= 1 {
+		return batch
+	}
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+	for len(batch) < size {
+		select {
+		case outgoingPacket, ok := <-queue:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, outgoingPacket)
+		case <-timer.C:
+			return batch
+		}
+	}
+	return batch
+}
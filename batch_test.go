@@ -0,0 +1,114 @@
+package raven
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchRecordingTransport struct {
+	recordingTransport
+	mu        sync.Mutex
+	batches   [][]*Packet
+	deadlines []bool
+}
+
+func (t *batchRecordingTransport) SendBatch(ctx context.Context, url, authHeader string, packets []*Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.batches = append(t.batches, packets)
+	_, deadline := ctx.Deadline()
+	t.deadlines = append(t.deadlines, deadline)
+	return nil
+}
+
+func TestSetBatching(t *testing.T) {
+	transport := &batchRecordingTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetBatching(3, time.Second)
+	client.SetSendTimeout(time.Minute)
+
+	var chs []chan error
+	for _, message := range []string{"a", "b", "c", "d"} {
+		_, ch := client.Capture(NewPacket(message), nil)
+		chs = append(chs, ch)
+	}
+	for _, ch := range chs {
+		if err := <-ch; err != nil {
+			t.Error(err)
+		}
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.batches) != 1 || len(transport.batches[0]) != 3 {
+		t.Fatalf("expected a single batch of 3 packets, got %v", transport.batches)
+	}
+	if !transport.deadlines[0] {
+		t.Error("expected the send timeout to bound the batch")
+	}
+	if len(transport.urls) != 1 {
+		t.Errorf("expected the remaining packet to be sent alone after the window, got %v", transport.urls)
+	}
+}
+
+func TestBatchingUnsupportedTransport(t *testing.T) {
+	transport := &recordingTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetBatching(2, time.Second)
+
+	client.Capture(NewPacket("a"), nil)
+	client.Capture(NewPacket("b"), nil)
+	client.Wait()
+
+	if len(transport.urls) != 2 {
+		t.Errorf("expected packets to be sent one by one, got %v", transport.urls)
+	}
+}
+
+func TestHTTPTransportSendBatch(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		if strings.Contains(string(body), `"message":"b"`) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := newClient(nil)
+	client.Transport = &HTTPTransport{Client: http.DefaultClient, Compression: CompressionNone}
+	client.url = server.URL + "/api/1/store/"
+	client.SetBatching(3, time.Second)
+
+	var chs []chan error
+	for _, message := range []string{"a", "b", "c"} {
+		_, ch := client.Capture(NewPacket(message), nil)
+		chs = append(chs, ch)
+	}
+	for i, ch := range chs {
+		err := <-ch
+		if failed := err != nil; failed != (i == 1) {
+			t.Errorf("packet %d: unexpected error %v", i, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 {
+		t.Errorf("expected one request per packet, got %d", requests)
+	}
+}
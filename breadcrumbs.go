@@ -0,0 +1,274 @@
+package raven
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxBreadcrumbs is how many breadcrumbs a client keeps by default
+const defaultMaxBreadcrumbs = 100
+
+// Breadcrumb records an event which happened before an error, e.g. a request
+// or a log message, see AddBreadcrumb - https://develop.sentry.dev/sdk/data-model/event-payloads/breadcrumbs/
+type Breadcrumb struct {
+	Timestamp Timestamp              `json:"timestamp"`
+	Type      string                 `json:"type,omitempty"`
+	Category  string                 `json:"category,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Level     Severity               `json:"level,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Breadcrumbs is the interface of the breadcrumbs of a packet, oldest first
+type Breadcrumbs struct {
+	Values []*Breadcrumb `json:"values"`
+}
+
+// Class provides name of implemented Sentry's interface
+func (b *Breadcrumbs) Class() string { return "breadcrumbs" }
+
+// AddBreadcrumb records breadcrumb in the context of given client, which
+// attaches its latest breadcrumbs, 100 by default, to every packet it
+// captures until ClearContext. The timestamp defaults to the current time.
+// A scrubbed copy is recorded, see scrubBreadcrumb.
+func (client *Client) AddBreadcrumb(breadcrumb *Breadcrumb) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if time.Time(breadcrumb.Timestamp).IsZero() {
+		now := time.Now
+		if client.clock != nil {
+			now = client.clock
+		}
+		breadcrumb.Timestamp = Timestamp(now())
+	}
+	client.context.addBreadcrumb(scrubBreadcrumb(breadcrumb, client.requestFilter), client.maxBreadcrumbs)
+}
+
+// AddBreadcrumb records breadcrumb in the context of the default *Client
+func AddBreadcrumb(breadcrumb *Breadcrumb) { DefaultClient.AddBreadcrumb(breadcrumb) }
+
+// SetMaxBreadcrumbs sets how many breadcrumbs given client keeps, zero disables them
+func (client *Client) SetMaxBreadcrumbs(max int) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.maxBreadcrumbs = max
+	if len(client.context.breadcrumbs) > max {
+		client.context.breadcrumbs = append([]*Breadcrumb(nil), client.context.breadcrumbs[len(client.context.breadcrumbs)-max:]...)
+	}
+}
+
+// SetMaxBreadcrumbs sets how many breadcrumbs the default *Client keeps
+func SetMaxBreadcrumbs(max int) { DefaultClient.SetMaxBreadcrumbs(max) }
+
+// HTTPBreadcrumb returns a breadcrumb of an outgoing or incoming request,
+// its level is error for a 5xx status and warning for a 4xx one.
+func HTTPBreadcrumb(method, url string, status int) *Breadcrumb {
+	level := INFO
+	switch {
+	case status >= 500:
+		level = ERROR
+	case status >= 400:
+		level = WARNING
+	}
+	data := map[string]interface{}{"method": method, "url": url}
+	if status != 0 {
+		data["status_code"] = status
+	}
+	return &Breadcrumb{Type: "http", Category: "http", Level: level, Data: data}
+}
+
+// QueryBreadcrumb returns a breadcrumb of a database query which took duration
+func QueryBreadcrumb(sql string, duration time.Duration) *Breadcrumb {
+	return &Breadcrumb{
+		Type:     "query",
+		Category: "query",
+		Message:  sql,
+		Level:    INFO,
+		Data:     map[string]interface{}{"duration_ms": float64(duration) / float64(time.Millisecond)},
+	}
+}
+
+// NavigationBreadcrumb returns a breadcrumb of a change of location, e.g. a redirect
+func NavigationBreadcrumb(from, to string) *Breadcrumb {
+	return &Breadcrumb{
+		Type:     "navigation",
+		Category: "navigation",
+		Level:    INFO,
+		Data:     map[string]interface{}{"from": from, "to": to},
+	}
+}
+
+// DefaultBreadcrumb returns a breadcrumb of the default type, e.g. a log message
+func DefaultBreadcrumb(level Severity, category, message string, data map[string]interface{}) *Breadcrumb {
+	return &Breadcrumb{Type: "default", Category: category, Message: message, Level: level, Data: data}
+}
+
+var (
+	// secretAssignment matches secret looking fields assigned in a message, e.g. password=hunter2 or "password": "hunter2"
+	secretAssignment = regexp.MustCompile(`(?i)([\w.-]*(?:password|passphrase|passwd|secret)[\w.-]*)(["']?\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s&,;]+)`)
+	// secretElement matches the content of secret looking XML elements, e.g. <password>hunter2</password>
+	secretElement = regexp.MustCompile(`(?i)(<[\w.:-]*(?:password|passphrase|passwd|secret)[\w.:-]*(?:\s[^>]*)?>)[^<]*`)
+	// sqlToken matches the string literals and words of a SQL statement
+	sqlToken = regexp.MustCompile(`'(?:[^']|'')*'|[\w$.]+`)
+)
+
+// scrubBreadcrumb returns a copy of breadcrumb with secrets masked like
+// request bodies are, in its message and data. Query crumbs have the string
+// and number literals of their statement replaced with ?, and http crumbs
+// have their headers and url filtered like the Http interface, see
+// SetHeaderDenylist and SetQueryAllowlist.
+func scrubBreadcrumb(breadcrumb *Breadcrumb, filter httpFilter) *Breadcrumb {
+	scrubbed := *breadcrumb
+	category := strings.ToLower(breadcrumb.Category)
+	switch {
+	case breadcrumb.Type == "query" || category == "query" || category == "db" || strings.HasPrefix(category, "db."):
+		scrubbed.Message = redactSQL(breadcrumb.Message)
+	default:
+		scrubbed.Message = scrubMessage(breadcrumb.Message)
+	}
+	if breadcrumb.Data == nil {
+		return &scrubbed
+	}
+	scrubbed.Data = scrubData(breadcrumb.Data)
+	if breadcrumb.Type == "http" || category == "http" {
+		for key, value := range scrubbed.Data {
+			switch key {
+			case "url":
+				if rawURL, ok := value.(string); ok {
+					scrubbed.Data[key] = filter.url(rawURL)
+				}
+			case "headers":
+				scrubbed.Data[key] = filter.headers(value)
+			}
+		}
+	}
+	return &scrubbed
+}
+
+// scrubMessage masks the values of secret looking fields assigned in message
+func scrubMessage(message string) string {
+	return secretAssignment.ReplaceAllString(message, "$1$2********")
+}
+
+// redactSQL replaces the string and number literals of statement with ?
+func redactSQL(statement string) string {
+	return sqlToken.ReplaceAllStringFunc(statement, func(token string) string {
+		if token[0] == '\'' {
+			return "?"
+		}
+		if token[0] >= '0' && token[0] <= '9' {
+			if _, err := strconv.ParseFloat(token, 64); err == nil {
+				return "?"
+			}
+		}
+		return token
+	})
+}
+
+// scrubData returns a copy of data with the values of secret looking keys
+// masked and secrets in strings scrubbed like messages
+func scrubData(data map[string]interface{}) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if isSecretField(key) {
+			scrubbed[key] = "********"
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			scrubbed[key] = scrubMessage(v)
+		case map[string]interface{}:
+			scrubbed[key] = scrubData(v)
+		default:
+			scrubbed[key] = value
+		}
+	}
+	return scrubbed
+}
+
+// url filters the query and masks the password of rawURL
+func (f httpFilter) url(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), filteredValue)
+		}
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = f.query(u.Query()).Encode()
+	}
+	return u.String()
+}
+
+// headers replaces the values of filtered headers with [Filtered]
+func (f httpFilter) headers(value interface{}) interface{} {
+	switch headers := value.(type) {
+	case map[string]string:
+		filtered := make(map[string]string, len(headers))
+		for name, v := range headers {
+			if !f.header(name) {
+				v = filteredValue
+			}
+			filtered[name] = v
+		}
+		return filtered
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(headers))
+		for name, v := range headers {
+			if !f.header(name) {
+				v = filteredValue
+			}
+			filtered[name] = v
+		}
+		return filtered
+	case http.Header:
+		filtered := make(map[string]string, len(headers))
+		for name := range headers {
+			v := headers.Get(name)
+			if !f.header(name) {
+				v = filteredValue
+			}
+			filtered[name] = v
+		}
+		return filtered
+	}
+	return value
+}
+
+func (c *clientContext) addBreadcrumb(breadcrumb *Breadcrumb, max int) {
+	if max <= 0 {
+		return
+	}
+	if len(c.breadcrumbs) >= max {
+		// drop the oldest ones, the slice is never shared
+		n := copy(c.breadcrumbs, c.breadcrumbs[len(c.breadcrumbs)-max+1:])
+		c.breadcrumbs = c.breadcrumbs[:n]
+	}
+	c.breadcrumbs = append(c.breadcrumbs, breadcrumb)
+}
+
+// breadcrumbsInterface returns a copy of the breadcrumbs of the context, nil without any
+func (c *clientContext) breadcrumbsInterface() Interface {
+	if len(c.breadcrumbs) == 0 {
+		return nil
+	}
+	return &Breadcrumbs{Values: append([]*Breadcrumb(nil), c.breadcrumbs...)}
+}
+
+// hasBreadcrumbs tells whether packet already carries breadcrumbs
+func hasBreadcrumbs(packet *Packet) bool {
+	for _, inter := range packet.Interfaces {
+		if inter != nil && inter.Class() == "breadcrumbs" {
+			return true
+		}
+	}
+	return false
+}
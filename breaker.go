@@ -0,0 +1,110 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreakerTransport while Sentry is
+// considered unavailable, packets failing with it are passed to the
+// client's DropHandler.
+var ErrCircuitOpen = errors.New("raven: circuit breaker open, packet dropped")
+
+// defaultBreakerThreshold applies to a CircuitBreakerTransport without a positive Threshold
+const defaultBreakerThreshold = 5
+
+// CircuitBreakerTransport stops delivering packets after Threshold
+// consecutive failures of the wrapped Transport, so a dead Sentry endpoint
+// can't stack up request timeouts in the worker. While open, it fails fast
+// and lets a single probe through every ProbeInterval, closing again as soon
+// as a probe succeeds.
+type CircuitBreakerTransport struct {
+	Transport Transport
+
+	// Threshold of consecutive failures opening the circuit, 5 when it isn't positive
+	Threshold int
+
+	// ProbeInterval between delivery attempts while the circuit is open
+	ProbeInterval time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreakerTransport wraps transport with a circuit breaker opening
+// after threshold consecutive failures, 5 when threshold isn't positive, and
+// probing every probeInterval.
+func NewCircuitBreakerTransport(transport Transport, threshold int, probeInterval time.Duration) *CircuitBreakerTransport {
+	return &CircuitBreakerTransport{
+		Transport:     transport,
+		Threshold:     threshold,
+		ProbeInterval: probeInterval,
+	}
+}
+
+// Open reports whether the circuit is open and packets are being dropped
+func (t *CircuitBreakerTransport) Open() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failures >= t.threshold()
+}
+
+func (t *CircuitBreakerTransport) threshold() int {
+	if t.Threshold <= 0 {
+		return defaultBreakerThreshold
+	}
+	return t.Threshold
+}
+
+// Unwrap returns the transport guarded by the breaker
+func (t *CircuitBreakerTransport) Unwrap() Transport { return t.Transport }
+
+// Send delivers the packet with the wrapped Transport unless the circuit is open
+func (t *CircuitBreakerTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx
+func (t *CircuitBreakerTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	t.mu.Lock()
+	if t.failures >= t.threshold() {
+		if t.probing || time.Since(t.openedAt) < t.ProbeInterval {
+			t.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		t.probing = true
+	}
+	t.mu.Unlock()
+
+	err := AsTransportV2(t.Transport).SendWithContext(ctx, url, authHeader, packet)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.probing = false
+	if err == nil {
+		t.failures = 0
+		return nil
+	}
+	t.failures++
+	if t.failures >= t.threshold() {
+		t.openedAt = time.Now()
+	}
+	return err
+}
+
+// SetCircuitBreaker wraps the transport of given client in a
+// CircuitBreakerTransport, see NewCircuitBreakerTransport.
+func (client *Client) SetCircuitBreaker(threshold int, probeInterval time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Transport = NewCircuitBreakerTransport(client.Transport, threshold, probeInterval)
+}
+
+// SetCircuitBreaker wraps the transport of the default *Client in a CircuitBreakerTransport
+func SetCircuitBreaker(threshold int, probeInterval time.Duration) {
+	DefaultClient.SetCircuitBreaker(threshold, probeInterval)
+}
@@ -0,0 +1,68 @@
+package raven
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	url := "https://example.com/api/1/store/"
+	rt := &recordingTransport{fail: map[string]bool{url: true}}
+	transport := NewCircuitBreakerTransport(rt, 2, time.Hour)
+
+	transport.Send(url, "", &Packet{})
+	transport.Send(url, "", &Packet{})
+	if !transport.Open() {
+		t.Fatal("expected circuit to open after 2 failures")
+	}
+
+	rt.urls = nil
+	if err := transport.Send(url, "", &Packet{}); err != ErrCircuitOpen {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if len(rt.urls) != 0 {
+		t.Error("open circuit should not reach the wrapped transport")
+	}
+
+	// a successful probe closes the circuit
+	rt.fail = nil
+	transport.ProbeInterval = 0
+	if err := transport.Send(url, "", &Packet{}); err != nil {
+		t.Errorf("expected probe to succeed, got %v", err)
+	}
+	if transport.Open() {
+		t.Error("expected circuit to close after a successful probe")
+	}
+}
+
+func TestCircuitBreakerDefaultThreshold(t *testing.T) {
+	url := "https://example.com/api/1/store/"
+	transport := NewCircuitBreakerTransport(&recordingTransport{fail: map[string]bool{url: true}}, 0, time.Hour)
+	if transport.Open() {
+		t.Fatal("expected a closed circuit before any failure")
+	}
+	for i := 0; i < defaultBreakerThreshold; i++ {
+		transport.Send(url, "", &Packet{})
+	}
+	if !transport.Open() {
+		t.Errorf("expected circuit to open after %d failures", defaultBreakerThreshold)
+	}
+}
+
+func TestCircuitBreakerDropHandler(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{fail: map[string]bool{"": true}}
+	client.SetCircuitBreaker(1, time.Hour)
+
+	var dropped []*Packet
+	client.DropHandler = func(packet *Packet) {
+		dropped = append(dropped, packet)
+	}
+
+	client.CaptureMessageAndWait("first", nil)
+	client.CaptureMessageAndWait("second", nil)
+
+	if len(dropped) != 1 || dropped[0].Message != "second" {
+		t.Errorf("expected only the second packet to be dropped, got %v", dropped)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build go1.18
+// +build go1.18
+
+package raven
+
+import "runtime/debug"
+
+// ReleaseFromBuildInfo returns the release name of the running binary, see
+// FormatRelease, from the version of its main module and the VCS revision it
+// was built from, which the go command embeds since Go 1.18. Binaries built
+// from a modified checkout have ".dirty" appended to the revision.
+func ReleaseFromBuildInfo(pkg string) (string, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ErrNoBuildInfo
+	}
+	version := info.Main.Version
+	if version == "(devel)" {
+		version = ""
+	}
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	release := FormatRelease(pkg, version, revision)
+	if modified && revision != "" {
+		release += ".dirty"
+	}
+	return release, ValidateRelease(release)
+}
@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package raven
+
+// ReleaseFromBuildInfo returns ErrNoBuildInfo before Go 1.18, which
+// introduced the VCS revision in the build information of binaries
+func ReleaseFromBuildInfo(pkg string) (string, error) {
+	return "", ErrNoBuildInfo
+}
@@ -0,0 +1,102 @@
+package raven
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cgroupRoot is where the cgroup hierarchy of the container is mounted
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupUnlimitedMemory is the smallest memory limit cgroup v1 uses for no limit
+const cgroupUnlimitedMemory = 1 << 62
+
+// cgroupLimits holds the CPU quota in cores and memory limit in bytes of the
+// cgroup of the process, zero when it has none.
+type cgroupLimits struct {
+	version int
+	cpu     float64
+	memory  uint64
+}
+
+// readCgroupLimits reads the limits of a cgroup v2 unified hierarchy, falling
+// back to the cpu and memory controllers of cgroup v1.
+func readCgroupLimits(root string) (limits cgroupLimits, ok bool) {
+	if cpuMax, err := readCgroupFile(root, "cpu.max"); err == nil {
+		limits.version = 2
+		// "<quota> <period>", quota is "max" without limit
+		if fields := strings.Fields(cpuMax); len(fields) == 2 {
+			limits.cpu = cgroupCPU(fields[0], fields[1])
+		}
+		if memoryMax, err := readCgroupFile(root, "memory.max"); err == nil {
+			limits.memory, _ = strconv.ParseUint(memoryMax, 10, 64)
+		}
+		return limits, true
+	}
+
+	quota, err := readCgroupFile(root, "cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return limits, false
+	}
+	limits.version = 1
+	if period, err := readCgroupFile(root, "cpu/cpu.cfs_period_us"); err == nil {
+		limits.cpu = cgroupCPU(quota, period)
+	}
+	if memory, err := readCgroupFile(root, "memory/memory.limit_in_bytes"); err == nil {
+		limits.memory, _ = strconv.ParseUint(memory, 10, 64)
+		if limits.memory >= cgroupUnlimitedMemory {
+			limits.memory = 0
+		}
+	}
+	return limits, true
+}
+
+func readCgroupFile(root, name string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, name))
+	return strings.TrimSpace(string(data)), err
+}
+
+// cgroupCPU converts a CFS quota and period to cores, -1 and max mean no limit
+func cgroupCPU(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+var (
+	runtimeOnce    sync.Once
+	runtimeContext map[string]interface{}
+)
+
+// RuntimeContextCollector attaches the Go version and the CPU quota and
+// memory limit of the container as the runtime context, since GOMAXPROCS and
+// NumCPU misrepresent the capacity of a container. It is enabled by default
+// and only reads the cgroup limits once.
+func RuntimeContextCollector(packet *Packet) (string, interface{}) {
+	runtimeOnce.Do(func() {
+		runtimeContext = map[string]interface{}{
+			"name":    "go",
+			"version": runtime.Version(),
+		}
+		if limits, ok := readCgroupLimits(cgroupRoot); ok {
+			runtimeContext["cgroup_version"] = limits.version
+			if limits.cpu > 0 {
+				runtimeContext["cpu_limit"] = limits.cpu
+			}
+			if limits.memory > 0 {
+				runtimeContext["memory_limit"] = limits.memory
+			}
+		}
+	})
+	return "runtime", runtimeContext
+}
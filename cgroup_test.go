@@ -0,0 +1,73 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCgroupFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "raven-cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadCgroupLimits(t *testing.T) {
+	testCases := []struct {
+		Files    map[string]string
+		Expected cgroupLimits
+		Ok       bool
+	}{
+		{map[string]string{}, cgroupLimits{}, false},
+		{map[string]string{"cpu.max": "150000 100000\n", "memory.max": "536870912\n"}, cgroupLimits{2, 1.5, 536870912}, true},
+		{map[string]string{"cpu.max": "max 100000\n", "memory.max": "max\n"}, cgroupLimits{2, 0, 0}, true},
+		{map[string]string{
+			"cpu/cpu.cfs_quota_us":         "200000\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "1073741824\n",
+		}, cgroupLimits{1, 2, 1073741824}, true},
+		{map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, cgroupLimits{1, 0, 0}, true},
+	}
+
+	for i, test := range testCases {
+		dir := writeCgroupFiles(t, test.Files)
+		limits, ok := readCgroupLimits(dir)
+		os.RemoveAll(dir)
+		if ok != test.Ok || limits != test.Expected {
+			t.Errorf("Case [%d]: expected %+v %v, got %+v %v", i, test.Expected, test.Ok, limits, ok)
+		}
+	}
+}
+
+func TestRuntimeContextMergesMemStats(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+	client.SetContextCollectors(RuntimeContextCollector, MemStatsContextCollector)
+
+	packet := &Packet{Message: "foo", Level: ERROR}
+	_, ch := client.Capture(packet, nil)
+	<-ch
+
+	runtimeContext := packet.Contexts["runtime"].(map[string]interface{})
+	if runtimeContext["name"] != "go" || runtimeContext["heap_inuse"] == nil {
+		t.Errorf("expected memory stats merged into the runtime context: %+v", runtimeContext)
+	}
+	_, shared := RuntimeContextCollector(packet)
+	if shared.(map[string]interface{})["heap_inuse"] != nil {
+		t.Error("merging should not modify the context of the collector")
+	}
+}
@@ -0,0 +1,115 @@
+package raven
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runOutput receives the errors and panics of Run, replaced in tests
+var runOutput io.Writer = os.Stderr
+
+// HandleCLIError captures err, the failure of a command line invocation with
+// args, e.g. os.Args, and waits for it to be sent before the caller exits.
+// It is meant for the ExitErrHandler of urfave/cli apps, which otherwise
+// exit before the event left the queue:
+//
+//	app.ExitErrHandler = func(c *cli.Context, err error) {
+//		raven.HandleCLIError(err, os.Args)
+//		cli.HandleExitCoder(err)
+//	}
+//
+// Errors with a zero exit code are ignored. The arguments are attached as
+// extra with the values of secret looking flags masked.
+func (client *Client) HandleCLIError(err error, args []string) EventID {
+	if err == nil {
+		return ""
+	}
+	if coder, ok := err.(interface {
+		ExitCode() int
+	}); ok && coder.ExitCode() == 0 {
+		return ""
+	}
+
+	tags := map[string]string{}
+	if len(args) > 0 {
+		tags["cli.command"] = args[0]
+	}
+	eventID := client.CaptureError(WrapWithExtra(err, map[string]interface{}{
+		"cli.args": scrubArgs(args),
+	}), tags)
+	client.waitTimeout(cliFlushTimeout)
+	return eventID
+}
+
+// HandleCLIError captures the failure of a command line invocation with the default *Client
+func HandleCLIError(err error, args []string) EventID {
+	return DefaultClient.HandleCLIError(err, args)
+}
+
+// Run runs main, the logic of a command line program or batch job, and
+// returns its exit code once the error it returned, or its panic, was
+// captured and sent, waiting at most 2 seconds:
+//
+//	func main() {
+//		os.Exit(raven.Run(run))
+//	}
+//
+// The code is 0 when main succeeds, 2 when it panics like the Go runtime,
+// the ExitCode of the error when it has one and 1 otherwise. Errors with a
+// zero exit code aren't captured, the others and panics are printed to
+// stderr.
+func (client *Client) Run(main func() error) int {
+	var err error
+	if rval, _ := client.CapturePanic(func() { err = main() }, nil); rval != nil {
+		fmt.Fprintf(runOutput, "panic: %v\n", rval)
+		client.waitTimeout(cliFlushTimeout)
+		return 2
+	}
+	if err == nil {
+		client.waitTimeout(cliFlushTimeout)
+		return 0
+	}
+
+	code := 1
+	if coder, ok := err.(interface {
+		ExitCode() int
+	}); ok {
+		code = coder.ExitCode()
+	}
+	if code != 0 {
+		client.CaptureError(err, nil)
+		fmt.Fprintln(runOutput, err)
+	}
+	client.waitTimeout(cliFlushTimeout)
+	return code
+}
+
+// Run runs main with the default *Client and returns its exit code, see Client.Run
+func Run(main func() error) int { return DefaultClient.Run(main) }
+
+// scrubArgs masks the values of flags looking like secrets, given either as
+// --flag=value or as --flag value.
+func scrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	copy(scrubbed, args)
+	for i := 0; i < len(scrubbed); i++ {
+		arg := scrubbed[i]
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if eq := strings.Index(name, "="); eq >= 0 {
+			if isSecretField(name[:eq]) {
+				scrubbed[i] = arg[:len(arg)-len(name)] + name[:eq+1] + "********"
+			}
+			continue
+		}
+		if isSecretField(name) && i+1 < len(scrubbed) && !strings.HasPrefix(scrubbed[i+1], "-") {
+			scrubbed[i+1] = "********"
+			i++
+		}
+	}
+	return scrubbed
+}
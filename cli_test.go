@@ -0,0 +1,82 @@
+package raven
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type exitError struct {
+	error
+	code int
+}
+
+func (e exitError) ExitCode() int { return e.code }
+
+func TestHandleCLIError(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	args := []string{"deploy", "--password", "hunter2", "--secret=abc", "-v", "prod"}
+	if client.HandleCLIError(exitError{errors.New("ok"), 0}, args) != "" {
+		t.Error("zero exit codes should not be captured")
+	}
+	if client.HandleCLIError(exitError{errors.New("deploy failed"), 1}, args) == "" {
+		t.Error("expected an event id")
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 {
+		t.Fatalf("expected 1 flushed packet, got %d", len(transport.packets))
+	}
+	expected := []string{"deploy", "--password", "********", "--secret=********", "-v", "prod"}
+	if !reflect.DeepEqual(transport.packets[0].Extra["cli.args"], expected) {
+		t.Errorf("incorrect args extra %+v", transport.packets[0].Extra["cli.args"])
+	}
+}
+
+func TestRun(t *testing.T) {
+	var output bytes.Buffer
+	runOutput = &output
+	defer func() { runOutput = os.Stderr }()
+
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	testCases := []struct {
+		main    func() error
+		code    int
+		message string
+	}{
+		{func() error { return nil }, 0, ""},
+		{func() error { return errors.New("failed") }, 1, "failed"},
+		{func() error { return exitError{errors.New("usage"), 3} }, 3, "usage"},
+		{func() error { return exitError{errors.New("help"), 0} }, 0, ""},
+		{func() error { panic("crashed") }, 2, "crashed"},
+	}
+	for _, test := range testCases {
+		transport.packets = nil
+		output.Reset()
+		if code := client.Run(test.main); code != test.code {
+			t.Errorf("expected exit code %d, got %d", test.code, code)
+		}
+		if test.message == "" {
+			if len(transport.packets) != 0 || output.Len() != 0 {
+				t.Errorf("expected no event, got %d and %q", len(transport.packets), output.String())
+			}
+			continue
+		}
+		if len(transport.packets) != 1 || transport.packets[0].Message != test.message {
+			t.Errorf("expected an event %q, got %+v", test.message, transport.packets)
+		}
+		if !strings.Contains(output.String(), test.message) {
+			t.Errorf("expected %q to be printed, got %q", test.message, output.String())
+		}
+	}
+}
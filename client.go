@@ -3,7 +3,7 @@ package raven
 
 import (
 	"bytes"
-	"compress/zlib"
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/hex"
@@ -12,15 +12,18 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	mrand "math/rand"
+	"net"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/certifi/gocertifi"
@@ -53,9 +56,6 @@ const (
 	FATAL   = Severity("fatal")
 )
 
-// Logger used in all internal log calls which can be enabled with SetDebug(true) function call
-var debugLogger = log.New(ioutil.Discard, "", 0)
-
 // Timestamp holds the creation time of a Packet
 type Timestamp time.Time
 
@@ -93,7 +93,9 @@ type Culpriter interface {
 	Culprit() string
 }
 
-// Transport used in Capture calls that handles communication with the Sentry servers
+// Transport used in Capture calls that handles communication with the Sentry servers.
+// Send is only ever called from the client's worker goroutine, which is where
+// packets get serialized, so it never adds latency to the capturing goroutine.
 type Transport interface {
 	Send(url, authHeader string, packet *Packet) error
 }
@@ -174,6 +176,7 @@ type Packet struct {
 	// Optional
 	Platform    string            `json:"platform,omitempty"`
 	Culprit     string            `json:"culprit,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
 	ServerName  string            `json:"server_name,omitempty"`
 	Release     string            `json:"release,omitempty"`
 	Environment string            `json:"environment,omitempty"`
@@ -181,8 +184,24 @@ type Packet struct {
 	Modules     map[string]string `json:"modules,omitempty"`
 	Fingerprint []string          `json:"fingerprint,omitempty"`
 	Extra       Extra             `json:"extra,omitempty"`
+	Contexts    Contexts          `json:"contexts,omitempty"`
+
+	// StartTimestamp and TimeSpent in milliseconds time the operation which
+	// failed, see SetTiming
+	StartTimestamp *Timestamp `json:"start_timestamp,omitempty"`
+	TimeSpent      int64      `json:"time_spent,omitempty"`
 
 	Interfaces []Interface `json:"-"`
+
+	// raw holds an already serialized packet, e.g. one replayed from a Spool
+	raw []byte
+
+	// pooled packets are returned to packetPool once sent, see AcquirePacket
+	pooled  bool
+	buffers packetBuffers
+
+	// dsc is sent in the trace header of envelopes carrying the packet
+	dsc DynamicSamplingContext
 }
 
 // NewPacket constructs a packet with the specified message and interfaces.
@@ -213,8 +232,6 @@ func NewPacketWithExtra(message string, extra Extra, interfaces ...Interface) *P
 func setExtraDefaults(extra Extra) Extra {
 	extra["runtime.Version"] = runtime.Version()
 	extra["runtime.NumCPU"] = runtime.NumCPU()
-	extra["runtime.GOMAXPROCS"] = runtime.GOMAXPROCS(0) // 0 just returns the current value
-	extra["runtime.NumGoroutine"] = runtime.NumGoroutine()
 	return extra
 }
 
@@ -241,12 +258,14 @@ func (packet *Packet) Init(project string) error {
 		packet.Logger = "root"
 	}
 	if packet.ServerName == "" {
-		packet.ServerName = hostname
+		packet.ServerName = Hostname()
 	}
 	if packet.Platform == "" {
 		packet.Platform = "go"
 	}
 
+	// The culprit is the innermost in app frame of a stacktrace, or else
+	// the transaction of the packet
 	if packet.Culprit == "" {
 		for _, inter := range packet.Interfaces {
 			if c, ok := inter.(Culpriter); ok {
@@ -257,10 +276,25 @@ func (packet *Packet) Init(project string) error {
 			}
 		}
 	}
+	if packet.Culprit == "" {
+		packet.Culprit = packet.Transaction
+	}
 
 	return nil
 }
 
+// SetTiming records that the operation which failed started at start and
+// ran for duration, e.g. with the RequestTiming of a request, so that slow
+// failures can be told apart. A zero start is ignored.
+func (packet *Packet) SetTiming(start time.Time, duration time.Duration) {
+	if start.IsZero() {
+		return
+	}
+	timestamp := Timestamp(start)
+	packet.StartTimestamp = &timestamp
+	packet.TimeSpent = int64(duration / time.Millisecond)
+}
+
 // AddTags appends new tags to the existing ones
 func (packet *Packet) AddTags(tags map[string]string) {
 	for k, v := range tags {
@@ -283,10 +317,29 @@ func uuid() (string, error) {
 
 // JSON encodes packet into JSON format that will be sent to the server
 func (packet *Packet) JSON() ([]byte, error) {
-	packetJSON, err := json.Marshal(packet)
-	if err != nil {
+	buf := jsonBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer jsonBufferPool.Put(buf)
+
+	if err := packet.writeJSON(buf); err != nil {
 		return nil, err
 	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// jsonBufferPool recycles the buffers used to encode packets
+var jsonBufferPool = sync.Pool{
+	New: func() interface{} { return &bytes.Buffer{} },
+}
+
+// writeJSON streams the packet attributes followed by its interfaces, keyed
+// by their class, into w as a single JSON object. One encoder writes every
+// part into a pooled buffer which is flushed to w after each of them.
+func (packet *Packet) writeJSON(w io.Writer) error {
+	if packet.raw != nil {
+		_, err := w.Write(packet.raw)
+		return err
+	}
 
 	interfaces := make(map[string]Interface, len(packet.Interfaces))
 	for _, inter := range packet.Interfaces {
@@ -295,27 +348,58 @@ func (packet *Packet) JSON() ([]byte, error) {
 		}
 	}
 
-	if len(interfaces) > 0 {
-		interfaceJSON, err := json.Marshal(interfaces)
-		if err != nil {
-			return nil, err
+	buf := jsonBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer jsonBufferPool.Put(buf)
+	enc := json.NewEncoder(buf)
+
+	if err := enc.Encode(packet); err != nil {
+		return err
+	}
+	// Encode terminates the object with "}\n", leave it open for the interfaces
+	buf.Truncate(buf.Len() - 2)
+	if _, err := buf.WriteTo(w); err != nil {
+		return err
+	}
+
+	// Keep the key order of a marshaled map[string]Interface
+	classes := make([]string, 0, len(interfaces))
+	for class := range interfaces {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	for _, class := range classes {
+		buf.WriteByte(',')
+		if err := enc.Encode(class); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(interfaces[class]); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
 		}
-		packetJSON[len(packetJSON)-1] = ','
-		packetJSON = append(packetJSON, interfaceJSON[1:]...)
 	}
 
-	return packetJSON, nil
+	_, err := w.Write([]byte{'}'})
+	return err
 }
 
-type context struct {
-	user *User
-	http *Http
-	tags map[string]string
+type clientContext struct {
+	user        *User
+	http        *Http
+	tags        map[string]string
+	transaction string
+	breadcrumbs []*Breadcrumb
 }
 
-func (c *context) setUser(u *User) { c.user = u }
-func (c *context) setHttp(h *Http) { c.http = h }
-func (c *context) setTags(t map[string]string) {
+func (c *clientContext) setUser(u *User) { c.user = u }
+func (c *clientContext) setHttp(h *Http) { c.http = h }
+func (c *clientContext) setTags(t map[string]string) {
 	if c.tags == nil {
 		c.tags = make(map[string]string)
 	}
@@ -323,32 +407,36 @@ func (c *context) setTags(t map[string]string) {
 		c.tags[k] = v
 	}
 }
-func (c *context) clear() {
+func (c *clientContext) setTransaction(name string) { c.transaction = name }
+func (c *clientContext) clear() {
 	c.user = nil
 	c.http = nil
 	c.tags = nil
+	c.transaction = ""
+	c.breadcrumbs = nil
 }
 
-// Return a list of interfaces to be used in appending with the rest
-func (c *context) interfaces() []Interface {
-	len, i := 0, 0
+// Return a list of interfaces to be used in appending with the rest, copies
+// of the user and http of the context so that packets never share them
+func (c *clientContext) interfaces() []Interface {
+	interfaces := make([]Interface, 0, 2)
 	if c.user != nil {
-		len++
+		user := *c.user
+		interfaces = append(interfaces, &user)
 	}
 	if c.http != nil {
-		len++
-	}
-	interfaces := make([]Interface, len)
-	if c.user != nil {
-		interfaces[i] = c.user
-		i++
-	}
-	if c.http != nil {
-		interfaces[i] = c.http
+		interfaces = append(interfaces, c.http.clone())
 	}
 	return interfaces
 }
 
+// contextInterfaces snapshots the interfaces of the context of given client
+func (client *Client) contextInterfaces() []Interface {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.context.interfaces()
+}
+
 // MaxQueueBuffer the maximum number of packets that will be buffered waiting to be delivered.
 // Packets will be dropped if the buffer is full. Used by NewClient.
 var MaxQueueBuffer = 100
@@ -357,11 +445,11 @@ func SetMaxQueueBuffer(maxCount int) {
 	MaxQueueBuffer = maxCount
 }
 
-func newTransport() Transport {
-	t := &HTTPTransport{}
+func newTransport(logger Logger) Transport {
+	t := &HTTPTransport{Logger: logger}
 	rootCAs, err := gocertifi.CACerts()
 	if err != nil {
-		debugLogger.Println("failed to load root TLS certificates:", err)
+		t.logger().Errorf("failed to load root TLS certificates: %v", err)
 	} else {
 		t.Client = &http.Client{
 			Transport: &http.Transport{
@@ -375,22 +463,82 @@ func newTransport() Transport {
 }
 
 func newClient(tags map[string]string) *Client {
+	client := newBareClient(tags)
+	client.setFromEnv()
+	return client
+}
+
+// newBareClient creates a client ignoring the environment
+func newBareClient(tags map[string]string) *Client {
+	logger := Logger(noopLogger{})
 	client := &Client{
-		Transport:  newTransport(),
+		Transport:  newTransport(logger),
 		Tags:       tags,
-		context:    &context{},
+		context:    &clientContext{},
+		logger:     logger,
 		sampleRate: 1.0,
 		queue:      make(chan *outgoingPacket, MaxQueueBuffer),
+
+		extraCollectors:   []ExtraCollector{RuntimeExtraCollector},
+		contextCollectors: []ContextCollector{DeviceContextCollector, RuntimeContextCollector},
+		requestFilter:     httpFilter{headerDeny: DefaultHeaderDenylist},
+		logRateLimit:      defaultLogRateLimit,
+		maxBreadcrumbs:    defaultMaxBreadcrumbs,
 	}
+	return client
+}
+
+// setFromEnv configures client from the SENTRY_* environment variables and
+// the platform it runs on
+func (client *Client) setFromEnv() {
 	err := client.SetDSN(os.Getenv("SENTRY_DSN"))
 
 	if err != nil {
-		debugLogger.Println("incorrect DSN", err)
+		client.Logger().Errorf("incorrect DSN: %v", err)
 	}
 
 	client.SetRelease(os.Getenv("SENTRY_RELEASE"))
 	client.SetEnvironment(os.Getenv("SENTRY_ENVIRONMENT"))
-	return client
+	client.setTagsFromEnv()
+	client.setTracingFromEnv()
+	if enabled, spotlightURL := spotlightFromEnv(); enabled {
+		client.SetSpotlight(true, spotlightURL)
+	}
+	if k8s := detectKubernetes(); k8s != nil {
+		client.setKubernetes(k8s)
+	}
+	if serverless := detectServerless(); serverless != nil {
+		client.setServerless(serverless)
+	}
+	if name := os.Getenv("SENTRY_SERVER_NAME"); name != "" {
+		client.SetServerName(name)
+	}
+}
+
+// setTagsFromEnv adds the tags of SENTRY_TAGS, e.g.
+// "region=eu-west-1,team=payments", to the tags of client not set yet, so
+// that platforms can stamp deployment metadata on every event.
+func (client *Client) setTagsFromEnv() {
+	value := os.Getenv("SENTRY_TAGS")
+	if value == "" {
+		return
+	}
+	tags := make(map[string]string, len(client.Tags))
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			client.Logger().Errorf("incorrect SENTRY_TAGS: %q is not a key=value pair", pair)
+			continue
+		}
+		tags[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	for key, value := range client.Tags {
+		tags[key] = value
+	}
+	client.Tags = tags
 }
 
 // New constructs a new Sentry client instance
@@ -418,30 +566,129 @@ func NewClient(dsn string, tags map[string]string) (*Client, error) {
 // by calling NewClient. Modification of fields concurrently with Send or after
 // calling Report for the first time is not thread-safe.
 type Client struct {
+	// Tags are added to every packet. The map must not be modified once the
+	// client is in use, SetTag and RemoveTag replace it safely instead.
 	Tags map[string]string
 
 	Transport Transport
 
-	// DropHandler is called when a packet is dropped because the buffer is full.
+	// DropHandler is called when a packet is dropped because the buffer is full
+	// or because the circuit breaker of the transport is open.
 	DropHandler func(*Packet)
 
+	// DropHandlerWithReason is called for every packet dropped by Capture or
+	// the worker, along with the reason why it was dropped.
+	DropHandlerWithReason func(*DroppedPacket)
+
+	// InternalErrorHandler is called for every error of the SDK: failed
+	// serializations, failed deliveries and dropped packets, so that they can
+	// be counted instead of being lost in the channels returned by Capture.
+	// It is called synchronously from the worker, from the goroutines sending
+	// envelopes and from Capture itself for packets dropped there, e.g.
+	// sampled, ignored, over the queue size or discarded by BeforeSend. It
+	// must not block, and must not capture events, which could recurse.
+	InternalErrorHandler func(*InternalError)
+
+	// BeforeSend is called by Capture with every initialized packet before it
+	// is queued, e.g. to scrub it. It may return a modified packet, or nil to
+	// drop it with DropBeforeSendNil.
+	BeforeSend func(*Packet) *Packet
+
 	// Context that will get appending to all packets
-	context *context
+	context *clientContext
+
+	// Receives internal diagnostics, see SetLogger and SetDebug
+	logger Logger
 
 	mu          sync.RWMutex
+	dsn         *Dsn
 	url         string
 	projectID   string
+	publicKey   string
 	authHeader  string
 	release     string
 	environment string
 	sampleRate  float32
 
+	// share of transactions profiled, see SetProfilesSampleRate
+	profilesSampleRate float32
+
+	// tracing of requests handled by Recoverer, see SetTracingEnabled
+	tracing tracing
+
 	// default logger name (leave empty for 'root')
 	defaultLoggerName string
 
 	includePaths       []string
 	ignoreErrorsRegexp *regexp.Regexp
 	queue              chan *outgoingPacket
+	// set by Close until Reopen
+	closed bool
+	// packets waiting in the queue and being sent by the worker, see QueueDepth and InFlight
+	queued   int32
+	inFlight int32
+
+	// coalescing of queued packets by the worker, see SetBatching
+	batchSize   int
+	batchWindow time.Duration
+
+	// deadline of a single send, see SetSendTimeout
+	sendTimeout time.Duration
+
+	// directory of dropped packets, see SetSpool
+	spool *Spool
+
+	// transport installed by a dryrun:// DSN and the one it replaced
+	dryRun         *WriterTransport
+	dryRunReplaced Transport
+
+	// sources of event ids and timestamps, see SetEventIDSource and SetClock
+	eventIDSource func() (string, error)
+	clock         func() time.Time
+
+	// run by the worker before sending, see SetExtraCollectors and SetContextCollectors
+	extraCollectors   []ExtraCollector
+	contextCollectors []ContextCollector
+
+	// grouping policy applied by the worker, see SetGrouper
+	grouper Grouper
+
+	// breadcrumbs kept in the context, see SetMaxBreadcrumbs
+	maxBreadcrumbs int
+
+	// levels of errors captured by CaptureError, see SetErrorSeverity
+	errorSeverities []errorSeverity
+
+	// function runtime defaults, see SetSynchronous and SetServerName
+	synchronous bool
+	serverName  string
+	serverless  string
+	coldStarted uint32
+
+	// request bodies recorded by Recoverer, see SetRequestBodyCapture
+	requestBodyMaxSize      int
+	requestBodyContentTypes []string
+
+	// headers and cookies forwarded by NewHttp, see SetHeaderDenylist
+	requestFilter httpFilter
+
+	// proxies whose forwarding headers are trusted, see SetTrustedProxies
+	trustedProxies []*net.IPNet
+
+	// request sessions not sent yet, see RecordSession
+	sessions sessionAggregates
+
+	// identical packets held back, see SetAggregation
+	aggregationWindow time.Duration
+	aggregates        aggregator
+
+	// dropped packets by reason, see DroppedPackets
+	drops                 dropStats
+	clientReportsDisabled bool
+
+	// structured logs not sent yet, see CaptureLog
+	logs         logBuffer
+	logRateLimit int
 
 	// A WaitGroup to keep track of all currently in-progress captures
 	// This is intended to be used with Client.Wait() to assure that
@@ -452,8 +699,16 @@ type Client struct {
 	start sync.Once
 }
 
-// DefaultClient initialize a default *Client instance
-var DefaultClient = newClient(nil)
+// DefaultClient initialize a default *Client instance, configured from the
+// environment unless built with the raven_noenv tag
+var DefaultClient = newDefaultClient()
+
+func newDefaultClient() *Client {
+	if defaultClientFromEnv {
+		return newClient(nil)
+	}
+	return newBareClient(nil)
+}
 
 // SetIgnoreErrors updates ignoreErrors config on given client
 func (client *Client) SetIgnoreErrors(errs []string) error {
@@ -481,7 +736,8 @@ func SetIgnoreErrors(errs ...string) error {
 }
 
 // SetDSN updates a client with a new DSN. It safe to call after and
-// concurrently with calls to Report and Send.
+// concurrently with calls to Report and Send. A DSN with the dryrun://
+// scheme switches the client to a WriterTransport, see SetDryRun.
 func (client *Client) SetDSN(dsn string) error {
 	if dsn == "" {
 		return nil
@@ -489,41 +745,86 @@ func (client *Client) SetDSN(dsn string) error {
 
 	client.mu.Lock()
 	defer client.mu.Unlock()
+	return client.setDSN(dsn)
+}
 
+// setDSN updates the DSN, callers must hold mu
+func (client *Client) setDSN(dsn string) error {
 	uri, err := url.Parse(dsn)
 	if err != nil {
 		return err
 	}
 
-	if uri.User == nil {
-		return ErrMissingUser
+	if uri.Scheme == dryRunScheme {
+		if client.dryRun != nil && client.Transport == client.dryRun && client.url == uri.String() {
+			// unchanged, e.g. reloaded by WatchConfig, keep the open output
+			return nil
+		}
+		t, err := newDryRunTransport(uri)
+		if err != nil {
+			return err
+		}
+		client.restoreTransport()
+		client.dryRun, client.dryRunReplaced = t, client.Transport
+		client.Transport = t
+		client.dsn, client.url = nil, uri.String()
+		return nil
 	}
-	publicKey := uri.User.Username()
-	secretKey, hasSecretKey := uri.User.Password()
-	uri.User = nil
 
-	if idx := strings.LastIndex(uri.Path, "/"); idx != -1 {
-		client.projectID = uri.Path[idx+1:]
-		uri.Path = uri.Path[:idx+1] + "api/" + client.projectID + "/store/"
-	}
-	if client.projectID == "" {
-		return ErrMissingProjectID
+	d, err := ParseDsn(dsn)
+	if err != nil {
+		return err
 	}
+	client.restoreTransport()
+	client.dsn = d
+	client.url, client.projectID, client.authHeader = d.StoreAPIURL(), d.ProjectID, d.AuthHeader()
+	client.publicKey = d.PublicKey
 
-	client.url = uri.String()
+	return nil
+}
 
-	if hasSecretKey {
-		client.authHeader = fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s, sentry_secret=%s", publicKey, secretKey)
-	} else {
-		client.authHeader = fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s", publicKey)
+// restoreTransport closes the output of a dry run DSN and restores the
+// transport it replaced, unless the transport was replaced since. Callers
+// must hold mu.
+func (client *Client) restoreTransport() {
+	if client.dryRun == nil {
+		return
+	}
+	if client.Transport == client.dryRun {
+		client.Transport = client.dryRunReplaced
 	}
+	if err := client.dryRun.close(); err != nil && client.logger != nil {
+		client.logger.Errorf("error closing dry run output: %v", err)
+	}
+	client.dryRun, client.dryRunReplaced = nil, nil
+}
 
-	return nil
+// Dsn returns a copy of the parsed DSN of given client, nil if none is set
+func (client *Client) Dsn() *Dsn {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	if client.dsn == nil {
+		return nil
+	}
+	d := *client.dsn
+	return &d
 }
 
 // SetDSN sets the DSN for the default *Client instance
 func SetDSN(dsn string) error { return DefaultClient.SetDSN(dsn) }
 
+// DisableDefaultClient removes the DSN the default *Client read from
+// SENTRY_DSN, so that it drops all events until SetDSN is called, e.g. in
+// libraries which must not report to the project of the host application.
+// Build with the raven_noenv tag to never configure it from the environment.
+func DisableDefaultClient() {
+	client := DefaultClient
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.dsn, client.url, client.projectID, client.authHeader, client.publicKey = nil, "", "", "", ""
+}
+
 // SetRelease sets the "release" tag.
 func (client *Client) SetRelease(release string) {
 	client.mu.Lock()
@@ -557,12 +858,61 @@ func (client *Client) SetSampleRate(rate float32) error {
 	return nil
 }
 
+// SetDebug enables printing of internal diagnostics to stdout, replacing any
+// Logger previously configured with SetLogger. See SetDebugWriter to choose
+// the output and verbosity.
 func (client *Client) SetDebug(debug bool) {
 	if debug == true {
-		debugLogger = log.New(os.Stdout, "raven: ", 0)
+		client.SetDebugWriter(os.Stdout, DebugVerbose)
 	} else {
-		debugLogger = log.New(ioutil.Discard, "", 0)
+		client.SetDebugWriter(ioutil.Discard, DebugErrors)
+		client.SetLogger(nil)
+	}
+}
+
+// SetLogger sets the Logger receiving internal diagnostics of the client and
+// its default HTTPTransport. A nil logger discards them. It should be called
+// before the first capture.
+func (client *Client) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = noopLogger{}
 	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.logger = logger
+	if t := findHTTPTransport(client.Transport); t != nil {
+		t.update(func() { t.Logger = logger })
+	}
+}
+
+// Logger returns the Logger receiving internal diagnostics of given client
+func (client *Client) Logger() Logger {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	if client.logger == nil {
+		return noopLogger{}
+	}
+	return client.logger
+}
+
+// SetEventIDSource replaces the random uuid generator used for the event id
+// of packets captured by given client, nil restores it. Together with SetClock
+// it makes serialized packets deterministic, e.g. for golden-file tests. Like
+// ids set on packets, the ids it returns must be accepted by ParseEventID,
+// otherwise Capture fails with ErrInvalidEventID.
+func (client *Client) SetEventIDSource(source func() (string, error)) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.eventIDSource = source
+}
+
+// SetClock replaces time.Now as the source of packet timestamps of given client, nil restores it
+func (client *Client) SetClock(clock func() time.Time) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.clock = clock
 }
 
 // SetRelease sets the "release" tag on the default *Client
@@ -582,14 +932,71 @@ func SetSampleRate(rate float32) error { return DefaultClient.SetSampleRate(rate
 // SetDebug sets the "debug" config on the default *Client
 func SetDebug(debug bool) { DefaultClient.SetDebug(debug) }
 
-func (client *Client) worker() {
-	for outgoingPacket := range client.queue {
+// SetLogger sets the Logger of the default *Client
+func SetLogger(logger Logger) { DefaultClient.SetLogger(logger) }
 
-		client.mu.RLock()
-		url, authHeader := client.url, client.authHeader
-		client.mu.RUnlock()
+func (client *Client) worker(queue chan *outgoingPacket) {
+	for outgoingPacket := range queue {
+		client.deliver(client.collectBatch(queue, outgoingPacket))
+	}
+}
+
+// deliver sends a batch of packets collected by the worker, as a single
+// SendBatch call when the transport supports it.
+func (client *Client) deliver(batch []*outgoingPacket) {
+	atomic.AddInt32(&client.queued, -int32(len(batch)))
+	atomic.AddInt32(&client.inFlight, int32(len(batch)))
+
+	client.mu.RLock()
+	url, authHeader, transport := client.url, client.authHeader, client.Transport
+	collectors, contextCollectors, grouper := client.extraCollectors, client.contextCollectors, client.grouper
+	spool := client.spool
+	client.mu.RUnlock()
+
+	for _, outgoingPacket := range batch {
+		collectExtra(outgoingPacket.packet, collectors)
+		collectContexts(outgoingPacket.packet, contextCollectors)
+		group(outgoingPacket.packet, grouper)
+	}
+
+	errs := make([]error, len(batch))
+	if bt, ok := transport.(BatchTransport); ok && len(batch) > 1 {
+		packets := make([]*Packet, len(batch))
+		for i, outgoingPacket := range batch {
+			packets[i] = outgoingPacket.packet
+		}
+		ctx, cancel := client.sendContext()
+		err := bt.SendBatch(ctx, url, authHeader, packets)
+		cancel()
+		if batchErr, ok := err.(BatchError); ok && len(batchErr) == len(errs) {
+			copy(errs, batchErr)
+		} else {
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+	} else {
+		for i, outgoingPacket := range batch {
+			ctx, cancel := client.sendContext()
+			errs[i] = AsTransportV2(transport).SendWithContext(ctx, url, authHeader, outgoingPacket.packet)
+			cancel()
+		}
+	}
 
-		outgoingPacket.ch <- client.Transport.Send(url, authHeader, outgoingPacket.packet)
+	for i, outgoingPacket := range batch {
+		if reason, dropped := dropReasonOf(errs[i]); dropped {
+			client.dropWithError(outgoingPacket.packet, reason, errs[i])
+		} else {
+			client.internalError(errs[i], outgoingPacket.packet)
+			if errs[i] != nil && spool != nil && retryable(errs[i]) {
+				spool.write(outgoingPacket.packet.EventID, outgoingPacket.packet.JSON)
+			}
+		}
+		if outgoingPacket.packet.pooled {
+			ReleasePacket(outgoingPacket.packet)
+		}
+		atomic.AddInt32(&client.inFlight, -1)
+		outgoingPacket.ch <- errs[i]
 		client.wg.Done()
 	}
 }
@@ -597,7 +1004,10 @@ func (client *Client) worker() {
 // Capture asynchronously delivers a packet to the Sentry server. It is a no-op
 // when client is nil. A channel is provided if it is important to check for a
 // send's success.
-func (client *Client) Capture(packet *Packet, captureTags map[string]string) (eventID string, ch chan error) {
+//
+// Capture never marshals the packet, it only enqueues it for the worker
+// goroutine, so the packet must not be modified after it was captured.
+func (client *Client) Capture(packet *Packet, captureTags map[string]string) (eventID EventID, ch chan error) {
 	ch = make(chan error, 1)
 
 	if client == nil {
@@ -606,7 +1016,13 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		return
 	}
 
-	if client.sampleRate < 1.0 && mrand.Float32() > client.sampleRate {
+	client.mu.RLock()
+	sampleRate := client.sampleRate
+	client.mu.RUnlock()
+	if sampleRate < 1.0 && mrand.Float32() > sampleRate {
+		if packet != nil {
+			client.drop(packet, DropSampled)
+		}
 		return
 	}
 
@@ -616,6 +1032,7 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 	}
 
 	if client.shouldExcludeErr(packet.Message) {
+		client.drop(packet, DropIgnored)
 		return
 	}
 
@@ -624,19 +1041,34 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 	// finished being acted upon, whether success or failure
 	client.wg.Add(1)
 
-	// Merge capture tags and client tags
+	// Merge capture tags and client tags, and snapshot the context and
+	// configuration of the client, which packet never references
 	packet.AddTags(captureTags)
-	packet.AddTags(client.Tags)
-
-	// Initialize any required packet fields
 	client.mu.RLock()
+	packet.AddTags(client.Tags)
 	packet.AddTags(client.context.tags)
+	if packet.Transaction == "" {
+		packet.Transaction = client.context.transaction
+	}
+	if breadcrumbs := client.context.breadcrumbsInterface(); breadcrumbs != nil && !hasBreadcrumbs(packet) {
+		packet.Interfaces = append(packet.Interfaces, breadcrumbs)
+	}
 	projectID := client.projectID
 	release := client.release
 	environment := client.environment
 	defaultLoggerName := client.defaultLoggerName
+	eventIDSource, clock := client.eventIDSource, client.clock
+	synchronous, serverName, serverless := client.synchronous, client.serverName, client.serverless
+	aggregationWindow := client.aggregationWindow
 	client.mu.RUnlock()
 
+	if serverless != "" {
+		packet.AddTags(client.serverlessTags(serverless))
+	}
+	if packet.ServerName == "" {
+		packet.ServerName = serverName
+	}
+
 	// set the global logger name on the packet if we must
 	if packet.Logger == "" && defaultLoggerName != "" {
 		packet.Logger = defaultLoggerName
@@ -647,12 +1079,33 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		packet.Level = Severity(captureTags["level"])
 	}
 
+	if packet.EventID == "" && eventIDSource != nil {
+		id, err := eventIDSource()
+		if err != nil {
+			ch <- err
+			client.wg.Done()
+			return
+		}
+		packet.EventID = id
+	}
+	if time.Time(packet.Timestamp).IsZero() && clock != nil {
+		packet.Timestamp = Timestamp(clock())
+	}
+
 	err := packet.Init(projectID)
 	if err != nil {
 		ch <- err
 		client.wg.Done()
 		return
 	}
+	// ids end up in urls and spool file names, reject anything but hex
+	id, err := ParseEventID(packet.EventID)
+	if err != nil {
+		ch <- err
+		client.wg.Done()
+		return
+	}
+	packet.EventID = string(id)
 
 	if packet.Release == "" {
 		packet.Release = release
@@ -662,37 +1115,77 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		packet.Environment = environment
 	}
 
+	if client.BeforeSend != nil {
+		if sent := client.BeforeSend(packet); sent != nil {
+			packet = sent
+		} else {
+			client.drop(packet, DropBeforeSendNil)
+			ch <- ErrPacketDropped
+			client.wg.Done()
+			return "", ch
+		}
+	}
+
+	// Pooled packets are released by the worker once sent, so
+	// packet must not be touched after it was queued
+	if aggregationWindow > 0 && !synchronous {
+		return client.aggregate(packet, ch, aggregationWindow), ch
+	}
+	eventID = EventID(packet.EventID)
+	client.enqueue(packet, ch)
+
+	if synchronous {
+		// hand the result over to the caller, ch is buffered
+		ch <- <-ch
+	}
+
+	return eventID, ch
+}
+
+// enqueue hands an initialized packet over to the worker, the caller must
+// have added it to client.wg.
+func (client *Client) enqueue(packet *Packet, ch chan error) {
 	outgoingPacket := &outgoingPacket{packet, ch}
 
+	// The queue is only closed and replaced holding the write lock
+	client.mu.RLock()
+	if client.closed {
+		client.mu.RUnlock()
+		client.drop(packet, DropClosed)
+		ch <- ErrPacketDropped
+		client.wg.Done()
+		return
+	}
+
 	// Lazily start background worker until we
 	// do our first write into the queue.
 	client.start.Do(func() {
-		go client.worker()
+		go client.worker(client.queue)
 	})
 
+	atomic.AddInt32(&client.queued, 1)
 	select {
 	case client.queue <- outgoingPacket:
+		client.mu.RUnlock()
 	default:
+		client.mu.RUnlock()
+		atomic.AddInt32(&client.queued, -1)
 		// Send would block, drop the packet
-		if client.DropHandler != nil {
-			client.DropHandler(packet)
-		}
+		client.drop(packet, DropQueueFull)
 		ch <- ErrPacketDropped
 		client.wg.Done()
 	}
-
-	return packet.EventID, ch
 }
 
 // Capture asynchronously delivers a packet to the Sentry server with the default *Client.
 // It is a no-op when client is nil. A channel is provided if it is important to check for a
 // send's success.
-func Capture(packet *Packet, captureTags map[string]string) (eventID string, ch chan error) {
+func Capture(packet *Packet, captureTags map[string]string) (eventID EventID, ch chan error) {
 	return DefaultClient.Capture(packet, captureTags)
 }
 
 // CaptureMessage formats and delivers a string message to the Sentry server.
-func (client *Client) CaptureMessage(message string, tags map[string]string, interfaces ...Interface) string {
+func (client *Client) CaptureMessage(message string, tags map[string]string, interfaces ...Interface) EventID {
 	if client == nil {
 		return ""
 	}
@@ -701,19 +1194,19 @@ func (client *Client) CaptureMessage(message string, tags map[string]string, int
 		return ""
 	}
 
-	packet := NewPacket(message, append(append(interfaces, client.context.interfaces()...), &Message{message, nil})...)
+	packet := NewPacket(message, append(append(interfaces, client.contextInterfaces()...), &Message{message, nil})...)
 	eventID, _ := client.Capture(packet, tags)
 
 	return eventID
 }
 
 // CaptureMessage formats and delivers a string message to the Sentry server with the default *Client
-func CaptureMessage(message string, tags map[string]string, interfaces ...Interface) string {
+func CaptureMessage(message string, tags map[string]string, interfaces ...Interface) EventID {
 	return DefaultClient.CaptureMessage(message, tags, interfaces...)
 }
 
 // CaptureMessageAndWait is identical to CaptureMessage except it blocks and waits for the message to be sent.
-func (client *Client) CaptureMessageAndWait(message string, tags map[string]string, interfaces ...Interface) string {
+func (client *Client) CaptureMessageAndWait(message string, tags map[string]string, interfaces ...Interface) EventID {
 	if client == nil {
 		return ""
 	}
@@ -722,7 +1215,7 @@ func (client *Client) CaptureMessageAndWait(message string, tags map[string]stri
 		return ""
 	}
 
-	packet := NewPacket(message, append(append(interfaces, client.context.interfaces()...), &Message{message, nil})...)
+	packet := NewPacket(message, append(append(interfaces, client.contextInterfaces()...), &Message{message, nil})...)
 	eventID, ch := client.Capture(packet, tags)
 	if eventID != "" {
 		<-ch
@@ -732,13 +1225,15 @@ func (client *Client) CaptureMessageAndWait(message string, tags map[string]stri
 }
 
 // CaptureMessageAndWait is identical to CaptureMessage except it blocks and waits for the message to be sent.
-func CaptureMessageAndWait(message string, tags map[string]string, interfaces ...Interface) string {
+func CaptureMessageAndWait(message string, tags map[string]string, interfaces ...Interface) EventID {
 	return DefaultClient.CaptureMessageAndWait(message, tags, interfaces...)
 }
 
 // CaptureError formats and delivers an error to the Sentry server.
-// Adds a stacktrace to the packet, excluding the call to this method.
-func (client *Client) CaptureError(err error, tags map[string]string, interfaces ...Interface) string {
+// Adds a stacktrace to the packet, excluding the call to this method, and
+// the "error.kind" and "error.transient" tags of timeouts, cancellations,
+// network, syscall and HTTP errors unless tags sets them.
+func (client *Client) CaptureError(err error, tags map[string]string, interfaces ...Interface) EventID {
 	if client == nil {
 		return ""
 	}
@@ -752,22 +1247,26 @@ func (client *Client) CaptureError(err error, tags map[string]string, interfaces
 	}
 
 	extra := extractExtra(err)
-	cause := Cause(err)
+	cause := rootCause(err)
 
-	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.context.interfaces()...), NewException(cause, GetOrNewStacktrace(cause, 1, 3, client.includePaths)))...)
-	eventID, _ := client.Capture(packet, tags)
+	exception := chainedExceptions(err, NewException(cause, GetOrNewStacktrace(cause, 1, 3, client.includePaths)), 3, client.includePaths)
+	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.contextInterfaces()...), exception)...)
+	if level := client.errorSeverity(err); level != "" {
+		packet.Level = level
+	}
+	eventID, _ := client.Capture(packet, withErrorTraits(tags, err))
 
 	return eventID
 }
 
 // CaptureError formats and delivers an error to the Sentry server using the default *Client.
 // Adds a stacktrace to the packet, excluding the call to this method.
-func CaptureError(err error, tags map[string]string, interfaces ...Interface) string {
+func CaptureError(err error, tags map[string]string, interfaces ...Interface) EventID {
 	return DefaultClient.CaptureError(err, tags, interfaces...)
 }
 
 // CaptureErrorAndWait is identical to CaptureError, except it blocks and assures that the event was sent
-func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, interfaces ...Interface) string {
+func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, interfaces ...Interface) EventID {
 	if client == nil {
 		return ""
 	}
@@ -777,10 +1276,14 @@ func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, int
 	}
 
 	extra := extractExtra(err)
-	cause := Cause(err)
+	cause := rootCause(err)
 
-	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.context.interfaces()...), NewException(cause, GetOrNewStacktrace(cause, 1, 3, client.includePaths)))...)
-	eventID, ch := client.Capture(packet, tags)
+	exception := chainedExceptions(err, NewException(cause, GetOrNewStacktrace(cause, 1, 3, client.includePaths)), 3, client.includePaths)
+	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.contextInterfaces()...), exception)...)
+	if level := client.errorSeverity(err); level != "" {
+		packet.Level = level
+	}
+	eventID, ch := client.Capture(packet, withErrorTraits(tags, err))
 	if eventID != "" {
 		<-ch
 	}
@@ -789,13 +1292,15 @@ func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, int
 }
 
 // CaptureErrorAndWait is identical to CaptureError, except it blocks and assures that the event was sent
-func CaptureErrorAndWait(err error, tags map[string]string, interfaces ...Interface) string {
+func CaptureErrorAndWait(err error, tags map[string]string, interfaces ...Interface) EventID {
 	return DefaultClient.CaptureErrorAndWait(err, tags, interfaces...)
 }
 
 // CapturePanic calls f and then recovers and reports a panic to the Sentry server if it occurs.
 // If an error is captured, both the error and the reported Sentry error ID are returned.
-func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces ...Interface) (err interface{}, errorID string) {
+// Panic values which are not errors are reported with their type as the "panic.type"
+// extra and, when they are structs, maps, slices or arrays, their contents as "panic.value".
+func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces ...Interface) (err interface{}, errorID EventID) {
 	// Note: This doesn't need to check for client, because we still want to go through the defer/recover path
 	// Down the line, Capture will be noop'd, so while this does a _tiny_ bit of overhead constructing the
 	// *Packet just to be thrown away, this should not be the normal case. Could be refactored to
@@ -810,13 +1315,13 @@ func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces
 			if client.shouldExcludeErr(rval.Error()) {
 				return
 			}
-			packet = NewPacket(rval.Error(), append(append(interfaces, client.context.interfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
+			packet = NewPacket(rval.Error(), append(append(interfaces, client.contextInterfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
 		default:
 			rvalStr := fmt.Sprint(rval)
 			if client.shouldExcludeErr(rvalStr) {
 				return
 			}
-			packet = NewPacket(rvalStr, append(append(interfaces, client.context.interfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
+			packet = NewPacketWithExtra(rvalStr, panicValueExtra(rval), append(append(interfaces, client.contextInterfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
 		}
 
 		errorID, _ = client.Capture(packet, tags)
@@ -828,12 +1333,12 @@ func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces
 
 // CapturePanic calls f and then recovers and reports a panic to the Sentry server if it occurs.
 // If an error is captured, both the error and the reported Sentry error ID are returned.
-func CapturePanic(f func(), tags map[string]string, interfaces ...Interface) (interface{}, string) {
+func CapturePanic(f func(), tags map[string]string, interfaces ...Interface) (interface{}, EventID) {
 	return DefaultClient.CapturePanic(f, tags, interfaces...)
 }
 
 // CapturePanicAndWait is identical to CapturePanic, except it blocks and assures that the event was sent
-func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, interfaces ...Interface) (err interface{}, errorID string) {
+func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, interfaces ...Interface) (err interface{}, errorID EventID) {
 	// Note: This doesn't need to check for client, because we still want to go through the defer/recover path
 	// Down the line, Capture will be noop'd, so while this does a _tiny_ bit of overhead constructing the
 	// *Packet just to be thrown away, this should not be the normal case. Could be refactored to
@@ -848,13 +1353,13 @@ func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, inte
 			if client.shouldExcludeErr(rval.Error()) {
 				return
 			}
-			packet = NewPacket(rval.Error(), append(append(interfaces, client.context.interfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
+			packet = NewPacket(rval.Error(), append(append(interfaces, client.contextInterfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
 		default:
 			rvalStr := fmt.Sprint(rval)
 			if client.shouldExcludeErr(rvalStr) {
 				return
 			}
-			packet = NewPacket(rvalStr, append(append(interfaces, client.context.interfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
+			packet = NewPacketWithExtra(rvalStr, panicValueExtra(rval), append(append(interfaces, client.contextInterfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
 		}
 
 		var ch chan error
@@ -869,26 +1374,90 @@ func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, inte
 }
 
 // CapturePanicAndWait is identical to CapturePanic, except it blocks and assures that the event was sent
-func CapturePanicAndWait(f func(), tags map[string]string, interfaces ...Interface) (interface{}, string) {
+func CapturePanicAndWait(f func(), tags map[string]string, interfaces ...Interface) (interface{}, EventID) {
 	return DefaultClient.CapturePanicAndWait(f, tags, interfaces...)
 }
 
-// Close given clients event queue
+// Close given clients event queue, packets already queued are still sent
+// while packets captured afterwards are dropped until Reopen is called.
+// Closing a closed client is a no-op.
 func (client *Client) Close() {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.closed {
+		return
+	}
+	client.closed = true
 	close(client.queue)
 }
 
 // Close defaults client event queue
 func Close() { DefaultClient.Close() }
 
-// Wait blocks and waits for all events to finish being sent to Sentry server
+// Reopen resumes reporting of a client stopped by Close with a new queue
+// and worker, keeping its configuration. Reopening an open client is a no-op.
+func (client *Client) Reopen() {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if !client.closed {
+		return
+	}
+	client.closed = false
+	client.queue = make(chan *outgoingPacket, cap(client.queue))
+	client.start = sync.Once{}
+}
+
+// Reopen resumes reporting of the default *Client after Close
+func Reopen() { DefaultClient.Reopen() }
+
+// Wait blocks and waits for all events, request sessions and logs to finish being sent to Sentry server
 func (client *Client) Wait() {
+	client.flushAggregates()
+	client.flushSessions()
+	client.flushLogs()
+	client.flushClientReports()
 	client.wg.Wait()
 }
 
 // Wait blocks and waits for all events to finish being sent to Sentry server
 func Wait() { DefaultClient.Wait() }
 
+// WaitContext is Wait bounded by ctx, e.g. of a graceful shutdown. It returns
+// nil once everything was sent and the error of ctx if it is done first, in
+// which case sending continues in the background.
+func (client *Client) WaitContext(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitContext waits for all events of the default *Client to be sent until ctx is done
+func WaitContext(ctx context.Context) error { return DefaultClient.WaitContext(ctx) }
+
+// QueueDepth returns how many captured packets of given client wait in its
+// queue for the worker. Compared to the queue size it tells how close the
+// client is to drop packets with DropQueueFull, e.g. for health endpoints.
+func (client *Client) QueueDepth() int { return int(atomic.LoadInt32(&client.queued)) }
+
+// QueueDepth returns how many captured packets of the default *Client wait in its queue
+func QueueDepth() int { return DefaultClient.QueueDepth() }
+
+// InFlight returns how many packets of given client the worker is sending
+func (client *Client) InFlight() int { return int(atomic.LoadInt32(&client.inFlight)) }
+
+// InFlight returns how many packets of the default *Client the worker is sending
+func InFlight() int { return DefaultClient.InFlight() }
+
 // URL returns configured url of given client
 func (client *Client) URL() string {
 	client.mu.RLock()
@@ -965,7 +1534,21 @@ func (client *Client) SetTagsContext(t map[string]string) {
 	client.context.setTags(t)
 }
 
-// ClearContext clears Context interface on given client by removing tags, user and request information
+// SetTransactionContext updates the transaction of Context interface on
+// given client, e.g. the route of a request or the name of a job, which is
+// sent as the transaction of packets and used as their culprit. It associates
+// events with the operation they happened in even without tracing.
+func (client *Client) SetTransactionContext(name string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.context.setTransaction(name)
+}
+
+// SetTransaction sets the transaction of packets captured by given client,
+// see SetTransactionContext
+func (client *Client) SetTransaction(name string) { client.SetTransactionContext(name) }
+
+// ClearContext clears Context interface on given client by removing tags, user, transaction, breadcrumbs and request information
 func (client *Client) ClearContext() {
 	client.mu.Lock()
 	defer client.mu.Unlock()
@@ -981,85 +1564,135 @@ func SetHttpContext(h *Http) { DefaultClient.SetHttpContext(h) }
 // SetTagsContext updates Tags of Context interface on default client
 func SetTagsContext(t map[string]string) { DefaultClient.SetTagsContext(t) }
 
-// ClearContext clears Context interface on default client by removing tags, user and request information
+// SetTransactionContext updates the transaction of Context interface on default client
+func SetTransactionContext(name string) { DefaultClient.SetTransactionContext(name) }
+
+// SetTransaction sets the transaction of packets captured by default client
+func SetTransaction(name string) { DefaultClient.SetTransaction(name) }
+
+// ClearContext clears Context interface on default client by removing tags, user, transaction, breadcrumbs and request information
 func ClearContext() { DefaultClient.ClearContext() }
 
 // HTTPTransport is the default transport, delivering packets to Sentry via the
-// HTTP API.
+// HTTP API. Its fields must not be assigned while it is sending, the setters
+// of Client change them safely at any time. The underlying *http.Transport
+// can only be configured before the first request, its setters fail with
+// ErrTransportInUse afterwards.
 type HTTPTransport struct {
 	*http.Client
+
+	// Logger receives diagnostics about failed requests, it is kept in
+	// sync with the owning Client's SetLogger.
+	Logger Logger
+
+	// Compression of packets bigger than CompressionThreshold, defaults to
+	// CompressionDeflate. Use CompressionNone to disable it entirely.
+	Compression Compression
+
+	// CompressionLevel passed to the compressor, zero keeps BestCompression.
+	// CPU-bound services may prefer BestSpeed.
+	CompressionLevel int
+
+	// CompressionThreshold in bytes below which packets are sent
+	// uncompressed, zero keeps the default of 1000 bytes and a negative
+	// value compresses every packet.
+	CompressionThreshold int
+
+	// RequestCallback is invoked with every request right before it is sent,
+	// e.g. to add internal gateway authentication headers or sign requests.
+	RequestCallback func(*http.Request)
+
+	// DumpRequests logs every request and response, including payloads, as
+	// debug messages to Logger, see SetDebugWriter
+	DumpRequests bool
+
+	// mu guards the fields above against the setters of Client and inUse
+	mu    sync.RWMutex
+	inUse bool
+}
+
+func (t *HTTPTransport) logger() Logger {
+	return t.settings().logger
 }
 
 // Send uses HTTPTransport to send a Packet to configured Sentry's DSN endpoint
 func (t *HTTPTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send aborting the request when ctx is done. A packet
+// with a dynamic sampling context, e.g. captured while handling a request
+// traced by Recoverer, is posted as an envelope to the envelope endpoint
+// derived from url, so that it reaches Sentry. Failed deliveries are
+// reported as a *TransportError.
+func (t *HTTPTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
 	if url == "" {
 		return nil
 	}
 
-	body, contentType, contentEncoding, err := serializedPacket(packet)
+	settings := t.use()
+	body, contentType, contentEncoding, err := serializedPacket(packet, settings.compression, settings.compressionLevel, settings.compressionThreshold)
 	if err != nil {
-		return fmt.Errorf("raven: error serializing packet: %v", err)
+		return &serializationError{err}
+	}
+	// the transport may close the body in the background, the packet is
+	// released once SendWithContext returned
+	defer body.Close()
+	if packet.sendsEnvelope() {
+		url = envelopeURL(url)
 	}
 	req, err := http.NewRequest("POST", url, body)
 	if err != nil {
 		return fmt.Errorf("raven: can't create new request: %v", err)
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("X-Sentry-Auth", authHeader)
 	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Content-Type", contentType)
 	if contentEncoding != "" {
 		req.Header.Set("Content-Encoding", contentEncoding)
 	}
+	return t.post(ctx, req, settings)
+}
+
+// post sends a request built by SendWithContext or SendEnvelope and checks its response
+func (t *HTTPTransport) post(ctx context.Context, req *http.Request, settings transportSettings) error {
+	if settings.requestCallback != nil {
+		settings.requestCallback(req)
+	}
 
+	if settings.dumpRequests {
+		dump, _ := httputil.DumpRequestOut(req, true)
+		settings.logger.Debugf("request:\n%s", redactSecret(dump))
+	}
 	res, err := t.Do(req)
 	if err != nil {
-		return err
+		return &TransportError{Err: err, Retryable: ctx.Err() == nil}
+	}
+	if settings.dumpRequests {
+		dump, _ := httputil.DumpResponse(res, true)
+		settings.logger.Debugf("response:\n%s", dump)
 	}
 
 	// Response body needs to be drained and closed in order for TCP connection to stay opened (via keep-alive) and reused
 	_, err = io.Copy(ioutil.Discard, res.Body)
 	if err != nil {
-		debugLogger.Println("Error while reading response body", res)
+		settings.logger.Debugf("error while reading response body: %v", err)
 	}
 
 	err = res.Body.Close()
 	if err != nil {
-		debugLogger.Println("Error while closing response body", err)
+		settings.logger.Debugf("error while closing response body: %v", err)
 	}
 
 	if res.StatusCode != 200 {
-		return fmt.Errorf("raven: got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error"))
-	}
-	return nil
-}
-
-func serializedPacket(packet *Packet) (io.Reader, string, string, error) {
-	packetJSON, err := packet.JSON()
-	if err != nil {
-		return nil, "", "", fmt.Errorf("raven: error marshaling packet %+v to JSON: %v", packet, err)
-	}
-
-	// Only deflate the packet if it is bigger than 1KB, as there is an overhead
-	if len(packetJSON) > 1000 {
-		buf := &bytes.Buffer{}
-		deflate, _ := zlib.NewWriterLevel(buf, zlib.BestCompression)
-		_, err := deflate.Write(packetJSON)
-		if err != nil {
-			debugLogger.Println("Error while deflating data in packet serializer", err)
-		}
-		err = deflate.Close()
-		if err != nil {
-			debugLogger.Println("Error while closing zlib deflate in packet serializer", err)
+		return &TransportError{
+			StatusCode: res.StatusCode,
+			Retryable:  res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500,
+			Err:        fmt.Errorf("raven: got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error")),
 		}
-		return buf, "application/octet-stream", "deflate", nil
 	}
-	return bytes.NewReader(packetJSON), "application/json", "", nil
-}
-
-var hostname string
-
-func init() {
-	hostname, _ = os.Hostname()
+	return nil
 }
 
 // Cause returns the underlying cause of the error, if possible.
@@ -1,10 +1,16 @@
 package raven
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	pkgErrors "github.com/pkg/errors"
+	"io/ioutil"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -18,9 +24,9 @@ func TestShouldExcludeErr(t *testing.T) {
 	regexpStrs := []string{"ERR_TIMEOUT", "should.exclude", "(?i)^big$"}
 
 	client := &Client{
-		Transport: newTransport(),
+		Transport: newTransport(nil),
 		Tags:      nil,
-		context:   &context{},
+		context:   &clientContext{},
 		queue:     make(chan *outgoingPacket, MaxQueueBuffer),
 	}
 
@@ -137,6 +143,50 @@ func TestPacketInit(t *testing.T) {
 	}
 }
 
+func TestPacketSetTiming(t *testing.T) {
+	packet := NewPacket("slow")
+	packet.SetTiming(time.Time{}, time.Second)
+	if packet.StartTimestamp != nil || packet.TimeSpent != 0 {
+		t.Errorf("expected a zero start to be ignored, got %v and %d", packet.StartTimestamp, packet.TimeSpent)
+	}
+
+	packet.SetTiming(time.Date(2000, 01, 01, 0, 0, 0, 0, time.UTC), 1500*time.Millisecond)
+	j, err := packet.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(j), `"start_timestamp":"2000-01-01T00:00:00.00","time_spent":1500`) {
+		t.Errorf("incorrect timing in %s", j)
+	}
+	if clone := packet.Clone(); clone.StartTimestamp == packet.StartTimestamp {
+		t.Error("expected the start timestamp to be copied")
+	}
+}
+
+func TestPacketInitCulprit(t *testing.T) {
+	library := &Stacktrace{Frames: []*StacktraceFrame{{Module: "database/sql", Function: "(*DB).Query"}}}
+	inApp := &Stacktrace{Frames: []*StacktraceFrame{
+		{Module: "example.com/app", Function: "handle", InApp: true},
+		{Module: "database/sql", Function: "(*DB).Query"},
+	}}
+	chained := Exceptions{Values: []*Exception{
+		{Value: "cause", Stacktrace: inApp},
+		{Value: "wrapped", Stacktrace: library},
+	}}
+
+	packet := &Packet{Message: "a", Transaction: "GET /users/:id", Interfaces: []Interface{chained}}
+	packet.Init("foo")
+	if packet.Culprit != "example.com/app.handle" {
+		t.Errorf("expected the in app frame, got %q", packet.Culprit)
+	}
+
+	packet = &Packet{Message: "a", Transaction: "GET /users/:id", Interfaces: []Interface{&Exception{Stacktrace: library}}}
+	packet.Init("foo")
+	if packet.Culprit != "GET /users/:id" {
+		t.Errorf("expected the transaction, got %q", packet.Culprit)
+	}
+}
+
 func TestSetDSN(t *testing.T) {
 	client := &Client{}
 	err := client.SetDSN("https://u:p@example.com/sentry/1")
@@ -156,6 +206,48 @@ func TestSetDSN(t *testing.T) {
 	}
 }
 
+func TestDisableDefaultClient(t *testing.T) {
+	previous := DefaultClient.Dsn()
+	defer func() {
+		if previous != nil {
+			SetDSN(previous.String())
+		}
+	}()
+
+	SetDSN("https://public@sentry.example.com/1")
+	DisableDefaultClient()
+	if DefaultClient.URL() != "" || DefaultClient.ProjectID() != "" || DefaultClient.Dsn() != nil {
+		t.Errorf("expected no dsn, got %s", DefaultClient.URL())
+	}
+}
+
+func TestNewBareClient(t *testing.T) {
+	os.Setenv("SENTRY_DSN", "https://public@sentry.example.com/1")
+	defer os.Unsetenv("SENTRY_DSN")
+
+	if client := newBareClient(nil); client.URL() != "" {
+		t.Errorf("expected the environment to be ignored, got %s", client.URL())
+	}
+	if client := newClient(nil); client.URL() != "https://sentry.example.com/api/1/store/" {
+		t.Errorf("expected the dsn of the environment, got %s", client.URL())
+	}
+}
+
+func TestTagsFromEnv(t *testing.T) {
+	os.Setenv("SENTRY_TAGS", "region=eu-west-1, team = payments,malformed,,=empty")
+	defer os.Unsetenv("SENTRY_TAGS")
+
+	tags := map[string]string{"team": "checkout"}
+	client := newClient(tags)
+	expected := map[string]string{"region": "eu-west-1", "team": "checkout"}
+	if !reflect.DeepEqual(client.Tags, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", client.Tags, expected)
+	}
+	if len(tags) != 1 {
+		t.Errorf("expected the tags passed to be untouched, got %v", tags)
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	client := newClient(nil)
 	if client.sampleRate != 1.0 {
@@ -362,3 +454,182 @@ func TestNewPacketWithExtraSetsDefault(t *testing.T) {
 		}
 	}
 }
+
+// blockingInterface blocks marshaling until unblock is closed
+type blockingInterface struct {
+	unblock chan struct{}
+}
+
+func (b *blockingInterface) Class() string { return "sentry.interfaces.Blocking" }
+
+func (b *blockingInterface) MarshalJSON() ([]byte, error) {
+	<-b.unblock
+	return []byte(`{}`), nil
+}
+
+func TestCaptureDoesNotMarshal(t *testing.T) {
+	client := newClient(nil)
+	client.SetDryRun(ioutil.Discard)
+	inter := &blockingInterface{make(chan struct{})}
+
+	captured := make(chan struct{})
+	go func() {
+		client.Capture(NewPacket("foo", inter), nil)
+		close(captured)
+	}()
+
+	select {
+	case <-captured:
+	case <-time.After(time.Second):
+		t.Fatal("Capture blocked on marshaling the packet")
+	}
+	close(inter.unblock)
+	client.Wait()
+}
+
+func TestSetEventIDSourceAndClock(t *testing.T) {
+	client := newClient(nil)
+	client.SetDryRun(ioutil.Discard)
+	now := time.Date(2000, 01, 01, 0, 0, 0, 0, time.UTC)
+	client.SetClock(func() time.Time { return now })
+	client.SetEventIDSource(func() (string, error) { return "DEADBEEF-DEAD-BEEF-DEAD-BEEFDEADBEEF", nil })
+
+	packet := NewPacket("foo")
+	eventID, _ := client.Capture(packet, nil)
+	client.Wait()
+
+	if eventID != "deadbeefdeadbeefdeadbeefdeadbeef" {
+		t.Errorf("incorrect EventID: %s", eventID)
+	}
+	if time.Time(packet.Timestamp) != now {
+		t.Errorf("incorrect Timestamp: %v", time.Time(packet.Timestamp))
+	}
+
+	client.SetEventIDSource(func() (string, error) { return "../../etc/passwd", nil })
+	if eventID, ch := client.Capture(NewPacket("foo"), nil); eventID != "" || <-ch != ErrInvalidEventID {
+		t.Errorf("expected an invalid event id to be rejected, got %q", eventID)
+	}
+}
+
+func TestCloseReopen(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+	var dropped []DropReason
+	client.DropHandlerWithReason = func(d *DroppedPacket) { dropped = append(dropped, d.Reason) }
+
+	client.Close()
+	client.Close()
+	if _, ch := client.Capture(NewPacket("closed"), nil); <-ch != ErrPacketDropped {
+		t.Error("expected packets of a closed client to be dropped")
+	}
+	if len(dropped) != 1 || dropped[0] != DropClosed {
+		t.Errorf("expected a DropClosed reason, got %v", dropped)
+	}
+
+	client.Reopen()
+	client.Reopen()
+	if _, ch := client.Capture(NewPacket("reopened"), nil); <-ch != nil {
+		t.Error("expected packets of a reopened client to be sent")
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 || transport.packets[0].Message != "reopened" {
+		t.Errorf("expected the packet captured after Reopen, got %d packets", len(transport.packets))
+	}
+}
+
+type blockingTransport struct{ release chan struct{} }
+
+func (t *blockingTransport) Send(url, authHeader string, packet *Packet) error {
+	<-t.release
+	return nil
+}
+
+func TestWaitContext(t *testing.T) {
+	client := newClient(nil)
+	transport := &blockingTransport{release: make(chan struct{})}
+	client.Transport = transport
+	client.Capture(NewPacket("slow"), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+
+	close(transport.release)
+	if err := client.WaitContext(context.Background()); err != nil {
+		t.Errorf("expected events to be flushed, got %v", err)
+	}
+}
+
+func TestQueueDepthInFlight(t *testing.T) {
+	client := newClient(nil)
+	transport := &blockingTransport{release: make(chan struct{})}
+	client.Transport = transport
+
+	client.Capture(NewPacket("first"), nil)
+	for deadline := time.Now().Add(time.Second); client.InFlight() != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 1 packet in flight, got %d", client.InFlight())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	client.Capture(NewPacket("second"), nil)
+	client.Capture(NewPacket("third"), nil)
+	if depth := client.QueueDepth(); depth != 2 {
+		t.Errorf("expected 2 queued packets, got %d", depth)
+	}
+
+	close(transport.release)
+	client.Wait()
+	if depth, inFlight := client.QueueDepth(), client.InFlight(); depth != 0 || inFlight != 0 {
+		t.Errorf("expected an idle client, got depth %d and %d in flight", depth, inFlight)
+	}
+}
+
+func TestCaptureSnapshotsContext(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	user := &User{ID: "before"}
+	headers := map[string]string{"Accept": "*/*"}
+	client.SetUserContext(user)
+	client.SetHttpContext(&Http{URL: "http://example.com/", Headers: headers})
+	client.CaptureMessage("snapshot", nil)
+	user.ID = "after"
+	client.Wait()
+
+	for _, inter := range transport.packets[0].Interfaces {
+		switch inter := inter.(type) {
+		case *User:
+			if inter == user || inter.ID != "before" {
+				t.Errorf("expected a snapshot of the user, got %+v", inter)
+			}
+		case *Http:
+			inter.Headers["Accept"] = "text/plain"
+		}
+	}
+	if headers["Accept"] != "*/*" {
+		t.Error("expected the packet not to share the headers of the context")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetUserContext(&User{ID: "concurrent"})
+			client.SetTagsContext(map[string]string{"concurrent": "true"})
+			client.ClearContext()
+		}()
+		go func() {
+			defer wg.Done()
+			client.CaptureError(errors.New("concurrent"), nil)
+		}()
+	}
+	wg.Wait()
+	client.Wait()
+}
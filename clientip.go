@@ -0,0 +1,112 @@
+package raven
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies sets the addresses, in CIDR notation or as single IPs,
+// of the reverse proxies in front of the application. The client IP of
+// requests from trusted proxies is taken from the Forwarded, X-Forwarded-For
+// or X-Real-IP headers, which can be spoofed by anyone else.
+func (client *Client) SetTrustedProxies(proxies ...string) error {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("raven: invalid trusted proxy %q: %v", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.trustedProxies = networks
+	return nil
+}
+
+// SetTrustedProxies sets the reverse proxies trusted by the default *Client
+func SetTrustedProxies(proxies ...string) error {
+	return DefaultClient.SetTrustedProxies(proxies...)
+}
+
+// ClientIP returns the address of the client which sent req. The forwarding
+// headers are only consulted when the peer is a trusted proxy, in which case
+// the rightmost forwarded address which isn't a trusted proxy is returned.
+func (client *Client) ClientIP(req *http.Request) string {
+	client.mu.RLock()
+	trusted := client.trustedProxies
+	client.mu.RUnlock()
+
+	peer := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	if !isTrustedProxy(peer, trusted) {
+		return peer
+	}
+
+	var forwarded []string
+	if header := req.Header.Get("Forwarded"); header != "" {
+		forwarded = parseForwarded(header)
+	} else if header := req.Header.Get("X-Forwarded-For"); header != "" {
+		for _, addr := range strings.Split(header, ",") {
+			forwarded = append(forwarded, strings.TrimSpace(addr))
+		}
+	} else if header := req.Header.Get("X-Real-IP"); header != "" {
+		forwarded = []string{strings.TrimSpace(header)}
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if net.ParseIP(forwarded[i]) == nil {
+			break
+		}
+		peer = forwarded[i]
+		if !isTrustedProxy(peer, trusted) {
+			break
+		}
+	}
+	return peer
+}
+
+// parseForwarded returns the addresses of the for parameters of a RFC 7239
+// Forwarded header, without quotes, brackets and ports.
+func parseForwarded(header string) []string {
+	var addrs []string
+	for _, element := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) < 4 || !strings.EqualFold(pair[:4], "for=") {
+				continue
+			}
+			addr := strings.Trim(pair[4:], `"`)
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			addrs = append(addrs, strings.Trim(addr, "[]"))
+		}
+	}
+	return addrs
+}
+
+func isTrustedProxy(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
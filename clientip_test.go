@@ -0,0 +1,42 @@
+package raven
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	client := newClient(nil)
+	if err := client.SetTrustedProxies("10.0.0.0/8", "192.168.1.1", "fd00::/8"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		RemoteAddr string
+		Headers    map[string]string
+		Expected   string
+	}{
+		{"203.0.113.7:1234", nil, "203.0.113.7"},
+		{"203.0.113.7:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9, 10.1.1.1"}, "203.0.113.9"},
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-For": "10.1.1.1, 10.2.2.2"}, "10.1.1.1"},
+		{"10.0.0.2:1234", map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+		{"192.168.1.1:1234", map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}, "2001:db8::1"},
+		{"[fd00::1]:1234", map[string]string{"X-Forwarded-For": "unknown"}, "fd00::1"},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = test.RemoteAddr
+		for key, value := range test.Headers {
+			req.Header.Set(key, value)
+		}
+		if ip := client.ClientIP(req); ip != test.Expected {
+			t.Errorf("Case [%d]: expected %s, got %s", i, test.Expected, ip)
+		}
+	}
+
+	if err := client.SetTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
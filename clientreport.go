@@ -0,0 +1,66 @@
+package raven
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// clientReportInterval is how long dropped packets are counted before they
+// are reported to Sentry
+const clientReportInterval = 30 * time.Second
+
+// clientReport tells Sentry how many events a client discarded and why -
+// https://develop.sentry.dev/sdk/telemetry/client-reports/
+type clientReport struct {
+	Timestamp       float64           `json:"timestamp"`
+	DiscardedEvents []discardedEvents `json:"discarded_events"`
+}
+
+type discardedEvents struct {
+	Reason   DropReason `json:"reason"`
+	Category string     `json:"category"`
+	Quantity int        `json:"quantity"`
+}
+
+// SetClientReports enables or disables the client reports of given client,
+// which send the number of dropped packets by reason to Sentry every 30
+// seconds and on Wait, if the transport is an EnvelopeTransport. They are
+// enabled by default.
+func (client *Client) SetClientReports(enabled bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.clientReportsDisabled = !enabled
+}
+
+// SetClientReports enables or disables the client reports of the default *Client
+func SetClientReports(enabled bool) { DefaultClient.SetClientReports(enabled) }
+
+// flushClientReports reports the packets dropped since the last report
+func (client *Client) flushClientReports() {
+	counts := client.drops.take()
+	client.mu.RLock()
+	disabled := client.clientReportsDisabled
+	client.mu.RUnlock()
+	if len(counts) == 0 || disabled {
+		return
+	}
+
+	report := clientReport{Timestamp: float64(time.Now().UnixNano()) / 1e9}
+	for reason, quantity := range counts {
+		report.DiscardedEvents = append(report.DiscardedEvents, discardedEvents{reason, "error", quantity})
+	}
+	sort.Sort(byDropReason(report.DiscardedEvents))
+	payload, err := json.Marshal(report)
+	if err != nil {
+		client.Logger().Errorf("error marshaling client report: %v", err)
+		return
+	}
+	client.sendEnvelope("client report", "", nil, envelopeItem{Type: "client_report", Payload: payload})
+}
+
+type byDropReason []discardedEvents
+
+func (d byDropReason) Len() int           { return len(d) }
+func (d byDropReason) Less(i, j int) bool { return d[i].Reason < d[j].Reason }
+func (d byDropReason) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
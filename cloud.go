@@ -0,0 +1,189 @@
+package raven
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CloudProvider selects the instance metadata service queried by CloudContextCollector
+type CloudProvider string
+
+// Supported cloud providers
+const (
+	CloudAWS   = CloudProvider("aws")
+	CloudGCP   = CloudProvider("gcp")
+	CloudAzure = CloudProvider("azure")
+)
+
+// Instance metadata endpoints, variables so tests can point them to a fake server
+var (
+	awsMetadataURL   = "http://169.254.169.254"
+	gcpMetadataURL   = "http://metadata.google.internal"
+	azureMetadataURL = "http://169.254.169.254"
+)
+
+var cloudMetadataClient = &http.Client{Timeout: time.Second}
+
+// CloudContextCollector returns an opt-in collector attaching the region,
+// zone, instance id and instance type of the current instance of provider
+// as the cloud context. The metadata service is only queried once, by the
+// worker sending the first packet, and the context is skipped when it is
+// unreachable.
+func CloudContextCollector(provider CloudProvider) ContextCollector {
+	var (
+		once    sync.Once
+		context map[string]interface{}
+	)
+	return func(packet *Packet) (string, interface{}) {
+		once.Do(func() {
+			metadata, err := fetchCloudMetadata(provider)
+			if err != nil {
+				return
+			}
+			context = map[string]interface{}{"provider": string(provider)}
+			for key, value := range metadata {
+				if value != "" {
+					context[key] = value
+				}
+			}
+		})
+		if context == nil {
+			return "cloud", nil
+		}
+		return "cloud", context
+	}
+}
+
+func fetchCloudMetadata(provider CloudProvider) (map[string]string, error) {
+	switch provider {
+	case CloudAWS:
+		return fetchAWSMetadata()
+	case CloudGCP:
+		return fetchGCPMetadata()
+	case CloudAzure:
+		return fetchAzureMetadata()
+	}
+	return nil, fmt.Errorf("raven: unknown cloud provider %q", provider)
+}
+
+func fetchAWSMetadata() (map[string]string, error) {
+	// IMDSv2 requires a session token, IMDSv1 works without one
+	req, err := http.NewRequest("PUT", awsMetadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, _ := fetchMetadata(req)
+
+	req, err = http.NewRequest("GET", awsMetadataURL+"/latest/dynamic/instance-identity/document", nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	}
+	body, err := fetchMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"region":        document.Region,
+		"zone":          document.AvailabilityZone,
+		"instance_id":   document.InstanceID,
+		"instance_type": document.InstanceType,
+	}, nil
+}
+
+func fetchGCPMetadata() (map[string]string, error) {
+	req, err := http.NewRequest("GET", gcpMetadataURL+"/computeMetadata/v1/instance/?recursive=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := fetchMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var instance struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`
+		MachineType string      `json:"machineType"`
+	}
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, err
+	}
+	// zone and machine type are resource paths, e.g. projects/1/zones/us-central1-a
+	zone := instance.Zone[strings.LastIndex(instance.Zone, "/")+1:]
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+	return map[string]string{
+		"region":        region,
+		"zone":          zone,
+		"instance_id":   instance.ID.String(),
+		"instance_type": instance.MachineType[strings.LastIndex(instance.MachineType, "/")+1:],
+	}, nil
+}
+
+func fetchAzureMetadata() (map[string]string, error) {
+	req, err := http.NewRequest("GET", azureMetadataURL+"/metadata/instance/compute?api-version=2021-02-01", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := fetchMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var compute struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMID     string `json:"vmId"`
+		VMSize   string `json:"vmSize"`
+	}
+	if err := json.Unmarshal(body, &compute); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"region":        compute.Location,
+		"zone":          compute.Zone,
+		"instance_id":   compute.VMID,
+		"instance_type": compute.VMSize,
+	}, nil
+}
+
+func fetchMetadata(req *http.Request) ([]byte, error) {
+	req.Header.Set("User-Agent", userAgent)
+	res, err := cloudMetadataClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("raven: metadata service returned http status %d", res.StatusCode)
+	}
+	return body, nil
+}
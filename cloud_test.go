@@ -0,0 +1,55 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCloudContextCollector(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("token"))
+	})
+	mux.HandleFunc("/latest/dynamic/instance-identity/document", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"region":"eu-west-1","availabilityZone":"eu-west-1a","instanceId":"i-123","instanceType":"t3.micro"}`))
+	})
+	mux.HandleFunc("/computeMetadata/v1/instance/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":4520031799277581759,"zone":"projects/1/zones/us-central1-a","machineType":"projects/1/machineTypes/n1-standard-1"}`))
+	})
+	mux.HandleFunc("/metadata/instance/compute", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"location":"westeurope","zone":"1","vmId":"02aab8a4","vmSize":"Standard_A3"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	defer func(aws, gcp, azure string) {
+		awsMetadataURL, gcpMetadataURL, azureMetadataURL = aws, gcp, azure
+	}(awsMetadataURL, gcpMetadataURL, azureMetadataURL)
+	awsMetadataURL, gcpMetadataURL, azureMetadataURL = server.URL, server.URL, server.URL
+
+	testCases := []struct {
+		Provider CloudProvider
+		Expected map[string]interface{}
+	}{
+		{CloudAWS, map[string]interface{}{"provider": "aws", "region": "eu-west-1", "zone": "eu-west-1a", "instance_id": "i-123", "instance_type": "t3.micro"}},
+		{CloudGCP, map[string]interface{}{"provider": "gcp", "region": "us-central1", "zone": "us-central1-a", "instance_id": "4520031799277581759", "instance_type": "n1-standard-1"}},
+		{CloudAzure, map[string]interface{}{"provider": "azure", "region": "westeurope", "zone": "1", "instance_id": "02aab8a4", "instance_type": "Standard_A3"}},
+	}
+	for _, test := range testCases {
+		name, context := CloudContextCollector(test.Provider)(&Packet{})
+		if name != "cloud" || !reflect.DeepEqual(context, test.Expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.Provider, test.Expected, context)
+		}
+	}
+
+	server.Close()
+	if _, context := CloudContextCollector(CloudAWS)(&Packet{}); context != nil {
+		t.Errorf("expected no context when the metadata service is unreachable, got %+v", context)
+	}
+}
@@ -0,0 +1,102 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// cliFlushTimeout bounds how long a failing command waits for its events to
+// be sent, the process usually exits right after.
+var cliFlushTimeout = 2 * time.Second
+
+// WrapCobra wraps the Run and RunE functions of cmd, a *cobra.Command, and
+// of all its subcommands, so that errors returned by RunE and panics are
+// captured, tagged with the command path and the flags which were set, and
+// flushed before Execute returns. Flag values which look like secrets are
+// masked. Commands are wrapped through reflection, so this package doesn't
+// depend on cobra; add subcommands before calling it.
+func (client *Client) WrapCobra(cmd interface{}) error {
+	v := reflect.ValueOf(cmd)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct || !v.MethodByName("Commands").IsValid() {
+		return fmt.Errorf("raven: WrapCobra expects a *cobra.Command, got %T", cmd)
+	}
+	client.wrapCobraCommand(v)
+	return nil
+}
+
+// WrapCobra wraps cmd with the default *Client, see Client.WrapCobra
+func WrapCobra(cmd interface{}) error { return DefaultClient.WrapCobra(cmd) }
+
+func (client *Client) wrapCobraCommand(cmd reflect.Value) {
+	for _, name := range []string{"RunE", "Run"} {
+		field := cmd.Elem().FieldByName(name)
+		if !field.IsValid() || field.Kind() != reflect.Func || field.IsNil() {
+			continue
+		}
+		next := field.Interface()
+		field.Set(reflect.MakeFunc(field.Type(), func(args []reflect.Value) []reflect.Value {
+			return client.runCobraCommand(reflect.ValueOf(next), args)
+		}))
+	}
+
+	subcommands := cmd.MethodByName("Commands").Call(nil)[0]
+	for i := 0; i < subcommands.Len(); i++ {
+		client.wrapCobraCommand(subcommands.Index(i))
+	}
+}
+
+func (client *Client) runCobraCommand(next reflect.Value, args []reflect.Value) (results []reflect.Value) {
+	tags := cobraTags(args[0])
+	defer func() {
+		if rval := recover(); rval != nil {
+			err, ok := rval.(error)
+			if !ok {
+				err = errors.New(fmt.Sprint(rval))
+			}
+			client.CaptureError(err, tags)
+			client.waitTimeout(cliFlushTimeout)
+			panic(rval)
+		}
+	}()
+
+	results = next.Call(args)
+	if len(results) == 1 && !results[0].IsNil() {
+		client.CaptureError(results[0].Interface().(error), tags)
+		client.waitTimeout(cliFlushTimeout)
+	}
+	return results
+}
+
+// cobraTags tags the path of a *cobra.Command and the flags which were set
+func cobraTags(cmd reflect.Value) map[string]string {
+	tags := map[string]string{
+		"cli.command": cmd.MethodByName("CommandPath").Call(nil)[0].String(),
+	}
+
+	flags := cmd.MethodByName("Flags").Call(nil)[0]
+	visit := flags.MethodByName("Visit")
+	if !visit.IsValid() {
+		return tags
+	}
+	visit.Call([]reflect.Value{reflect.MakeFunc(visit.Type().In(0), func(args []reflect.Value) []reflect.Value {
+		flag := args[0].Elem()
+		name := flag.FieldByName("Name").String()
+		value := fmt.Sprint(flag.FieldByName("Value").Interface())
+		if isSecretField(name) {
+			value = "********"
+		}
+		tags["cli.flag."+name] = value
+		return nil
+	})})
+	return tags
+}
+
+// waitTimeout waits for in-flight events like Wait, but at most timeout
+func (client *Client) waitTimeout(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client.WaitContext(ctx)
+}
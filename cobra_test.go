@@ -0,0 +1,99 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeCommand mimics the parts of *cobra.Command used by WrapCobra
+type fakeCommand struct {
+	Use      string
+	Run      func(cmd *fakeCommand, args []string)
+	RunE     func(cmd *fakeCommand, args []string) error
+	parent   *fakeCommand
+	children []*fakeCommand
+	flags    *fakeFlagSet
+}
+
+func (c *fakeCommand) CommandPath() string {
+	if c.parent == nil {
+		return c.Use
+	}
+	return c.parent.CommandPath() + " " + c.Use
+}
+
+func (c *fakeCommand) Commands() []*fakeCommand { return c.children }
+func (c *fakeCommand) Flags() *fakeFlagSet      { return c.flags }
+
+type fakeValue string
+
+func (v fakeValue) String() string { return string(v) }
+
+type fakeFlag struct {
+	Name  string
+	Value fakeValue
+}
+
+type fakeFlagSet struct {
+	set []*fakeFlag
+}
+
+func (f *fakeFlagSet) Visit(fn func(*fakeFlag)) {
+	for _, flag := range f.set {
+		fn(flag)
+	}
+}
+
+func TestWrapCobra(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	failure := errors.New("migration failed")
+	root := &fakeCommand{Use: "app", flags: &fakeFlagSet{}}
+	migrate := &fakeCommand{
+		Use:    "migrate",
+		parent: root,
+		flags:  &fakeFlagSet{[]*fakeFlag{{"steps", "3"}, {"db-password", "hunter2"}}},
+		RunE:   func(cmd *fakeCommand, args []string) error { return failure },
+	}
+	crash := &fakeCommand{
+		Use:    "crash",
+		parent: root,
+		flags:  &fakeFlagSet{},
+		Run:    func(cmd *fakeCommand, args []string) { panic("boom") },
+	}
+	root.children = []*fakeCommand{migrate, crash}
+
+	if err := client.WrapCobra(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate.RunE(migrate, nil); err != failure {
+		t.Errorf("expected the command error, got %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		crash.Run(crash, nil)
+	}()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 flushed packets, got %d", len(transport.packets))
+	}
+	tags := map[string]string{}
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["cli.command"] != "app migrate" || tags["cli.flag.steps"] != "3" || tags["cli.flag.db-password"] != "********" {
+		t.Errorf("incorrect tags %+v", tags)
+	}
+
+	if err := client.WrapCobra(struct{}{}); err == nil {
+		t.Error("expected an error for a non command")
+	}
+}
@@ -0,0 +1,121 @@
+package raven
+
+import (
+	"runtime"
+	"time"
+)
+
+// Contexts holds structured data about the environment of an event keyed by
+// context name, e.g. "device" or "runtime", which Sentry indexes and
+// displays separately from extra.
+type Contexts map[string]interface{}
+
+// ContextCollector returns a named context attached to packets sent by a
+// client. Collectors run lazily on the worker right before the packet is
+// sent and may return a nil context to skip the packet. Contexts already set
+// on the packet under the same name are left untouched, unless both are
+// maps, in which case the missing keys are added to the existing context.
+type ContextCollector func(packet *Packet) (name string, context interface{})
+
+// MemStatsContextCollector attaches heap, GC and goroutine statistics as the
+// runtime context of ERROR and FATAL packets, to correlate failures with
+// memory pressure. It is opt-in as runtime.ReadMemStats stops the world.
+func MemStatsContextCollector(packet *Packet) (string, interface{}) {
+	if packet.Level != ERROR && packet.Level != FATAL {
+		return "runtime", nil
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	context := map[string]interface{}{
+		"name":           "go",
+		"version":        runtime.Version(),
+		"heap_inuse":     stats.HeapInuse,
+		"heap_objects":   stats.HeapObjects,
+		"num_gc":         stats.NumGC,
+		"gc_pause_total": time.Duration(stats.PauseTotalNs).String(),
+		"num_goroutine":  runtime.NumGoroutine(),
+	}
+	if stats.NumGC > 0 {
+		context["gc_pause_last"] = time.Duration(stats.PauseNs[(stats.NumGC+255)%256]).String()
+	}
+	return "runtime", context
+}
+
+// SetContextCollectors replaces the context collectors of given client,
+// calling it without collectors disables them.
+func (client *Client) SetContextCollectors(collectors ...ContextCollector) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.contextCollectors = collectors
+}
+
+// AddContextCollector adds a context collector to given client, e.g.
+// MemStatsContextCollector.
+func (client *Client) AddContextCollector(collector ContextCollector) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.contextCollectors = append(client.contextCollectors, collector)
+}
+
+// SetContextCollectors replaces the context collectors of the default *Client
+func SetContextCollectors(collectors ...ContextCollector) {
+	DefaultClient.SetContextCollectors(collectors...)
+}
+
+// AddContextCollector adds a context collector to the default *Client
+func AddContextCollector(collector ContextCollector) {
+	DefaultClient.AddContextCollector(collector)
+}
+
+// collectContexts runs collectors on packets which were not serialized yet
+func collectContexts(packet *Packet, collectors []ContextCollector) {
+	if packet.raw != nil {
+		return
+	}
+	for _, collect := range collectors {
+		name, context := collect(packet)
+		if context == nil {
+			continue
+		}
+		if existing, ok := packet.Contexts[name]; ok {
+			mergeContext(existing, context)
+			continue
+		}
+		if packet.Contexts == nil {
+			packet.Contexts = Contexts{}
+		}
+		packet.Contexts[name] = copyContext(context)
+	}
+}
+
+// copyContext copies map contexts, which collectors may share between
+// packets, so that merging into them does not leak into other packets.
+func copyContext(context interface{}) interface{} {
+	m, ok := context.(map[string]interface{})
+	if !ok {
+		return context
+	}
+	copied := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
+// mergeContext adds the keys of context missing in existing, if both are maps
+func mergeContext(existing, context interface{}) {
+	dst, ok := existing.(map[string]interface{})
+	if !ok {
+		return
+	}
+	src, ok := context.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range src {
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+}
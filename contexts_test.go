@@ -0,0 +1,28 @@
+package raven
+
+import "testing"
+
+func TestMemStatsContextCollector(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+	client.SetContextCollectors(MemStatsContextCollector)
+
+	errorPacket := &Packet{Message: "foo", Level: ERROR}
+	infoPacket := &Packet{Message: "foo", Level: INFO}
+	userPacket := &Packet{Message: "foo", Level: FATAL, Contexts: Contexts{"runtime": "custom"}}
+	for _, packet := range []*Packet{errorPacket, infoPacket, userPacket} {
+		_, ch := client.Capture(packet, nil)
+		<-ch
+	}
+
+	runtimeContext, ok := errorPacket.Contexts["runtime"].(map[string]interface{})
+	if !ok || runtimeContext["name"] != "go" || runtimeContext["num_goroutine"] == nil {
+		t.Errorf("incorrect runtime context: %+v", errorPacket.Contexts)
+	}
+	if infoPacket.Contexts != nil {
+		t.Errorf("info packets should not collect memory stats: %+v", infoPacket.Contexts)
+	}
+	if userPacket.Contexts["runtime"] != "custom" {
+		t.Errorf("existing contexts should not be replaced: %+v", userPacket.Contexts)
+	}
+}
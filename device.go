@@ -0,0 +1,89 @@
+package raven
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var processStart = time.Now()
+
+var (
+	deviceOnce    sync.Once
+	deviceContext map[string]interface{}
+)
+
+// DeviceContextCollector attaches the host the process runs on as the
+// device context: hostname, architecture, number of CPUs, total memory, boot
+// and process start time. Memory and boot time are only known on Linux. It
+// is enabled by default and only inspects the host once, except for the
+// hostname which follows SetHostname and RefreshHostname.
+func DeviceContextCollector(packet *Packet) (string, interface{}) {
+	deviceOnce.Do(func() {
+		deviceContext = map[string]interface{}{
+			"arch":               runtime.GOARCH,
+			"processor_count":    runtime.NumCPU(),
+			"process_start_time": processStart.UTC().Format(time.RFC3339),
+		}
+		if memory, ok := readMemTotal("/proc/meminfo"); ok {
+			deviceContext["memory_size"] = memory
+		}
+		if boot, ok := readBootTime("/proc/stat"); ok {
+			deviceContext["boot_time"] = boot.UTC().Format(time.RFC3339)
+		}
+	})
+	device := make(map[string]interface{}, len(deviceContext)+1)
+	for key, value := range deviceContext {
+		device[key] = value
+	}
+	device["name"] = Hostname()
+	return "device", device
+}
+
+// readMemTotal returns the MemTotal of a /proc/meminfo file in bytes
+func readMemTotal(path string) (uint64, bool) {
+	value, ok := readProcField(path, "MemTotal:")
+	if !ok {
+		return 0, false
+	}
+	kb, err := strconv.ParseUint(strings.TrimSuffix(value, " kB"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return kb * 1024, true
+}
+
+// readBootTime returns the btime of a /proc/stat file
+func readBootTime(path string) (time.Time, bool) {
+	value, ok := readProcField(path, "btime")
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// readProcField returns the trimmed remainder of the first line of file starting with key
+func readProcField(path, key string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, key) {
+			return strings.TrimSpace(strings.TrimPrefix(line, key)), true
+		}
+	}
+	return "", false
+}
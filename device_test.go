@@ -0,0 +1,40 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadProcFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	meminfo := filepath.Join(dir, "meminfo")
+	ioutil.WriteFile(meminfo, []byte("MemTotal:       16314372 kB\nMemFree:         1416512 kB\n"), 0644)
+	if memory, ok := readMemTotal(meminfo); !ok || memory != 16314372*1024 {
+		t.Errorf("incorrect memory size: %d, %v", memory, ok)
+	}
+
+	stat := filepath.Join(dir, "stat")
+	ioutil.WriteFile(stat, []byte("cpu  1 2 3\nbtime 1700000000\nprocesses 42\n"), 0644)
+	if boot, ok := readBootTime(stat); !ok || boot.Unix() != 1700000000 {
+		t.Errorf("incorrect boot time: %v, %v", boot, ok)
+	}
+
+	if _, ok := readMemTotal(filepath.Join(dir, "missing")); ok {
+		t.Error("missing files should not be read")
+	}
+}
+
+func TestDeviceContextCollector(t *testing.T) {
+	name, context := DeviceContextCollector(&Packet{})
+	device, ok := context.(map[string]interface{})
+	if name != "device" || !ok || device["name"] != hostname || device["arch"] == "" {
+		t.Errorf("incorrect device context %q: %+v", name, context)
+	}
+}
@@ -0,0 +1,58 @@
+package raven
+
+// InternalErrorKind classifies the errors passed to InternalErrorHandler
+type InternalErrorKind string
+
+// Kinds of internal errors
+const (
+	// A packet or envelope could not be serialized
+	InternalSerialization = InternalErrorKind("serialization")
+	// Sentry could not be reached or rejected a packet or envelope
+	InternalTransport = InternalErrorKind("transport")
+	// A packet was dropped, see DropReason
+	InternalDropped = InternalErrorKind("dropped")
+)
+
+// InternalError describes a failure of the SDK itself, passed to the
+// InternalErrorHandler of a client, e.g. to count them in metrics.
+type InternalError struct {
+	Kind InternalErrorKind
+	Err  error
+
+	// Packet which failed, nil for envelopes like logs and sessions. It must
+	// not be retained after the handler returned.
+	Packet *Packet
+
+	// Reason is set for dropped packets
+	Reason DropReason
+}
+
+func (e *InternalError) Error() string { return string(e.Kind) + ": " + e.Err.Error() }
+
+// Cause returns the underlying error
+func (e *InternalError) Cause() error { return e.Err }
+
+// serializationError marks errors of the transport serializing a packet
+type serializationError struct {
+	err error
+}
+
+func (e *serializationError) Error() string {
+	return "raven: error serializing packet: " + e.err.Error()
+}
+
+// Cause returns the underlying error
+func (e *serializationError) Cause() error { return e.err }
+
+// internalError reports an error sending packet, which is nil for
+// envelopes, to the InternalErrorHandler of given client
+func (client *Client) internalError(err error, packet *Packet) {
+	if client.InternalErrorHandler == nil || err == nil {
+		return
+	}
+	kind := InternalTransport
+	if _, ok := err.(*serializationError); ok {
+		kind = InternalSerialization
+	}
+	client.InternalErrorHandler(&InternalError{Kind: kind, Err: err, Packet: packet})
+}
@@ -0,0 +1,59 @@
+package raven
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) Send(url, authHeader string, packet *Packet) error {
+	return errors.New("unreachable")
+}
+
+func TestInternalErrorHandler(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		internalErrors []*InternalError
+	)
+	handler := func(err *InternalError) {
+		mu.Lock()
+		defer mu.Unlock()
+		internalErrors = append(internalErrors, err)
+	}
+
+	client := newClient(nil)
+	client.Transport = failingTransport{}
+	client.InternalErrorHandler = handler
+	client.CaptureMessageAndWait("failing", nil)
+	client.SetSampleRate(0)
+	client.CaptureMessage("sampled", nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serializing, _ := New(strings.Replace(server.URL, "http://", "http://public@", 1) + "/42")
+	serializing.InternalErrorHandler = handler
+	packet := NewPacket("unserializable")
+	packet.Extra["channel"] = make(chan int)
+	_, ch := serializing.Capture(packet, nil)
+	<-ch
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []InternalErrorKind{InternalTransport, InternalDropped, InternalSerialization}
+	if len(internalErrors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), internalErrors)
+	}
+	for i, kind := range expected {
+		if internalErrors[i].Kind != kind || internalErrors[i].Packet == nil {
+			t.Errorf("expected a %s error with packet, got %+v", kind, internalErrors[i])
+		}
+	}
+	if internalErrors[1].Reason != DropSampled {
+		t.Errorf("expected a sampled packet, got %s", internalErrors[1].Reason)
+	}
+}
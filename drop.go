@@ -0,0 +1,136 @@
+package raven
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DropReason tells why a packet was not delivered, values follow the
+// discard reasons of Sentry client reports.
+type DropReason string
+
+// Reasons passed to DropHandlerWithReason
+const (
+	// The client queue was full, see MaxQueueBuffer
+	DropQueueFull = DropReason("queue_overflow")
+	// Sentry answered with 429 Too Many Requests
+	DropRateLimited = DropReason("ratelimit_backoff")
+	// The packet was not selected by the client sample rate
+	DropSampled = DropReason("sample_rate")
+	// The packet message matched the client ignore errors
+	DropIgnored = DropReason("event_processor")
+	// The circuit breaker of the transport was open
+	DropCircuitOpen = DropReason("network_error")
+	// The client was closed, see Close
+	DropClosed = DropReason("send_error")
+	// The BeforeSend hook of the client returned nil
+	DropBeforeSendNil = DropReason("before_send")
+	// The transport failed to serialize the packet
+	DropSerializationError = DropReason("internal_sdk_error")
+)
+
+// DroppedPacket is passed to DropHandlerWithReason for every dropped packet
+type DroppedPacket struct {
+	Packet *Packet
+	Reason DropReason
+
+	once    sync.Once
+	payload []byte
+	err     error
+}
+
+// Payload returns the serialized packet, e.g. to persist it to disk or a
+// secondary sink. It is serialized at most once, however many times it is called.
+func (d *DroppedPacket) Payload() ([]byte, error) {
+	d.once.Do(func() {
+		d.payload, d.err = d.Packet.JSON()
+	})
+	return d.payload, d.err
+}
+
+// dropStats counts the dropped packets of a client by reason, in total and
+// since the last client report
+type dropStats struct {
+	mu      sync.Mutex
+	counts  map[DropReason]int
+	pending map[DropReason]int
+}
+
+// add counts a dropped packet and tells whether it is the first one since the last client report
+func (s *dropStats) add(reason DropReason) (first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[DropReason]int)
+	}
+	s.counts[reason]++
+	if s.pending == nil {
+		s.pending = make(map[DropReason]int)
+	}
+	s.pending[reason]++
+	return len(s.pending) == 1 && s.pending[reason] == 1
+}
+
+// take returns the counts since the last client report and resets them
+func (s *dropStats) take() map[DropReason]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := s.pending
+	s.pending = nil
+	return pending
+}
+
+// DroppedPackets returns how many packets given client dropped so far, by reason
+func (client *Client) DroppedPackets() map[DropReason]int {
+	client.drops.mu.Lock()
+	defer client.drops.mu.Unlock()
+
+	counts := make(map[DropReason]int, len(client.drops.counts))
+	for reason, count := range client.drops.counts {
+		counts[reason] = count
+	}
+	return counts
+}
+
+// DroppedPackets returns how many packets the default *Client dropped so far, by reason
+func DroppedPackets() map[DropReason]int { return DefaultClient.DroppedPackets() }
+
+// drop reports a dropped packet to the drop handlers of given client
+func (client *Client) drop(packet *Packet, reason DropReason) {
+	client.dropWithError(packet, reason, ErrPacketDropped)
+}
+
+// dropWithError is drop for a packet whose send failed with err
+func (client *Client) dropWithError(packet *Packet, reason DropReason, err error) {
+	if client.drops.add(reason) {
+		time.AfterFunc(clientReportInterval, client.flushClientReports)
+	}
+	if client.DropHandler != nil && (reason == DropQueueFull || reason == DropCircuitOpen) {
+		client.DropHandler(packet)
+	}
+	if client.DropHandlerWithReason != nil {
+		client.DropHandlerWithReason(&DroppedPacket{Packet: packet, Reason: reason})
+	}
+	if client.InternalErrorHandler != nil {
+		kind := InternalDropped
+		if reason == DropSerializationError {
+			kind = InternalSerialization
+		}
+		client.InternalErrorHandler(&InternalError{Kind: kind, Err: err, Packet: packet, Reason: reason})
+	}
+}
+
+// dropReasonOf tells whether a send error means the packet was dropped
+func dropReasonOf(err error) (DropReason, bool) {
+	if err == ErrCircuitOpen {
+		return DropCircuitOpen, true
+	}
+	if _, ok := err.(*serializationError); ok {
+		return DropSerializationError, true
+	}
+	if transportErr, ok := err.(*TransportError); ok && transportErr.StatusCode == http.StatusTooManyRequests {
+		return DropRateLimited, true
+	}
+	return "", false
+}
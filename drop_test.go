@@ -0,0 +1,137 @@
+package raven
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDropHandlerWithReason(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{fail: map[string]bool{"": true}}
+	client.SetCircuitBreaker(1, time.Hour)
+	client.SetIgnoreErrors([]string{"^ignored$"})
+
+	var dropped []*DroppedPacket
+	client.DropHandlerWithReason = func(d *DroppedPacket) {
+		dropped = append(dropped, d)
+	}
+
+	client.Capture(NewPacket("ignored"), nil)
+	client.CaptureMessageAndWait("first", nil)
+	client.CaptureMessageAndWait("second", nil)
+	client.SetSampleRate(0)
+	client.Capture(NewPacket("sampled"), nil)
+
+	expected := []DropReason{DropIgnored, DropCircuitOpen, DropSampled}
+	if len(dropped) != len(expected) {
+		t.Fatalf("expected %d dropped packets, got %d", len(expected), len(dropped))
+	}
+	for i, reason := range expected {
+		if dropped[i].Reason != reason {
+			t.Errorf("%d: incorrect reason: got %s, want %s", i, dropped[i].Reason, reason)
+		}
+	}
+
+	payload, err := dropped[1].Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload), `"message":"second"`) {
+		t.Errorf("incorrect payload: %s", payload)
+	}
+}
+
+func TestDropReasonOf(t *testing.T) {
+	if reason, ok := dropReasonOf(&TransportError{StatusCode: 429}); !ok || reason != DropRateLimited {
+		t.Errorf("expected DropRateLimited, got %s", reason)
+	}
+	if _, ok := dropReasonOf(&TransportError{StatusCode: 500}); ok {
+		t.Error("server errors should not be reported as drops")
+	}
+	if reason, ok := dropReasonOf(&serializationError{ErrPacketDropped}); !ok || reason != DropSerializationError {
+		t.Errorf("expected DropSerializationError, got %s", reason)
+	}
+}
+
+func TestBeforeSend(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.BeforeSend = func(packet *Packet) *Packet {
+		if packet.Message == "secret" {
+			return nil
+		}
+		packet.Message = "scrubbed"
+		return packet
+	}
+
+	if eventID, ch := client.Capture(NewPacket("secret"), nil); eventID != "" || <-ch != ErrPacketDropped {
+		t.Error("expected the packet to be dropped")
+	}
+	client.CaptureMessageAndWait("public", nil)
+	if len(transport.packets) != 1 || transport.packets[0].Message != "scrubbed" {
+		t.Errorf("expected the scrubbed packet to be sent, got %v", transport.packets)
+	}
+	client.SetSampleRate(0)
+	client.Capture(NewPacket("sampled"), nil)
+
+	expected := map[DropReason]int{DropBeforeSendNil: 1, DropSampled: 1}
+	if counts := client.DroppedPackets(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("incorrect drop counts: got %v, want %v", counts, expected)
+	}
+}
+
+type envelopeRecorder struct {
+	mu        sync.Mutex
+	envelopes []string
+}
+
+func (t *envelopeRecorder) Send(url, authHeader string, packet *Packet) error { return nil }
+
+func (t *envelopeRecorder) SendEnvelope(url, authHeader string, envelope []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.envelopes = append(t.envelopes, string(envelope))
+	return nil
+}
+
+func TestClientReports(t *testing.T) {
+	client := newClient(nil)
+	transport := &envelopeRecorder{}
+	client.Transport = transport
+	client.BeforeSend = func(packet *Packet) *Packet { return nil }
+	client.SetIgnoreErrors([]string{"ignored"})
+
+	client.CaptureMessage("discarded", nil)
+	client.CaptureMessage("discarded", nil)
+	client.CaptureMessage("ignored", nil)
+	client.Wait()
+	client.Wait()
+
+	if len(transport.envelopes) != 1 {
+		t.Fatalf("expected a single client report, got %q", transport.envelopes)
+	}
+	lines := strings.Split(transport.envelopes[0], "\n")
+	if !strings.Contains(lines[1], `"type":"client_report"`) {
+		t.Errorf("expected a client report item, got %s", lines[1])
+	}
+	var report clientReport
+	if err := json.Unmarshal([]byte(lines[2]), &report); err != nil {
+		t.Fatal(err)
+	}
+	expected := []discardedEvents{{DropBeforeSendNil, "error", 2}}
+	if !reflect.DeepEqual(report.DiscardedEvents, expected) {
+		t.Errorf("incorrect discarded events %+v", report.DiscardedEvents)
+	}
+
+	client.SetClientReports(false)
+	client.CaptureMessage("discarded", nil)
+	client.Wait()
+	if len(transport.envelopes) != 1 {
+		t.Errorf("expected no client report once disabled, got %d", len(transport.envelopes))
+	}
+}
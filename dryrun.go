@@ -0,0 +1,104 @@
+package raven
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// dryRunScheme is the DSN scheme selecting a WriterTransport instead of
+// HTTPTransport. "dryrun://" pretty-prints packets to stdout while
+// "dryrun:///var/log/sentry.json" appends one packet per line to the file.
+const dryRunScheme = "dryrun"
+
+// WriterTransport writes every serialized packet to Writer instead of
+// sending it to Sentry. It is meant for local development and staging
+// environments without a Sentry project.
+type WriterTransport struct {
+	Writer io.Writer
+
+	// Pretty indents the JSON output, otherwise packets are written one per line
+	Pretty bool
+
+	mu     sync.Mutex
+	closer io.Closer
+}
+
+// NewWriterTransport creates a WriterTransport writing packets to w
+func NewWriterTransport(w io.Writer, pretty bool) *WriterTransport {
+	return &WriterTransport{Writer: w, Pretty: pretty}
+}
+
+// Send writes the packet JSON to the configured Writer, url and authHeader are ignored
+func (t *WriterTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendBatch(context.Background(), url, authHeader, []*Packet{packet})
+}
+
+// SendBatch writes all packets to the configured Writer at once, ctx is ignored
+func (t *WriterTransport) SendBatch(ctx context.Context, url, authHeader string, packets []*Packet) error {
+	buf := &bytes.Buffer{}
+	for _, packet := range packets {
+		packetJSON, err := packet.JSON()
+		if err != nil {
+			return fmt.Errorf("raven: error marshaling packet %+v to JSON: %v", packet, err)
+		}
+		if t.Pretty {
+			if err := json.Indent(buf, packetJSON, "", "  "); err != nil {
+				return fmt.Errorf("raven: error indenting packet JSON: %v", err)
+			}
+		} else {
+			buf.Write(packetJSON)
+		}
+		buf.WriteByte('\n')
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := buf.WriteTo(t.Writer)
+	return err
+}
+
+// newDryRunTransport builds the WriterTransport described by a dryrun:// DSN
+func newDryRunTransport(uri *url.URL) (*WriterTransport, error) {
+	path := uri.Host + uri.Path
+	if path == "" || path == "stdout" {
+		return NewWriterTransport(os.Stdout, true), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("raven: can't open dry run output: %v", err)
+	}
+	t := NewWriterTransport(f, false)
+	t.closer = f
+	return t, nil
+}
+
+// close closes the file opened for a dryrun:// DSN
+func (t *WriterTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closer == nil {
+		return nil
+	}
+	err := t.closer.Close()
+	t.closer = nil
+	return err
+}
+
+// SetDryRun replaces the transport of given client with a WriterTransport
+// pretty-printing packets to w.
+func (client *Client) SetDryRun(w io.Writer) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Transport = NewWriterTransport(w, true)
+}
+
+// SetDryRun replaces the transport of the default *Client with a
+// WriterTransport pretty-printing packets to w.
+func SetDryRun(w io.Writer) { DefaultClient.SetDryRun(w) }
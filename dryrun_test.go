@@ -0,0 +1,95 @@
+package raven
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterTransport(t *testing.T) {
+	buf := &bytes.Buffer{}
+	transport := NewWriterTransport(buf, false)
+	packet := &Packet{Message: "foo", EventID: "1"}
+
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one packet per line, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], `{"message":"foo","event_id":"1"`) {
+		t.Errorf("incorrect packet JSON: %s", lines[0])
+	}
+}
+
+func TestWriterTransportPretty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	transport := NewWriterTransport(buf, true)
+	if err := transport.Send("", "", &Packet{Message: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "{\n  \"message\": \"foo\",") {
+		t.Errorf("expected indented JSON, got %s", buf.String())
+	}
+}
+
+func TestSetDSNDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+
+	client := newClient(nil)
+	original := client.Transport
+	if err := client.SetDSN("dryrun://" + path); err != nil {
+		t.Fatal(err)
+	}
+	dryRun, ok := client.Transport.(*WriterTransport)
+	if !ok {
+		t.Fatalf("expected *WriterTransport, got %T", client.Transport)
+	}
+
+	client.CaptureMessage("foo", nil)
+	client.Wait()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"message":"foo"`) {
+		t.Errorf("packet was not written to file: %s", data)
+	}
+
+	if err := client.SetDSN("https://public@sentry.example.com/1"); err != nil {
+		t.Fatal(err)
+	}
+	if client.Transport != original {
+		t.Errorf("expected the transport replaced by the dry run to be restored, got %T", client.Transport)
+	}
+	if dryRun.closer != nil {
+		t.Error("expected the dry run output to be closed")
+	}
+}
+
+func TestSetDryRun(t *testing.T) {
+	buf := &bytes.Buffer{}
+	client := newClient(nil)
+	client.SetDryRun(buf)
+
+	client.CaptureMessage("foo", nil)
+	client.Wait()
+
+	if !strings.Contains(buf.String(), `"message": "foo"`) {
+		t.Errorf("packet was not written: %s", buf.String())
+	}
+}
@@ -0,0 +1,92 @@
+package raven
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Dsn is a parsed Sentry DSN of the form
+// {Scheme}://{PublicKey}[:{SecretKey}]@{Host}[:{Port}]{Path}/{ProjectID}
+type Dsn struct {
+	Scheme    string
+	PublicKey string
+	// SecretKey is only set by legacy DSNs
+	SecretKey string
+	Host      string
+	// Port is 0 when the DSN has no explicit port
+	Port int
+	// Path is the prefix of the project id, e.g. "/sentry", without trailing slash
+	Path      string
+	ProjectID string
+}
+
+// ParseDsn parses and validates dsn
+func ParseDsn(dsn string) (*Dsn, error) {
+	uri, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if uri.User == nil || uri.User.Username() == "" {
+		return nil, ErrMissingUser
+	}
+
+	d := &Dsn{Scheme: uri.Scheme, PublicKey: uri.User.Username()}
+	d.SecretKey, _ = uri.User.Password()
+
+	host, port, err := net.SplitHostPort(uri.Host)
+	if err != nil {
+		// no port
+		host = strings.Trim(uri.Host, "[]")
+	} else if d.Port, err = strconv.Atoi(port); err != nil {
+		return nil, fmt.Errorf("raven: dsn has invalid port %q", port)
+	}
+	d.Host = host
+
+	if idx := strings.LastIndex(uri.Path, "/"); idx != -1 {
+		d.Path, d.ProjectID = uri.Path[:idx], uri.Path[idx+1:]
+	}
+	if d.ProjectID == "" {
+		return nil, ErrMissingProjectID
+	}
+	return d, nil
+}
+
+// String returns the DSN d was parsed from
+func (d *Dsn) String() string {
+	credentials := d.PublicKey
+	if d.SecretKey != "" {
+		credentials += ":" + d.SecretKey
+	}
+	return d.Scheme + "://" + credentials + "@" + d.hostPort() + d.Path + "/" + d.ProjectID
+}
+
+func (d *Dsn) hostPort() string {
+	if d.Port != 0 {
+		return net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	}
+	if strings.Contains(d.Host, ":") {
+		return "[" + d.Host + "]"
+	}
+	return d.Host
+}
+
+func (d *Dsn) apiURL(endpoint string) string {
+	return d.Scheme + "://" + d.hostPort() + d.Path + "/api/" + d.ProjectID + "/" + endpoint + "/"
+}
+
+// StoreAPIURL returns the url of the store endpoint events are sent to
+func (d *Dsn) StoreAPIURL() string { return d.apiURL("store") }
+
+// EnvelopeAPIURL returns the url of the envelope endpoint
+func (d *Dsn) EnvelopeAPIURL() string { return d.apiURL("envelope") }
+
+// AuthHeader returns the X-Sentry-Auth header authenticating requests to the endpoints of d
+func (d *Dsn) AuthHeader() string {
+	if d.SecretKey != "" {
+		return fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s, sentry_secret=%s", d.PublicKey, d.SecretKey)
+	}
+	return fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s", d.PublicKey)
+}
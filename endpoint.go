@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"context"
+	"fmt"
+)
+
+// Endpoint has the signature of go-kit's endpoint.Endpoint, to which it
+// converts without this package depending on go-kit:
+//
+//	func ravenMiddleware(method string) endpoint.Middleware {
+//		return func(next endpoint.Endpoint) endpoint.Endpoint {
+//			return endpoint.Endpoint(raven.WrapEndpoint(method, "http", raven.Endpoint(next)))
+//		}
+//	}
+type Endpoint func(ctx context.Context, request interface{}) (response interface{}, err error)
+
+// EndpointTagger extracts tags from the request metadata go-kit transports
+// store in the context, e.g. the http.ContextKeyRequestPath value.
+type EndpointTagger func(ctx context.Context) map[string]string
+
+// WrapEndpoint returns an Endpoint calling next, which captures the errors
+// it returns and recovers panics, returned as errors, tagged with the
+// endpoint method, transport and the tags of taggers.
+func (client *Client) WrapEndpoint(method, transport string, next Endpoint, taggers ...EndpointTagger) Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		tags := map[string]string{"endpoint.method": method}
+		if transport != "" {
+			tags["endpoint.transport"] = transport
+		}
+		for _, tagger := range taggers {
+			for key, value := range tagger(ctx) {
+				tags[key] = value
+			}
+		}
+
+		err = client.captureHandler(tags, func() error {
+			var err error
+			response, err = next(ctx, request)
+			return err
+		})
+		return response, err
+	}
+}
+
+// WrapEndpoint wraps next with the default *Client, see Client.WrapEndpoint
+func WrapEndpoint(method, transport string, next Endpoint, taggers ...EndpointTagger) Endpoint {
+	return DefaultClient.WrapEndpoint(method, transport, next, taggers...)
+}
+
+// ContextTagger returns an EndpointTagger tagging tag with the value of key
+// in the context, e.g. one of the request metadata keys of go-kit transports.
+func ContextTagger(key interface{}, tag string) EndpointTagger {
+	return func(ctx context.Context) map[string]string {
+		value := ctx.Value(key)
+		if value == nil {
+			return nil
+		}
+		return map[string]string{tag: fmt.Sprint(value)}
+	}
+}
@@ -0,0 +1,47 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type endpointContextKey int
+
+func TestWrapEndpoint(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	failure := errors.New("not found")
+	endpoint := client.WrapEndpoint("GetUser", "http", func(ctx context.Context, request interface{}) (interface{}, error) {
+		if request == nil {
+			panic("nil request")
+		}
+		return "user", failure
+	}, ContextTagger(endpointContextKey(0), "http.path"))
+
+	ctx := context.WithValue(context.Background(), endpointContextKey(0), "/users/1")
+	if response, err := endpoint(ctx, "1"); response != "user" || err != failure {
+		t.Errorf("expected the endpoint response and error, got %v, %v", response, err)
+	}
+	if _, err := endpoint(ctx, nil); err == nil {
+		t.Error("expected the panic as error")
+	}
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	for i, packet := range transport.packets {
+		tags := map[string]string{}
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["endpoint.method"] != "GetUser" || tags["endpoint.transport"] != "http" || tags["http.path"] != "/users/1" {
+			t.Errorf("packet %d: incorrect tags %+v", i, tags)
+		}
+	}
+}
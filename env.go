@@ -0,0 +1,9 @@
+//go:build !raven_noenv
+// +build !raven_noenv
+
+package raven
+
+// defaultClientFromEnv configures DefaultClient from the SENTRY_* environment
+// variables at package initialization, build with the raven_noenv tag to
+// disable it
+const defaultClientFromEnv = true
@@ -0,0 +1,9 @@
+//go:build raven_noenv
+// +build raven_noenv
+
+package raven
+
+// defaultClientFromEnv is false when built with the raven_noenv tag, so that
+// libraries embedding raven don't pick up the environment of the host
+// application
+const defaultClientFromEnv = false
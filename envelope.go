@@ -0,0 +1,127 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// envelopeContentType is the Content-Type of requests carrying envelopes
+const envelopeContentType = "application/x-sentry-envelope"
+
+// envelopeItem is a single item of a Sentry envelope - https://develop.sentry.dev/sdk/envelopes/
+type envelopeItem struct {
+	Type    string
+	Payload []byte
+
+	// Headers are added to the item header, e.g. content_type
+	Headers map[string]interface{}
+}
+
+// envelope serializes items into the newline delimited envelope format, dsc
+// is sent as trace header for dynamic sampling when not empty
+func envelope(eventID string, dsc DynamicSamplingContext, items ...envelopeItem) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	header, err := envelopeHeader(eventID, dsc)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(header)
+	buf.WriteByte('\n')
+
+	for _, item := range items {
+		headers := map[string]interface{}{
+			"type":   item.Type,
+			"length": len(item.Payload),
+		}
+		for key, value := range item.Headers {
+			headers[key] = value
+		}
+		itemHeader, err := json.Marshal(headers)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(itemHeader)
+		buf.WriteByte('\n')
+		buf.Write(item.Payload)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// envelopeHeader marshals the first line of an envelope
+func envelopeHeader(eventID string, dsc DynamicSamplingContext) ([]byte, error) {
+	headers := map[string]interface{}{"sent_at": time.Now().UTC().Format(time.RFC3339Nano)}
+	// envelopes without event, e.g. of sessions, have no event id
+	if eventID != "" {
+		headers["event_id"] = eventID
+	}
+	if len(dsc) > 0 {
+		headers["trace"] = dsc
+	}
+	return json.Marshal(headers)
+}
+
+// eventEnvelope wraps a packet into an envelope with a single event item
+func eventEnvelope(packet *Packet) ([]byte, error) {
+	packetJSON, err := packet.JSON()
+	if err != nil {
+		return nil, err
+	}
+	return envelope(packet.EventID, packet.dsc, envelopeItem{Type: "event", Payload: packetJSON})
+}
+
+// sendsEnvelope reports whether HTTPTransport posts the packet as an envelope
+// to the envelope endpoint rather than as JSON to the store endpoint. Only
+// packets with a dynamic sampling context are, as the store endpoint has no
+// header to carry it, and self-hosted Sentry before 20.6 no envelope endpoint.
+func (packet *Packet) sendsEnvelope() bool { return len(packet.dsc) > 0 }
+
+// writeEnvelope streams the packet into w as an envelope with a single event
+// item. The item header has no length, its payload ends at the newline which
+// JSON never contains unescaped.
+func (packet *Packet) writeEnvelope(w io.Writer) error {
+	header, err := envelopeHeader(packet.EventID, packet.dsc)
+	if err != nil {
+		return err
+	}
+	header = append(header, "\n{\"type\":\"event\"}\n"...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if err := packet.writeJSON(w); err != nil {
+		return err
+	}
+	_, err = w.Write([]byte{'\n'})
+	return err
+}
+
+// sendEnvelope sends an envelope of items in the background, tracked by Wait,
+// if the transport of given client supports envelopes. what names the items
+// in errors, e.g. "logs".
+func (client *Client) sendEnvelope(what, eventID string, dsc DynamicSamplingContext, items ...envelopeItem) {
+	client.mu.RLock()
+	url, authHeader, transport := client.url, client.authHeader, client.Transport
+	client.mu.RUnlock()
+
+	t := findEnvelopeTransport(transport)
+	if t == nil {
+		return
+	}
+	body, err := envelope(eventID, dsc, items...)
+	if err != nil {
+		client.Logger().Errorf("error building %s envelope: %v", what, err)
+		client.internalError(&serializationError{err}, nil)
+		return
+	}
+
+	client.wg.Add(1)
+	go func() {
+		defer client.wg.Done()
+		if err := t.SendEnvelope(url, authHeader, body); err != nil {
+			client.Logger().Errorf("error sending %s: %v", what, err)
+			client.internalError(err, nil)
+		}
+	}()
+}
@@ -0,0 +1,74 @@
+package raven
+
+import (
+	"os"
+	"strings"
+)
+
+// environmentAliases maps common spellings to the environment names Sentry
+// suggests
+var environmentAliases = map[string]string{
+	"prod":        "production",
+	"prd":         "production",
+	"production":  "production",
+	"stage":       "staging",
+	"stg":         "staging",
+	"staging":     "staging",
+	"dev":         "development",
+	"develop":     "development",
+	"development": "development",
+	"local":       "development",
+	"test":        "test",
+	"testing":     "test",
+	"qa":          "qa",
+}
+
+// Command line flags of development builds, see detectEnvironment
+var devModeFlags = []string{"-dev", "--dev", "-debug", "--debug"}
+
+// detectEnvironment infers the environment the process runs in from, in
+// order, the GO_ENV and APP_ENV environment variables, a k8s namespace
+// naming an environment, e.g. "payments-staging", and development flags on
+// the command line. It returns an empty string without any of them.
+func detectEnvironment() string {
+	for _, key := range []string{"GO_ENV", "APP_ENV"} {
+		if value := strings.ToLower(strings.TrimSpace(os.Getenv(key))); value != "" {
+			if alias, ok := environmentAliases[value]; ok {
+				return alias
+			}
+			return value
+		}
+	}
+	if k8s := detectKubernetes(); k8s != nil {
+		separators := func(r rune) bool { return r == '-' || r == '_' || r == '.' }
+		for _, part := range strings.FieldsFunc(strings.ToLower(k8s["namespace"]), separators) {
+			if alias, ok := environmentAliases[part]; ok {
+				return alias
+			}
+		}
+	}
+	for _, arg := range os.Args[1:] {
+		for _, flag := range devModeFlags {
+			if arg == flag || arg == flag+"=true" {
+				return "development"
+			}
+		}
+	}
+	return ""
+}
+
+// DetectEnvironment sets the environment of given client, unless it is set,
+// e.g. by SENTRY_ENVIRONMENT, to the one inferred from the GO_ENV and APP_ENV
+// environment variables, the k8s namespace or development flags such as
+// -dev on the command line, and returns it.
+func (client *Client) DetectEnvironment() string {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.environment == "" {
+		client.environment = detectEnvironment()
+	}
+	return client.environment
+}
+
+// DetectEnvironment infers the environment of the default *Client unless it is set
+func DetectEnvironment() string { return DefaultClient.DetectEnvironment() }
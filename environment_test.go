@@ -0,0 +1,41 @@
+package raven
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	for _, key := range []string{"GO_ENV", "APP_ENV", "KUBERNETES_SERVICE_HOST", "POD_NAMESPACE"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"app"}
+
+	if environment := detectEnvironment(); environment != "" {
+		t.Errorf("expected no environment, got %q", environment)
+	}
+
+	os.Args = []string{"app", "--dev"}
+	if environment := detectEnvironment(); environment != "development" {
+		t.Errorf("expected development with a dev flag, got %q", environment)
+	}
+
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	os.Setenv("POD_NAMESPACE", "payments-stg")
+	if environment := detectEnvironment(); environment != "staging" {
+		t.Errorf("expected staging from the namespace, got %q", environment)
+	}
+
+	os.Setenv("APP_ENV", "Prod")
+	if environment := detectEnvironment(); environment != "production" {
+		t.Errorf("expected production from APP_ENV, got %q", environment)
+	}
+
+	client := newBareClient(nil)
+	client.SetEnvironment("canary")
+	if environment := client.DetectEnvironment(); environment != "canary" {
+		t.Errorf("expected the environment set to be kept, got %q", environment)
+	}
+}
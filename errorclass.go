@@ -0,0 +1,116 @@
+package raven
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Kinds of errors tagged as "error.kind" by CaptureError
+const (
+	errorKindCanceled = "canceled"
+	errorKindTimeout  = "timeout"
+	errorKindNetwork  = "network"
+	errorKindSyscall  = "syscall"
+	errorKindHTTP     = "http"
+)
+
+// errorTraits classifies err or the first of its causes showing a common
+// trait, for triage and alert routing: "error.kind" is one of canceled,
+// timeout, network, syscall and http, and "error.transient" tells whether
+// retrying may succeed. Syscall errors add their "error.errno" and typed
+// errors with a status code their "error.status_code".
+func errorTraits(err error) map[string]string {
+	for err != nil {
+		if tags := errorTraitsOf(err); tags != nil {
+			return tags
+		}
+		err = nextError(err)
+	}
+	return nil
+}
+
+func errorTraitsOf(err error) map[string]string {
+	traits := func(kind string, transient bool) map[string]string {
+		return map[string]string{"error.kind": kind, "error.transient": strconv.FormatBool(transient)}
+	}
+
+	switch e := err.(type) {
+	case syscall.Errno:
+		tags := traits(errorKindSyscall, e.Temporary() || e.Timeout())
+		tags["error.errno"] = e.Error()
+		return tags
+	case *TransportError:
+		if e.StatusCode != 0 {
+			tags := traits(errorKindHTTP, transientStatus(e.StatusCode))
+			tags["error.status_code"] = strconv.Itoa(e.StatusCode)
+			return tags
+		}
+	case interface {
+		StatusCode() int
+	}:
+		tags := traits(errorKindHTTP, transientStatus(e.StatusCode()))
+		tags["error.status_code"] = strconv.Itoa(e.StatusCode())
+		return tags
+	}
+
+	if err == context.Canceled {
+		return traits(errorKindCanceled, true)
+	}
+	if err == context.DeadlineExceeded {
+		return traits(errorKindTimeout, true)
+	}
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return traits(errorKindTimeout, true)
+		}
+		if _, wrapper := err.(*url.Error); !wrapper {
+			return traits(errorKindNetwork, netErr.Temporary())
+		}
+	}
+	return nil
+}
+
+// transientStatus tells whether a request failing with status may succeed later
+func transientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// nextError returns the error wrapped by err, see Cause, also for the error
+// types of the standard library wrapping syscall and network errors
+func nextError(err error) error {
+	switch e := err.(type) {
+	case causer:
+		return e.Cause()
+	case *url.Error:
+		return e.Err
+	case *net.OpError:
+		return e.Err
+	case *os.PathError:
+		return e.Err
+	case *os.SyscallError:
+		return e.Err
+	}
+	return nil
+}
+
+// withErrorTraits returns tags with the traits of err added, tags taking precedence
+func withErrorTraits(tags map[string]string, err error) map[string]string {
+	traits := errorTraits(err)
+	if traits == nil {
+		return tags
+	}
+	for key, value := range tags {
+		traits[key] = value
+	}
+	return traits
+}
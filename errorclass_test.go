@@ -0,0 +1,60 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+type statusError struct{ status int }
+
+func (e statusError) Error() string   { return "unexpected status" }
+func (e statusError) StatusCode() int { return e.status }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorTraits(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	tests := []struct {
+		err      error
+		expected map[string]string
+	}{
+		{context.Canceled, map[string]string{"error.kind": "canceled", "error.transient": "true"}},
+		{WrapWithExtra(context.DeadlineExceeded, nil), map[string]string{"error.kind": "timeout", "error.transient": "true"}},
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: timeoutError{}}, map[string]string{"error.kind": "timeout", "error.transient": "true"}},
+		{refused, map[string]string{"error.kind": "network", "error.transient": "false"}},
+		{&os.PathError{Op: "open", Path: "/tmp/x", Err: syscall.EAGAIN}, map[string]string{"error.kind": "syscall", "error.transient": "true", "error.errno": syscall.EAGAIN.Error()}},
+		{statusError{503}, map[string]string{"error.kind": "http", "error.transient": "true", "error.status_code": "503"}},
+		{&TransportError{StatusCode: 400}, map[string]string{"error.kind": "http", "error.transient": "false", "error.status_code": "400"}},
+		{errors.New("plain"), nil},
+	}
+	for _, test := range tests {
+		if traits := errorTraits(test.err); !reflect.DeepEqual(traits, test.expected) {
+			t.Errorf("%v: incorrect traits: got %v, want %v", test.err, traits, test.expected)
+		}
+	}
+}
+
+func TestCaptureErrorTraits(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.CaptureErrorAndWait(context.Canceled, map[string]string{"error.kind": "shutdown"})
+
+	tags := map[string]string{}
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["error.kind"] != "shutdown" || tags["error.transient"] != "true" {
+		t.Errorf("expected traits without overriding tags, got %v", tags)
+	}
+}
@@ -1,9 +1,53 @@
 package raven
 
+import "reflect"
+
 type causer interface {
 	Cause() error
 }
 
+// wrapper is implemented by the errors of Go 1.13 wrapping another error
+type wrapper interface {
+	Unwrap() error
+}
+
+// unwrapError returns the error wrapped by err, through Cause like with
+// github.com/pkg/errors or Unwrap like with Go 1.13, nil if there is none
+func unwrapError(err error) error {
+	switch err := err.(type) {
+	case causer:
+		return err.Cause()
+	case wrapper:
+		return err.Unwrap()
+	}
+	return nil
+}
+
+// rootCause returns the innermost error wrapped by err, see unwrapError.
+// Unlike Cause, it also follows Go 1.13 wrapped errors.
+func rootCause(err error) error {
+	for {
+		next := unwrapError(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// isError tells whether target is err or one of the errors it wraps
+func isError(err, target error) bool {
+	if target == nil || !reflect.TypeOf(target).Comparable() {
+		return err == target
+	}
+	for ; err != nil; err = unwrapError(err) {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
 type errWrappedWithExtra struct {
 	err       error
 	extraInfo map[string]interface{}
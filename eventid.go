@@ -0,0 +1,47 @@
+package raven
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidEventID is returned for event ids which are not 32 hexadecimal characters
+var ErrInvalidEventID = errors.New("raven: event id must be 32 hexadecimal characters")
+
+// EventID identifies an event, or a check-in, in Sentry. It is returned by
+// Capture and the Capture* functions, and empty when nothing was captured.
+type EventID string
+
+// ParseEventID parses an event id, also in the dashed form of a uuid and in
+// uppercase, to its canonical form.
+func ParseEventID(s string) (EventID, error) {
+	id := EventID(strings.ToLower(strings.Replace(s, "-", "", -1)))
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate returns ErrInvalidEventID unless id is 32 lowercase hexadecimal characters
+func (id EventID) Validate() error {
+	if len(id) != 32 {
+		return ErrInvalidEventID
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ErrInvalidEventID
+		}
+	}
+	return nil
+}
+
+// String returns id as a string
+func (id EventID) String() string { return string(id) }
+
+// Short returns the first 8 characters of id, as shown by Sentry
+func (id EventID) Short() string {
+	if len(id) < 8 {
+		return string(id)
+	}
+	return string(id[:8])
+}
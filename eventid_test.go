@@ -0,0 +1,32 @@
+package raven
+
+import "testing"
+
+func TestParseEventID(t *testing.T) {
+	for _, s := range []string{"5f0c2a1e8b7d4c3a9e210a1b2c3d4e5f", "5F0C2A1E-8B7D-4C3A-9E21-0A1B2C3D4E5F"} {
+		id, err := ParseEventID(s)
+		if err != nil || id != "5f0c2a1e8b7d4c3a9e210a1b2c3d4e5f" {
+			t.Errorf("%q: incorrect event id %q, %v", s, id, err)
+		}
+	}
+	for _, s := range []string{"", "5f0c2a1e", "5f0c2a1e8b7d4c3a9e210a1b2c3d4e5g"} {
+		if _, err := ParseEventID(s); err != ErrInvalidEventID {
+			t.Errorf("%q: expected ErrInvalidEventID, got %v", s, err)
+		}
+	}
+}
+
+func TestEventIDShort(t *testing.T) {
+	id := EventID("5f0c2a1e8b7d4c3a9e210a1b2c3d4e5f")
+	if id.Short() != "5f0c2a1e" || id.String() != "5f0c2a1e8b7d4c3a9e210a1b2c3d4e5f" {
+		t.Errorf("incorrect forms %q and %q", id.Short(), id.String())
+	}
+}
+
+func TestCaptureEventID(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &packetTransport{}
+	if id := client.CaptureMessageAndWait("message", nil); id.Validate() != nil {
+		t.Errorf("expected a valid event id, got %q", id)
+	}
+}
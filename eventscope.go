@@ -0,0 +1,96 @@
+package raven
+
+import (
+	"sync"
+	"time"
+)
+
+// eventScope is the state shared by the scopes of a request and of a task
+// run, which keep the user, tags and breadcrumbs of their events apart from
+// the context of the client.
+type eventScope struct {
+	// Tags are added to the events of the scope
+	Tags map[string]string
+
+	// User is attached to the events of the scope
+	User *User
+
+	client *Client
+
+	mu      sync.Mutex
+	context clientContext
+}
+
+// SetUser sets the user of the events of the scope
+func (s *eventScope) SetUser(user *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.User = user
+}
+
+// SetTag adds a tag to the events of the scope
+func (s *eventScope) SetTag(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Tags == nil {
+		s.Tags = make(map[string]string)
+	}
+	s.Tags[key] = value
+}
+
+// AddBreadcrumb records breadcrumb for the events of the scope only,
+// scrubbed and limited like the breadcrumbs of the client.
+func (s *eventScope) AddBreadcrumb(breadcrumb *Breadcrumb) {
+	s.client.mu.RLock()
+	max, filter := s.client.maxBreadcrumbs, s.client.requestFilter
+	s.client.mu.RUnlock()
+	if time.Time(breadcrumb.Timestamp).IsZero() {
+		breadcrumb.Timestamp = Timestamp(time.Now())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.context.addBreadcrumb(scrubBreadcrumb(breadcrumb, filter), max)
+}
+
+// errorPacket builds the packet of err like Client.CaptureError with
+// interfaces, nil when err is nil or excluded
+func (s *eventScope) errorPacket(err error, interfaces []Interface) *Packet {
+	client := s.client
+	if err == nil || client.shouldExcludeErr(err.Error()) {
+		return nil
+	}
+	cause := rootCause(err)
+	exception := chainedExceptions(err, NewException(cause, GetOrNewStacktrace(cause, 2, 3, client.includePaths)), 3, client.includePaths)
+	packet := NewPacketWithExtra(err.Error(), extractExtra(err), append(interfaces, exception)...)
+	if level := client.errorSeverity(err); level != "" {
+		packet.Level = level
+	}
+	return packet
+}
+
+// snapshot returns a copy of the user, nil without one, and the breadcrumbs of the scope
+func (s *eventScope) snapshot() (*User, Interface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var user *User
+	if s.User != nil {
+		copied := *s.User
+		user = &copied
+	}
+	return user, s.context.breadcrumbsInterface()
+}
+
+// captureTags returns a copy of the tags of the scope with tags added
+func (s *eventScope) captureTags(tags map[string]string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merged := make(map[string]string, len(s.Tags)+len(tags))
+	for key, value := range s.Tags {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+	return merged
+}
@@ -21,6 +21,35 @@ func NewException(err error, stacktrace *Stacktrace) *Exception {
 	return ex
 }
 
+// chainedExceptions returns root, the exception of the cause of err, chained
+// with an exception of every error wrapping it which carries its own stack,
+// see GetOrNewStacktrace, each with its own stacktrace. The chain is followed
+// through Cause, like with github.com/pkg/errors, and Unwrap, like with Go
+// 1.13 errors. It returns root alone when no wrapper carries a stack.
+func chainedExceptions(err error, root *Exception, context int, appPackagePrefixes []string) Interface {
+	var wrappers []*Exception
+	for link := err; ; {
+		next := unwrapError(link)
+		if next == nil {
+			break
+		}
+		if _, ok := errorStack(link); ok {
+			wrappers = append(wrappers, NewException(link, GetOrNewStacktrace(link, 0, context, appPackagePrefixes)))
+		}
+		link = next
+	}
+	if len(wrappers) == 0 {
+		return root
+	}
+
+	// Sentry orders chained exceptions from the innermost cause to the outermost error
+	values := []*Exception{root}
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		values = append(values, wrappers[i])
+	}
+	return Exceptions{Values: values}
+}
+
 // Exception defines Sentry's spec compliant interface holding Exception information - https://docs.sentry.io/development/sdk-dev/interfaces/exception/
 type Exception struct {
 	// Required
@@ -51,3 +80,14 @@ type Exceptions struct {
 
 // Class provides name of implemented Sentry's interface
 func (es Exceptions) Class() string { return "exception" }
+
+// Culprit reads the top-most in app frame of the outermost exception having one,
+// chained exceptions are ordered from the innermost cause to the outermost error
+func (es Exceptions) Culprit() string {
+	for i := len(es.Values) - 1; i >= 0; i-- {
+		if culprit := es.Values[i].Culprit(); culprit != "" {
+			return culprit
+		}
+	}
+	return ""
+}
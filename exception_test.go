@@ -3,7 +3,10 @@ package raven
 import (
 	"encoding/json"
 	"errors"
+	"runtime"
 	"testing"
+
+	pkgErrors "github.com/pkg/errors"
 )
 
 var newExceptionTests = []struct {
@@ -37,3 +40,82 @@ func TestNewException_JSON(t *testing.T) {
 		t.Errorf("incorrect JSON: got %s, want %s", string(b), expected)
 	}
 }
+
+// stackError wraps an error with the stack where it was wrapped
+type stackError struct {
+	msg    string
+	cause  error
+	frames []runtime.Frame
+}
+
+func wrapWithStack(cause error, msg string) error {
+	pcs := make([]uintptr, 10)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	err := &stackError{msg: msg, cause: cause}
+	for {
+		frame, more := frames.Next()
+		err.frames = append(err.frames, frame)
+		if !more {
+			return err
+		}
+	}
+}
+
+func (e *stackError) Error() string               { return e.msg + ": " + e.cause.Error() }
+func (e *stackError) Cause() error                { return e.cause }
+func (e *stackError) StackTrace() []runtime.Frame { return e.frames }
+
+func TestChainedExceptions(t *testing.T) {
+	root := errors.New("connection reset")
+	err := wrapWithStack(WrapWithExtra(wrapWithStack(root, "query"), nil), "load user")
+	rootException := NewException(root, nil)
+
+	chained, ok := chainedExceptions(err, rootException, 0, nil).(Exceptions)
+	if !ok || len(chained.Values) != 3 {
+		t.Fatalf("expected the root and 2 wrappers, got %#v", chained)
+	}
+	if chained.Values[0] != rootException || chained.Values[1].Module != "query" || chained.Values[2].Value != "load user: query: connection reset" {
+		t.Errorf("incorrect chain order: %+v", chained.Values)
+	}
+	for _, wrapper := range chained.Values[1:] {
+		if wrapper.Stacktrace == nil || len(wrapper.Stacktrace.Frames) == 0 {
+			t.Errorf("expected the stack of %q", wrapper.Value)
+		}
+	}
+
+	if single := chainedExceptions(WrapWithExtra(root, nil), rootException, 0, nil); single != rootException {
+		t.Errorf("expected the root exception alone without stacks, got %#v", single)
+	}
+}
+
+func TestChainedExceptionsPkgErrors(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	root := pkgErrors.New("connection reset")
+	err := pkgErrors.WithStack(&wrappingError{"load user", pkgErrors.Wrap(root, "query")})
+	client.CaptureError(err, nil)
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	var chained Exceptions
+	for _, inter := range transport.packets[0].Interfaces {
+		if exceptions, ok := inter.(Exceptions); ok {
+			chained = exceptions
+		}
+	}
+	if len(chained.Values) != 3 {
+		t.Fatalf("expected the root and 2 wrappers with stacks, got %#v", chained)
+	}
+	if chained.Values[0].Type != "*errors.fundamental" || chained.Values[1].Module != "query" || chained.Values[2].Value != "load user: query: connection reset" {
+		t.Errorf("incorrect chain order: %+v %+v %+v", chained.Values[0], chained.Values[1], chained.Values[2])
+	}
+	for _, exception := range chained.Values {
+		frames := exception.Stacktrace.Frames
+		if len(frames) == 0 || frames[len(frames)-1].Function != "TestChainedExceptionsPkgErrors" {
+			t.Errorf("expected the stack of %q, got %+v", exception.Value, exception.Stacktrace)
+		}
+	}
+}
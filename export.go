@@ -0,0 +1,164 @@
+package raven
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultExportMaxSize is the size at which an ExportTransport rotates files
+const defaultExportMaxSize = 64 << 20
+
+// ExportTransport archives every packet to newline-delimited JSON files in a
+// directory, one packet per line, e.g. for air-gapped environments without
+// Sentry or to audit what is sent. Files are named after the time they were
+// created, so that they sort chronologically, and rotated once they reach
+// MaxSize.
+type ExportTransport struct {
+	Dir string
+
+	// MaxSize is the size in bytes at which a new file is started
+	MaxSize int64
+
+	// MaxFiles is how many files are kept, the oldest ones are removed on
+	// rotation; zero keeps all of them
+	MaxFiles int
+
+	// Transport, when set, also delivers the packets once exported
+	Transport Transport
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewExportTransport creates an ExportTransport writing to dir, creating the
+// directory if needed, with files of at most 64MB.
+func NewExportTransport(dir string) (*ExportTransport, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("raven: can't create export directory: %v", err)
+	}
+	return &ExportTransport{Dir: dir, MaxSize: defaultExportMaxSize}, nil
+}
+
+// Unwrap returns the transport delivering exported packets, nil if there is none
+func (t *ExportTransport) Unwrap() Transport { return t.Transport }
+
+// Send exports packet, url and authHeader are only passed on to Transport
+func (t *ExportTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx, which only applies to Transport
+func (t *ExportTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	packetJSON, err := packet.JSON()
+	if err != nil {
+		return fmt.Errorf("raven: error marshaling packet %+v to JSON: %v", packet, err)
+	}
+	if err := t.write(append(packetJSON, '\n')); err != nil {
+		return fmt.Errorf("raven: can't export packet %s: %v", packet.EventID, err)
+	}
+	if t.Transport != nil {
+		return AsTransportV2(t.Transport).SendWithContext(ctx, url, authHeader, packet)
+	}
+	return nil
+}
+
+// write appends line to the current file, rotating it first if it is full
+func (t *ExportTransport) write(line []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil || t.MaxSize > 0 && t.size > 0 && t.size+int64(len(line)) > t.MaxSize {
+		if err := t.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := t.file.Write(line)
+	t.size += int64(n)
+	return err
+}
+
+// rotate closes the current file, starts a new one and removes the oldest
+// files beyond MaxFiles, callers must hold mu
+func (t *ExportTransport) rotate() error {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+
+	name := "events-" + time.Now().UTC().Format("20060102T150405.000000000") + ".ndjson"
+	f, err := os.OpenFile(filepath.Join(t.Dir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.file, t.size = f, info.Size()
+
+	if t.MaxFiles > 0 {
+		files, err := t.Files()
+		if err != nil {
+			return err
+		}
+		for len(files) > t.MaxFiles {
+			os.Remove(files[0])
+			files = files[1:]
+		}
+	}
+	return nil
+}
+
+// Files lists the exported files, oldest first
+func (t *ExportTransport) Files() ([]string, error) {
+	infos, err := ioutil.ReadDir(t.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), "events-") && strings.HasSuffix(info.Name(), ".ndjson") {
+			files = append(files, filepath.Join(t.Dir, info.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Close closes the current file, the next packet starts a new one
+func (t *ExportTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
+
+// SetExport replaces the transport of given client with an ExportTransport
+// archiving packets to dir instead of sending them to Sentry.
+func (client *Client) SetExport(dir string) error {
+	transport, err := NewExportTransport(dir)
+	if err != nil {
+		return err
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Transport = transport
+	return nil
+}
+
+// SetExport replaces the transport of the default *Client with an ExportTransport archiving packets to dir
+func SetExport(dir string) error { return DefaultClient.SetExport(dir) }
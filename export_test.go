@@ -0,0 +1,65 @@
+package raven
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestExportTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport, err := NewExportTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := &packetTransport{}
+	transport.Transport = next
+	transport.MaxSize = 1
+	transport.MaxFiles = 2
+	defer transport.Close()
+
+	for _, message := range []string{"first", "second", "third"} {
+		packet := NewPacket(message)
+		packet.Init("1")
+		if err := transport.Send("", "", packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := transport.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected the 2 latest files to be kept, got %v", files)
+	}
+	var messages []string
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var packet Packet
+			if err := json.Unmarshal(scanner.Bytes(), &packet); err != nil {
+				t.Errorf("incorrect line %s: %v", scanner.Bytes(), err)
+			}
+			messages = append(messages, packet.Message)
+		}
+		f.Close()
+	}
+	if len(messages) != 2 || messages[0] != "second" || messages[1] != "third" {
+		t.Errorf("expected one packet per rotated file, got %v", messages)
+	}
+	if len(next.packets) != 3 {
+		t.Errorf("expected the packets to be passed on, got %d", len(next.packets))
+	}
+}
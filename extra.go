@@ -0,0 +1,52 @@
+package raven
+
+import "runtime"
+
+// ExtraCollector adds information to the extra of a packet. Collectors run
+// lazily on the worker right before the packet is sent, so expensive ones
+// don't slow down the capturing goroutine.
+type ExtraCollector func(extra Extra)
+
+// RuntimeExtraCollector adds the current GOMAXPROCS and number of goroutines,
+// it is the default collector of every client.
+func RuntimeExtraCollector(extra Extra) {
+	extra["runtime.GOMAXPROCS"] = runtime.GOMAXPROCS(0) // 0 just returns the current value
+	extra["runtime.NumGoroutine"] = runtime.NumGoroutine()
+}
+
+// MemStatsExtraCollector adds heap and GC statistics. It is not enabled by
+// default as runtime.ReadMemStats stops the world.
+func MemStatsExtraCollector(extra Extra) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	extra["runtime.HeapAlloc"] = stats.HeapAlloc
+	extra["runtime.HeapObjects"] = stats.HeapObjects
+	extra["runtime.NumGC"] = stats.NumGC
+	extra["runtime.PauseTotalNs"] = stats.PauseTotalNs
+}
+
+// SetExtraCollectors replaces the collectors run on every packet sent by
+// given client, calling it without collectors disables them.
+func (client *Client) SetExtraCollectors(collectors ...ExtraCollector) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.extraCollectors = collectors
+}
+
+// SetExtraCollectors replaces the collectors run on every packet sent by the default *Client
+func SetExtraCollectors(collectors ...ExtraCollector) {
+	DefaultClient.SetExtraCollectors(collectors...)
+}
+
+// collectExtra runs collectors on packets which were not serialized yet
+func collectExtra(packet *Packet, collectors []ExtraCollector) {
+	if packet.raw != nil || len(collectors) == 0 {
+		return
+	}
+	if packet.Extra == nil {
+		packet.Extra = Extra{}
+	}
+	for _, collect := range collectors {
+		collect(packet.Extra)
+	}
+}
@@ -0,0 +1,34 @@
+package raven
+
+import "testing"
+
+func TestExtraCollectors(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+
+	packet := NewPacket("foo")
+	if _, ok := packet.Extra["runtime.NumGoroutine"]; ok {
+		t.Error("collectors should not run when the packet is created")
+	}
+	_, ch := client.Capture(packet, nil)
+	<-ch
+	if _, ok := packet.Extra["runtime.NumGoroutine"]; !ok {
+		t.Errorf("default collector did not run: %+v", packet.Extra)
+	}
+
+	client.SetExtraCollectors(func(extra Extra) { extra["gauge"] = 42 })
+	packet = NewPacket("foo")
+	_, ch = client.Capture(packet, nil)
+	<-ch
+	if _, ok := packet.Extra["runtime.NumGoroutine"]; ok || packet.Extra["gauge"] != 42 {
+		t.Errorf("incorrect collected extra: %+v", packet.Extra)
+	}
+
+	client.SetExtraCollectors()
+	packet = &Packet{Message: "foo"}
+	_, ch = client.Capture(packet, nil)
+	<-ch
+	if packet.Extra != nil {
+		t.Errorf("disabled collectors should not add extra: %+v", packet.Extra)
+	}
+}
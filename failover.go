@@ -0,0 +1,122 @@
+package raven
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultFailoverThreshold applies to a FailoverTransport without a positive Threshold
+const defaultFailoverThreshold = 3
+
+// FailoverTransport delivers packets to the client's DSN and switches to a
+// fallback DSN (e.g. an on-prem Relay) after Threshold consecutive delivery
+// failures. Only retryable failures, i.e. network errors, rate limiting and
+// server errors, count, a rejected event doesn't mean the primary is down.
+// While failed over, the primary is retried every RetryInterval and
+// used again as soon as a delivery to it succeeds.
+type FailoverTransport struct {
+	// Transport performs the actual delivery to the primary and fallback
+	Transport Transport
+
+	// Threshold of consecutive primary failures before switching to the
+	// fallback, 3 when it isn't positive
+	Threshold int
+
+	// RetryInterval between attempts to deliver to the primary while failed over
+	RetryInterval time.Duration
+
+	fallbackURL        string
+	fallbackAuthHeader string
+
+	mu          sync.Mutex
+	failures    int
+	lastAttempt time.Time
+}
+
+// NewFailoverTransport creates a FailoverTransport delivering through
+// transport and falling back to fallbackDSN after 3 consecutive failures.
+func NewFailoverTransport(transport Transport, fallbackDSN string) (*FailoverTransport, error) {
+	dsn, err := ParseDsn(fallbackDSN)
+	if err != nil {
+		return nil, err
+	}
+	return &FailoverTransport{
+		Transport:          transport,
+		Threshold:          defaultFailoverThreshold,
+		RetryInterval:      time.Minute,
+		fallbackURL:        dsn.StoreAPIURL(),
+		fallbackAuthHeader: dsn.AuthHeader(),
+	}, nil
+}
+
+// FailedOver reports whether packets are currently delivered to the fallback
+func (t *FailoverTransport) FailedOver() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failures >= t.threshold()
+}
+
+func (t *FailoverTransport) threshold() int {
+	if t.Threshold <= 0 {
+		return defaultFailoverThreshold
+	}
+	return t.Threshold
+}
+
+// Unwrap returns the transport delivering to the primary and fallback
+func (t *FailoverTransport) Unwrap() Transport { return t.Transport }
+
+// Send delivers the packet to the primary url, or to the fallback once the
+// primary is considered down.
+func (t *FailoverTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx
+func (t *FailoverTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	transport := AsTransportV2(t.Transport)
+	t.mu.Lock()
+	tryPrimary := t.failures < t.threshold() || time.Since(t.lastAttempt) >= t.RetryInterval
+	if tryPrimary {
+		t.lastAttempt = time.Now()
+	}
+	t.mu.Unlock()
+
+	if !tryPrimary {
+		return transport.SendWithContext(ctx, t.fallbackURL, t.fallbackAuthHeader, packet)
+	}
+
+	err := transport.SendWithContext(ctx, url, authHeader, packet)
+
+	t.mu.Lock()
+	if err == nil {
+		t.failures = 0
+	} else if retryable(err) {
+		t.failures++
+	}
+	failedOver := t.failures >= t.threshold()
+	t.mu.Unlock()
+
+	if err != nil && retryable(err) && failedOver {
+		return transport.SendWithContext(ctx, t.fallbackURL, t.fallbackAuthHeader, packet)
+	}
+	return err
+}
+
+// SetFallbackDSN wraps the transport of given client in a FailoverTransport
+// switching to dsn when the primary DSN becomes unavailable.
+func (client *Client) SetFallbackDSN(dsn string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := NewFailoverTransport(client.Transport, dsn)
+	if err != nil {
+		return err
+	}
+	client.Transport = t
+	return nil
+}
+
+// SetFallbackDSN sets the fallback DSN of the default *Client
+func SetFallbackDSN(dsn string) error { return DefaultClient.SetFallbackDSN(dsn) }
@@ -0,0 +1,94 @@
+package raven
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	primary := "https://example.com/api/1/store/"
+	fallback := "https://relay.example.com/api/1/store/"
+	rt := &recordingTransport{fail: map[string]bool{primary: true}}
+
+	transport, err := NewFailoverTransport(rt, "https://u@relay.example.com/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.Threshold = 2
+	transport.RetryInterval = time.Hour
+
+	// first failure is returned, second one switches to the fallback
+	if err := transport.Send(primary, "", &Packet{}); err == nil {
+		t.Error("expected primary failure")
+	}
+	if err := transport.Send(primary, "", &Packet{}); err != nil {
+		t.Errorf("expected fallback delivery, got %v", err)
+	}
+	if !transport.FailedOver() {
+		t.Fatal("expected transport to fail over")
+	}
+
+	// while failed over, the primary is not retried before RetryInterval
+	rt.urls = nil
+	transport.Send(primary, "", &Packet{})
+	if len(rt.urls) != 1 || rt.urls[0] != fallback {
+		t.Errorf("expected delivery to fallback only, got %v", rt.urls)
+	}
+
+	// primary recovers and is used again after RetryInterval
+	rt.fail = nil
+	transport.RetryInterval = 0
+	rt.urls = nil
+	transport.Send(primary, "", &Packet{})
+	if len(rt.urls) != 1 || rt.urls[0] != primary {
+		t.Errorf("expected delivery to primary, got %v", rt.urls)
+	}
+	if transport.FailedOver() {
+		t.Error("expected transport to switch back to primary")
+	}
+}
+
+type statusTransport struct {
+	status int
+	sends  int
+}
+
+func (t *statusTransport) Send(url, authHeader string, packet *Packet) error {
+	t.sends++
+	return &TransportError{
+		StatusCode: t.status,
+		Retryable:  t.status == http.StatusTooManyRequests || t.status >= 500,
+		Err:        fmt.Errorf("raven: got http status %d", t.status),
+	}
+}
+
+func TestFailoverTransportRetryableFailures(t *testing.T) {
+	rt := &statusTransport{status: http.StatusBadRequest}
+	transport, err := NewFailoverTransport(rt, "https://u@relay.example.com/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.Threshold = 0
+
+	// rejected events don't mean the primary is down
+	for i := 0; i < defaultFailoverThreshold; i++ {
+		transport.Send("https://example.com/api/1/store/", "", &Packet{})
+	}
+	if transport.FailedOver() || rt.sends != defaultFailoverThreshold {
+		t.Errorf("expected no failover after client errors, got %d sends", rt.sends)
+	}
+
+	rt.status = http.StatusServiceUnavailable
+	for i := 0; i < defaultFailoverThreshold-1; i++ {
+		transport.Send("https://example.com/api/1/store/", "", &Packet{})
+	}
+	if transport.FailedOver() {
+		t.Fatal("expected the default threshold to apply")
+	}
+	transport.Send("https://example.com/api/1/store/", "", &Packet{})
+	if !transport.FailedOver() {
+		t.Error("expected failover after server errors")
+	}
+}
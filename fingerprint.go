@@ -0,0 +1,65 @@
+package raven
+
+import (
+	"reflect"
+	"regexp"
+)
+
+// FingerprintDefault stands for the default grouping of Sentry in a custom
+// fingerprint, e.g. to split the default group of an event by a tag value.
+const FingerprintDefault = "{{ default }}"
+
+// Variable parts of error messages replaced by FingerprintFromError
+var (
+	fingerprintUUIDPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	fingerprintHexPattern    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	fingerprintNumberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// FingerprintFromError returns a fingerprint grouping errors by the type and
+// message of their cause, see Cause, where uuids, hexadecimal and decimal
+// numbers are replaced by placeholders so that e.g. "user 42 not found" and
+// "user 43 not found" are grouped together. It returns nil for a nil err.
+func FingerprintFromError(err error) []string {
+	if err == nil {
+		return nil
+	}
+	cause := Cause(err)
+	message := fingerprintUUIDPattern.ReplaceAllString(cause.Error(), "<uuid>")
+	message = fingerprintHexPattern.ReplaceAllString(message, "<hex>")
+	message = fingerprintNumberPattern.ReplaceAllString(message, "<n>")
+	return []string{reflect.TypeOf(cause).String(), message}
+}
+
+// FingerprintFromStack returns a fingerprint grouping events by the functions
+// of the depth innermost in app frames of st, or of any frames when none is in
+// app. Unlike line numbers, functions stay stable across releases. A depth of
+// zero or less takes all frames.
+func FingerprintFromStack(st *Stacktrace, depth int) []string {
+	if st == nil {
+		return nil
+	}
+	var fingerprint []string
+	for _, inAppOnly := range []bool{true, false} {
+		for i := len(st.Frames) - 1; i >= 0; i-- {
+			frame := st.Frames[i]
+			if (inAppOnly && !frame.InApp) || frame.Function == "" {
+				continue
+			}
+			fingerprint = append(fingerprint, frame.Module+"."+frame.Function)
+			if len(fingerprint) == depth {
+				return fingerprint
+			}
+		}
+		if len(fingerprint) > 0 {
+			break
+		}
+	}
+	return fingerprint
+}
+
+// WithDefaultFingerprint returns a fingerprint made of the default grouping
+// of Sentry followed by parts, which splits the default groups by parts.
+func WithDefaultFingerprint(parts ...string) []string {
+	return append([]string{FingerprintDefault}, parts...)
+}
@@ -0,0 +1,45 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFingerprintFromError(t *testing.T) {
+	first := FingerprintFromError(WrapWithExtra(errors.New("user 42 not found in 5f0c2a1e-8b7d-4c3a-9e21-0a1b2c3d4e5f at 0xc000123"), nil))
+	second := FingerprintFromError(errors.New("user 7 not found in 0d9e8f7a-6b5c-4d3e-8f21-a0b1c2d3e4f5 at 0xc000456"))
+	expected := []string{"*errors.errorString", "user <n> not found in <uuid> at <hex>"}
+	if !reflect.DeepEqual(first, expected) || !reflect.DeepEqual(second, expected) {
+		t.Errorf("incorrect fingerprints: got %q and %q, want %q", first, second, expected)
+	}
+	if FingerprintFromError(nil) != nil {
+		t.Error("expected no fingerprint for a nil error")
+	}
+}
+
+func TestFingerprintFromStack(t *testing.T) {
+	st := &Stacktrace{Frames: []*StacktraceFrame{
+		{Module: "main", Function: "main", InApp: true},
+		{Module: "example.com/app", Function: "handle", InApp: true},
+		{Module: "example.com/app", Function: "load", InApp: true},
+		{Module: "database/sql", Function: "(*DB).Query"},
+	}}
+	if fingerprint := FingerprintFromStack(st, 2); !reflect.DeepEqual(fingerprint, []string{"example.com/app.load", "example.com/app.handle"}) {
+		t.Errorf("incorrect in app fingerprint: %q", fingerprint)
+	}
+	if fingerprint := FingerprintFromStack(st, 0); len(fingerprint) != 3 {
+		t.Errorf("expected all in app frames, got %q", fingerprint)
+	}
+
+	library := &Stacktrace{Frames: []*StacktraceFrame{{Module: "runtime", Function: "goexit"}}}
+	if fingerprint := FingerprintFromStack(library, 2); !reflect.DeepEqual(fingerprint, []string{"runtime.goexit"}) {
+		t.Errorf("expected frames of any package without in app frames, got %q", fingerprint)
+	}
+}
+
+func TestWithDefaultFingerprint(t *testing.T) {
+	if fingerprint := WithDefaultFingerprint("eu-west-1"); !reflect.DeepEqual(fingerprint, []string{"{{ default }}", "eu-west-1"}) {
+		t.Errorf("incorrect fingerprint: %q", fingerprint)
+	}
+}
@@ -0,0 +1,15 @@
+package raven
+
+// Go runs f in a new goroutine, capturing its panic like CapturePanic instead
+// of letting it crash the process without an event. The panic is recovered,
+// the goroutine ends once it was captured; Wait waits for the event.
+//
+//	client.Go(func() {
+//		processBatch(batch)
+//	})
+func (client *Client) Go(f func()) {
+	go client.CapturePanic(f, nil)
+}
+
+// Go runs f in a new goroutine capturing its panic with the default *Client
+func Go(f func()) { DefaultClient.Go(f) }
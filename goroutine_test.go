@@ -0,0 +1,32 @@
+package raven
+
+import (
+	"testing"
+	"time"
+)
+
+type channelTransport chan *Packet
+
+func (t channelTransport) Send(url, authHeader string, packet *Packet) error {
+	t <- packet
+	return nil
+}
+
+func TestGo(t *testing.T) {
+	client := newClient(nil)
+	transport := make(channelTransport, 1)
+	client.Transport = transport
+
+	client.Go(func() {
+		panic("background failure")
+	})
+
+	select {
+	case packet := <-transport:
+		if packet.Message != "background failure" {
+			t.Errorf("incorrect message %q", packet.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the panic to be captured")
+	}
+}
@@ -0,0 +1,55 @@
+package raven
+
+// Grouper decides how Sentry groups a packet into issues, e.g. by stripping
+// ids from its message or grouping it by error type, so that the grouping
+// policy lives in one place instead of every capture. It runs on the worker
+// right before the packet is sent, after the collectors, and returns the
+// fingerprint and culprit of the packet. A nil fingerprint or an empty
+// culprit keeps the ones of the packet.
+type Grouper func(packet *Packet) (fingerprint []string, culprit string)
+
+// GroupByErrorType is a Grouper grouping the exceptions of a packet by their
+// types, whatever their messages, e.g. for errors embedding request details.
+// Packets without exceptions keep the default grouping.
+func GroupByErrorType(packet *Packet) ([]string, string) {
+	var fingerprint []string
+	for _, inter := range packet.Interfaces {
+		switch e := inter.(type) {
+		case *Exception:
+			fingerprint = append(fingerprint, e.Type)
+		case *Exceptions:
+			for _, value := range e.Values {
+				fingerprint = append(fingerprint, value.Type)
+			}
+		case Exceptions:
+			for _, value := range e.Values {
+				fingerprint = append(fingerprint, value.Type)
+			}
+		}
+	}
+	return fingerprint, ""
+}
+
+// SetGrouper sets the Grouper run on every packet sent by given client, nil removes it
+func (client *Client) SetGrouper(grouper Grouper) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.grouper = grouper
+}
+
+// SetGrouper sets the Grouper run on every packet sent by the default *Client
+func SetGrouper(grouper Grouper) { DefaultClient.SetGrouper(grouper) }
+
+// group runs grouper on packets which were not serialized yet
+func group(packet *Packet, grouper Grouper) {
+	if packet.raw != nil || grouper == nil {
+		return
+	}
+	fingerprint, culprit := grouper(packet)
+	if fingerprint != nil {
+		packet.Fingerprint = fingerprint
+	}
+	if culprit != "" {
+		packet.Culprit = culprit
+	}
+}
@@ -0,0 +1,40 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetGrouper(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetGrouper(func(packet *Packet) ([]string, string) {
+		if strings.HasPrefix(packet.Message, "user ") {
+			return []string{"user lookup"}, "users.Find"
+		}
+		return nil, ""
+	})
+
+	client.CaptureMessageAndWait("user 42 not found", nil)
+	client.CaptureMessageAndWait("unrelated", nil)
+
+	if packet := transport.packets[0]; !reflect.DeepEqual(packet.Fingerprint, []string{"user lookup"}) || packet.Culprit != "users.Find" {
+		t.Errorf("expected the grouping of the hook, got %q and %q", packet.Fingerprint, packet.Culprit)
+	}
+	if packet := transport.packets[1]; packet.Fingerprint != nil || packet.Culprit != "" {
+		t.Errorf("expected the default grouping, got %q and %q", packet.Fingerprint, packet.Culprit)
+	}
+}
+
+func TestGroupByErrorType(t *testing.T) {
+	packet := NewPacket("request 42 failed", NewException(errors.New("request 42 failed"), nil))
+	if fingerprint, culprit := GroupByErrorType(packet); !reflect.DeepEqual(fingerprint, []string{"*errors.errorString"}) || culprit != "" {
+		t.Errorf("incorrect grouping: %q and %q", fingerprint, culprit)
+	}
+	if fingerprint, _ := GroupByErrorType(NewPacket("message")); fingerprint != nil {
+		t.Errorf("expected the default grouping without exceptions, got %q", fingerprint)
+	}
+}
@@ -0,0 +1,148 @@
+package raven
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidInterval is returned by StartHeartbeat and WatchConfig for an
+// interval which isn't positive
+var ErrInvalidInterval = errors.New("raven: interval must be positive")
+
+// CheckInStatus is the status of a check-in of a Sentry Crons monitor
+type CheckInStatus string
+
+// Statuses of check-ins
+const (
+	CheckInInProgress CheckInStatus = "in_progress"
+	CheckInOK         CheckInStatus = "ok"
+	CheckInError      CheckInStatus = "error"
+)
+
+// checkIn is the payload of a check_in envelope item - https://develop.sentry.dev/sdk/telemetry/check-ins/
+type checkIn struct {
+	CheckInID     EventID                `json:"check_in_id"`
+	MonitorSlug   string                 `json:"monitor_slug"`
+	Status        CheckInStatus          `json:"status"`
+	Duration      float64                `json:"duration,omitempty"`
+	Release       string                 `json:"release,omitempty"`
+	Environment   string                 `json:"environment,omitempty"`
+	MonitorConfig map[string]interface{} `json:"monitor_config,omitempty"`
+}
+
+// CaptureCheckIn sends a check-in of the Sentry Crons monitor slug and
+// returns its id. A job reports its start with CheckInInProgress and an empty
+// id, then its end with CheckInOK or CheckInError, the id of its start and
+// its duration. It returns ErrInvalidEventID if id is neither empty nor valid.
+func (client *Client) CaptureCheckIn(slug string, status CheckInStatus, id EventID, duration time.Duration) (EventID, error) {
+	if id == "" {
+		generated, err := uuid()
+		if err != nil {
+			return "", err
+		}
+		id = EventID(generated)
+	} else if err := id.Validate(); err != nil {
+		return "", err
+	}
+	client.sendCheckIn(slug, status, id, duration, nil)
+	return id, nil
+}
+
+// CaptureCheckIn sends a check-in of the monitor slug with the default *Client
+func CaptureCheckIn(slug string, status CheckInStatus, id EventID, duration time.Duration) (EventID, error) {
+	return DefaultClient.CaptureCheckIn(slug, status, id, duration)
+}
+
+// sendCheckIn sends a check-in of the monitor slug, upserting the monitor
+// with config when it is not nil
+func (client *Client) sendCheckIn(slug string, status CheckInStatus, id EventID, duration time.Duration, config map[string]interface{}) {
+	client.mu.RLock()
+	release, environment := client.release, client.environment
+	client.mu.RUnlock()
+
+	if id == "" {
+		generated, err := uuid()
+		if err != nil {
+			return
+		}
+		id = EventID(generated)
+	}
+	payload, err := json.Marshal(checkIn{
+		CheckInID:     id,
+		MonitorSlug:   slug,
+		Status:        status,
+		Duration:      duration.Seconds(),
+		Release:       release,
+		Environment:   environment,
+		MonitorConfig: config,
+	})
+	if err != nil {
+		client.Logger().Errorf("error marshaling check-in: %v", err)
+		return
+	}
+	client.sendEnvelope("check-in", "", nil, envelopeItem{Type: "check_in", Payload: payload})
+}
+
+// intervalSchedule returns the monitor config of a heartbeat every interval,
+// Sentry schedules intervals in whole minutes
+func intervalSchedule(interval time.Duration) map[string]interface{} {
+	minutes := int((interval + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return map[string]interface{}{
+		"schedule": map[string]interface{}{"type": "interval", "value": minutes, "unit": "minute"},
+	}
+}
+
+// StartHeartbeat calls check every interval in a new goroutine and reports
+// the liveness of a daemon to the Sentry Crons monitor slug, which is
+// created with an interval schedule if it does not exist: an ok check-in
+// when check returns nil, an error check-in and an event of the error
+// otherwise. A nil check always succeeds. The heartbeat runs until stop is
+// called. It returns ErrInvalidInterval if interval isn't positive.
+func (client *Client) StartHeartbeat(slug string, interval time.Duration, check func() error) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	done := make(chan struct{})
+	config := intervalSchedule(interval)
+
+	beat := func() {
+		start := time.Now()
+		var err error
+		if check != nil {
+			err = check()
+		}
+		if err != nil {
+			client.CaptureError(err, map[string]string{"monitor.slug": slug})
+			client.sendCheckIn(slug, CheckInError, "", time.Since(start), config)
+			return
+		}
+		client.sendCheckIn(slug, CheckInOK, "", time.Since(start), config)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		beat()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				beat()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// StartHeartbeat reports the liveness of a daemon to the monitor slug with the default *Client
+func StartHeartbeat(slug string, interval time.Duration, check func() error) (stop func(), err error) {
+	return DefaultClient.StartHeartbeat(slug, interval, check)
+}
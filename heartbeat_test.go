@@ -0,0 +1,120 @@
+package raven
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntervalSchedule(t *testing.T) {
+	for interval, minutes := range map[time.Duration]int{
+		10 * time.Second: 1,
+		time.Minute:      1,
+		90 * time.Second: 2,
+		time.Hour:        60,
+	} {
+		schedule := intervalSchedule(interval)["schedule"].(map[string]interface{})
+		if schedule["value"] != minutes {
+			t.Errorf("expected %d minutes for %s, got %v", minutes, interval, schedule["value"])
+		}
+	}
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		checkIns []checkIn
+		events   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/42/store/" {
+			events++
+			return
+		}
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		if len(lines) != 3 || !strings.Contains(lines[1], `"type":"check_in"`) {
+			t.Errorf("incorrect envelope %q", body)
+			return
+		}
+		var c checkIn
+		if err := json.Unmarshal([]byte(lines[2]), &c); err != nil {
+			t.Error(err)
+		}
+		checkIns = append(checkIns, c)
+	}))
+	defer server.Close()
+
+	client, err := New(strings.Replace(server.URL, "http://", "http://public:secret@", 1) + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.StartHeartbeat("worker", 0, nil); err != ErrInvalidInterval {
+		t.Errorf("expected ErrInvalidInterval, got %v", err)
+	}
+
+	var calls int
+	beats := make(chan struct{}, 10)
+	stop, err := client.StartHeartbeat("worker", 10*time.Millisecond, func() error {
+		calls++
+		defer func() { beats <- struct{}{} }()
+		if calls == 2 {
+			return errors.New("queue unreachable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-beats
+	<-beats
+	stop()
+	stop()
+	// a beat may have started before stop
+	time.Sleep(20 * time.Millisecond)
+	client.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(checkIns) < 2 || events != 1 {
+		t.Fatalf("expected check-ins and 1 event, got %d and %d", len(checkIns), events)
+	}
+	if checkIns[0].Status != CheckInOK && checkIns[1].Status != CheckInOK {
+		t.Errorf("expected an ok check-in, got %+v", checkIns)
+	}
+	var errored bool
+	for _, c := range checkIns {
+		if c.MonitorSlug != "worker" || c.MonitorConfig == nil {
+			t.Errorf("incorrect check-in %+v", c)
+		}
+		errored = errored || c.Status == CheckInError
+	}
+	if !errored {
+		t.Errorf("expected an error check-in, got %+v", checkIns)
+	}
+}
+
+func TestCaptureCheckIn(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &packetTransport{}
+
+	id, err := client.CaptureCheckIn("nightly", CheckInInProgress, "", 0)
+	if err != nil || id.Validate() != nil {
+		t.Fatalf("expected a new check-in id, got %q and %v", id, err)
+	}
+	if finished, err := client.CaptureCheckIn("nightly", CheckInOK, id, time.Minute); err != nil || finished != id {
+		t.Errorf("expected the check-in %q to be finished, got %q and %v", id, finished, err)
+	}
+	if _, err := client.CaptureCheckIn("nightly", CheckInOK, "not-an-id", time.Minute); err != ErrInvalidEventID {
+		t.Errorf("expected ErrInvalidEventID, got %v", err)
+	}
+}
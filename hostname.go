@@ -0,0 +1,43 @@
+package raven
+
+import (
+	"os"
+	"sync"
+)
+
+// hostname is the default server name of packets and the name of the device
+// context, read once at startup unless overridden or refreshed. It is read
+// in its declaration rather than in init, so that it is already set when
+// DefaultClient is created, e.g. to detect the Kubernetes pod.
+var (
+	hostnameMu  sync.RWMutex
+	hostname, _ = os.Hostname()
+)
+
+// Hostname returns the hostname sent by default as the server name of packets
+func Hostname() string {
+	hostnameMu.RLock()
+	defer hostnameMu.RUnlock()
+	return hostname
+}
+
+// SetHostname overrides the hostname of the process for all clients, e.g.
+// once a container got its final hostname. SetServerName overrides the
+// server name of a single client instead.
+func SetHostname(name string) {
+	hostnameMu.Lock()
+	defer hostnameMu.Unlock()
+	hostname = name
+}
+
+// RefreshHostname reads the hostname of the process again, e.g. after a
+// checkpoint was restored on another host, and returns it. It keeps the
+// current hostname on error.
+func RefreshHostname() (string, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return Hostname(), err
+	}
+	SetHostname(name)
+	return name, nil
+}
@@ -0,0 +1,25 @@
+package raven
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSetHostname(t *testing.T) {
+	defer RefreshHostname()
+
+	SetHostname("restored-host")
+	packet := NewPacket("message")
+	packet.Init("project")
+	if packet.ServerName != "restored-host" {
+		t.Errorf("incorrect server name %q", packet.ServerName)
+	}
+	if _, device := DeviceContextCollector(packet); device.(map[string]interface{})["name"] != "restored-host" {
+		t.Errorf("incorrect device name %v", device)
+	}
+
+	expected, _ := os.Hostname()
+	if name, err := RefreshHostname(); err != nil || name != expected || Hostname() != expected {
+		t.Errorf("expected the hostname %q, got %q, %v", expected, name, err)
+	}
+}
@@ -1,36 +1,23 @@
 package raven
 
 import (
-	"errors"
-	"fmt"
-	"net"
 	"net/http"
 	"net/url"
-	"runtime/debug"
 	"strings"
 )
 
-// NewHttp creates new HTTP object that follows Sentry's HTTP interface spec and will be attached to the Packet
+// NewHttp creates new HTTP object that follows Sentry's HTTP interface spec and will be attached to the Packet.
+// The request body is only included when it was recorded by Client.RecordRequestBody. Headers and cookies
+// are filtered according to the configuration of the default *Client, see Client.NewHttp.
 func NewHttp(req *http.Request) *Http {
-	proto := "http"
-	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
-		proto = "https"
-	}
-	h := &Http{
-		Method:  req.Method,
-		Cookies: req.Header.Get("Cookie"),
-		Query:   sanitizeQuery(req.URL.Query()).Encode(),
-		URL:     proto + "://" + req.Host + req.URL.Path,
-		Headers: make(map[string]string, len(req.Header)),
-	}
-	if addr, port, err := net.SplitHostPort(req.RemoteAddr); err == nil {
-		h.Env = map[string]string{"REMOTE_ADDR": addr, "REMOTE_PORT": port}
-	}
-	for k, v := range req.Header {
-		h.Headers[k] = strings.Join(v, ",")
-	}
-	h.Headers["Host"] = req.Host
-	return h
+	return DefaultClient.NewHttp(req)
+}
+
+// NewHttp creates a new Http interface from req, only including the headers,
+// cookies and query parameters allowed by given client, see SetHeaderDenylist,
+// SetCookieDenylist and SetQueryAllowlist.
+func (client *Client) NewHttp(req *http.Request) *Http {
+	return client.NewHttpFromRequestInfo(HTTPRequestInfo(req))
 }
 
 var querySecretFields = []string{"password", "passphrase", "passwd", "secret"}
@@ -65,6 +52,28 @@ type Http struct {
 // Class provides name of implemented Sentry's interface
 func (h *Http) Class() string { return "request" }
 
+// clone returns a copy of h not sharing its headers, env or form data
+func (h *Http) clone() *Http {
+	clone := *h
+	clone.Headers = cloneStrings(h.Headers)
+	clone.Env = cloneStrings(h.Env)
+	if data, ok := h.Data.(map[string]string); ok {
+		clone.Data = cloneStrings(data)
+	}
+	return &clone
+}
+
+func cloneStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
 // RecoveryHandler uses Recoverer to wrap the stdlib net/http Mux.
 // Example:
 //	http.HandleFunc("/", raven.RecoveryHandler(func(w http.ResponseWriter, r *http.Request) {
@@ -80,22 +89,5 @@ func RecoveryHandler(handler func(http.ResponseWriter, *http.Request)) func(http
 //  ...
 //	http.Handle("/", raven.Recoverer(mux))
 func Recoverer(handler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rval := recover(); rval != nil {
-				debug.PrintStack()
-				rvalStr := fmt.Sprint(rval)
-				var packet *Packet
-				if err, ok := rval.(error); ok {
-					packet = NewPacket(rvalStr, NewException(errors.New(rvalStr), GetOrNewStacktrace(err, 2, 3, nil)), NewHttp(r))
-				} else {
-					packet = NewPacket(rvalStr, NewException(errors.New(rvalStr), NewStacktrace(2, 3, nil)), NewHttp(r))
-				}
-				Capture(packet, nil)
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-		}()
-
-		handler.ServeHTTP(w, r)
-	})
+	return DefaultClient.WrapHandler(nil, nil, nil)(handler)
 }
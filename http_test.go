@@ -86,6 +86,15 @@ func NewCookiesRequest() Testcase {
 	return Testcase{req, h}
 }
 
+func NewAuthorizationRequest() Testcase {
+	req := newBaseRequest()
+	req.Header.Add("Authorization", "Bearer token")
+	req.Header.Add("X-Api-Key", "key")
+
+	h := newBaseHttp()
+	return Testcase{req, h}
+}
+
 var newHttpTests = []Testcase{
 	NewRequest(),
 	NewRequestIPV6(),
@@ -95,8 +104,12 @@ var newHttpTests = []Testcase{
 }
 
 func TestNewHttp(t *testing.T) {
+	// forward all headers, as before DefaultHeaderDenylist
+	client := newClient(nil)
+	client.SetHeaderDenylist()
+
 	for _, test := range newHttpTests {
-		actual := NewHttp(test.request)
+		actual := client.NewHttp(test.request)
 		if actual.Method != test.Method {
 			t.Errorf("incorrect Method: got %s, want %s", actual.Method, test.Method)
 		}
@@ -121,6 +134,49 @@ func TestNewHttp(t *testing.T) {
 	}
 }
 
+func TestNewHttpDefaultHeaderDenylist(t *testing.T) {
+	for _, test := range []Testcase{NewAuthorizationRequest(), NewCookiesRequest()} {
+		actual := NewHttp(test.request)
+		if actual.Cookies != "" || !reflect.DeepEqual(actual.Headers, newBaseHttp().Headers) {
+			t.Errorf("expected denied headers to be dropped, got cookies %q and headers %+v", actual.Cookies, actual.Headers)
+		}
+	}
+}
+
+func TestNewHttpFilters(t *testing.T) {
+	client := newClient(nil)
+	client.SetHeaderDenylist("Authorization")
+	client.SetCookieDenylist("session")
+
+	req := newBaseRequest()
+	req.Header.Add("Cookie", "session=secret; theme=dark")
+	req.Header.Add("Authorization", "Bearer token")
+	h := client.NewHttp(req)
+	if h.Cookies != "theme=dark" || h.Headers["Cookie"] != "theme=dark" || h.Headers["Authorization"] != "" {
+		t.Errorf("incorrect filtered cookies %q and headers %+v", h.Cookies, h.Headers)
+	}
+
+	client.SetHeaderAllowlist("foo", "cookie")
+	client.SetCookieAllowlist("session")
+	h = client.NewHttp(req)
+	expected := map[string]string{"Foo": "bar", "Cookie": "session=secret", "Host": "example.com"}
+	if !reflect.DeepEqual(h.Headers, expected) {
+		t.Errorf("incorrect allowed headers: got %+v, want %+v", h.Headers, expected)
+	}
+}
+
+func TestNewHttpQueryAllowlist(t *testing.T) {
+	client := newClient(nil)
+	client.SetQueryAllowlist("page", "sort")
+
+	req := newBaseRequest()
+	req.URL.RawQuery = "page=2&sort=name&token=abc&signature=def"
+	expected := "page=2&signature=%5BFiltered%5D&sort=name&token=%5BFiltered%5D"
+	if h := client.NewHttp(req); h.Query != expected {
+		t.Errorf("incorrect Query: got %s, want %s", h.Query, expected)
+	}
+}
+
 var sanitizeQueryTests = []struct {
 	input, output string
 }{
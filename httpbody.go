@@ -0,0 +1,169 @@
+package raven
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultRequestBodyContentTypes are the content types whose request bodies
+// are captured when SetRequestBodyCapture is called without content types.
+var DefaultRequestBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"text/plain",
+	"text/xml",
+}
+
+type requestBodyKey struct{}
+
+// requestBody is the captured prefix of a request body stored in the request context
+type requestBody struct {
+	contentType string
+	data        []byte
+	truncated   bool
+}
+
+// SetRequestBodyCapture makes the Recoverer middleware of given client record
+// up to maxSize bytes of request bodies of contentTypes, which NewHttp then
+// attaches to the Http interface with secret fields scrubbed. A maxSize of
+// zero, the default, disables capturing.
+func (client *Client) SetRequestBodyCapture(maxSize int, contentTypes ...string) {
+	if len(contentTypes) == 0 {
+		contentTypes = DefaultRequestBodyContentTypes
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestBodyMaxSize = maxSize
+	client.requestBodyContentTypes = contentTypes
+}
+
+// SetRequestBodyCapture configures request body capturing of the default *Client
+func SetRequestBodyCapture(maxSize int, contentTypes ...string) {
+	DefaultClient.SetRequestBodyCapture(maxSize, contentTypes...)
+}
+
+// RecordRequestBody reads ahead up to the configured size of the body of req,
+// when its content type is allowed, and returns a request whose body still
+// yields the complete payload to the handler.
+func (client *Client) RecordRequestBody(req *http.Request) *http.Request {
+	client.mu.RLock()
+	maxSize, contentTypes := client.requestBodyMaxSize, client.requestBodyContentTypes
+	client.mu.RUnlock()
+
+	if maxSize <= 0 || req.Body == nil {
+		return req
+	}
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if !containsString(contentTypes, contentType) {
+		return req
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(req.Body, int64(maxSize)+1))
+	body := &requestBody{contentType: contentType, data: data}
+	if len(data) > maxSize {
+		body.data, body.truncated = data[:maxSize], true
+	}
+	if err != nil {
+		body.truncated = true
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), requestBodyKey{}, body))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+	return req
+}
+
+// recordedBody returns the body recorded by RecordRequestBody, if any
+func recordedBody(req *http.Request) *requestBody {
+	body, _ := req.Context().Value(requestBodyKey{}).(*requestBody)
+	return body
+}
+
+// httpData returns a request body as Http data, with secret fields scrubbed
+func httpData(body *requestBody) interface{} {
+	if body == nil || len(body.data) == 0 {
+		return nil
+	}
+
+	if !body.truncated {
+		switch body.contentType {
+		case "application/x-www-form-urlencoded":
+			if form, err := url.ParseQuery(string(body.data)); err == nil {
+				data := make(map[string]string, len(form))
+				for key, values := range sanitizeQuery(form) {
+					data[key] = strings.Join(values, ",")
+				}
+				return data
+			}
+		case "application/json":
+			var value interface{}
+			if err := json.Unmarshal(body.data, &value); err == nil {
+				if scrubbed, err := json.Marshal(scrubJSON(value)); err == nil {
+					return string(scrubbed)
+				}
+			}
+		}
+	}
+
+	// Malformed, truncated or other payloads are attached as text, with the
+	// values of secret looking fields masked
+	data := scrubRaw(string(body.data))
+	if body.truncated {
+		data += "..."
+	}
+	return data
+}
+
+// scrubRaw masks the values of secret looking fields and XML elements in a
+// body which couldn't be parsed
+func scrubRaw(data string) string {
+	return secretElement.ReplaceAllString(scrubMessage(data), "$1********")
+}
+
+// scrubJSON masks the values of object keys containing a secret keyword
+func scrubJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSecretField(key) {
+				v[key] = "********"
+			} else {
+				v[key] = scrubJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = scrubJSON(item)
+		}
+	}
+	return value
+}
+
+func isSecretField(field string) bool {
+	field = strings.ToLower(field)
+	for _, keyword := range querySecretFields {
+		if strings.Contains(field, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package raven
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordRequestBody(t *testing.T) {
+	client := newClient(nil)
+	client.SetRequestBodyCapture(64)
+
+	testCases := []struct {
+		ContentType string
+		Body        string
+		Expected    interface{}
+	}{
+		{"application/json", `{"user":"foo","password":"bar","items":[{"secret":"baz"}]}`, `{"items":[{"secret":"********"}],"password":"********","user":"foo"}`},
+		{"application/json; charset=utf-8", `{"user":`, `{"user":`},
+		{"application/json", `{"secret": "foo",`, `{"secret": ********,`},
+		{"application/json", `{"password":"hunter2","padding":"` + strings.Repeat("a", 60) + `"}`, `{"password":********,"padding":"` + strings.Repeat("a", 31) + "..."},
+		{"text/xml", "<login><Password>hunter2</Password></login>", "<login><Password>********</Password></login>"},
+		{"text/plain", "user=foo&password=bar", "user=foo&password=********"},
+		{"application/x-www-form-urlencoded", "user=foo&passwd=bar", map[string]string{"user": "foo", "passwd": "********"}},
+		{"text/plain", strings.Repeat("a", 100), strings.Repeat("a", 64) + "..."},
+		{"application/octet-stream", "binary", nil},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(test.Body))
+		req.Header.Set("Content-Type", test.ContentType)
+		req = client.RecordRequestBody(req)
+
+		if h := NewHttp(req); !reflect.DeepEqual(h.Data, test.Expected) {
+			t.Errorf("Case [%d]: expected data %#v, got %#v", i, test.Expected, h.Data)
+		}
+		if body, _ := ioutil.ReadAll(req.Body); string(body) != test.Body {
+			t.Errorf("Case [%d]: handler should read the complete body, got %q", i, body)
+		}
+	}
+}
+
+func TestRecordRequestBodyDisabled(t *testing.T) {
+	client := newClient(nil)
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	if client.RecordRequestBody(req) != req {
+		t.Error("request bodies should not be recorded by default")
+	}
+}
+
+func TestRecovererRecordsRequestBody(t *testing.T) {
+	transport := &httpDataTransport{}
+	DefaultClient.mu.Lock()
+	previous := DefaultClient.Transport
+	DefaultClient.Transport = transport
+	DefaultClient.mu.Unlock()
+	defer func() {
+		DefaultClient.mu.Lock()
+		DefaultClient.Transport = previous
+		DefaultClient.mu.Unlock()
+	}()
+	DefaultClient.SetRequestBodyCapture(1024)
+	defer DefaultClient.SetRequestBodyCapture(0)
+
+	handler := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		panic("malformed payload")
+	}))
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	DefaultClient.Wait()
+
+	if transport.data != `{"id":1}` {
+		t.Errorf("expected request body in the Http interface, got %#v", transport.data)
+	}
+}
+
+// httpDataTransport records the data of the Http interface of the last packet
+type httpDataTransport struct {
+	data interface{}
+}
+
+func (t *httpDataTransport) Send(url, authHeader string, packet *Packet) error {
+	for _, iface := range packet.Interfaces {
+		if h, ok := iface.(*Http); ok {
+			t.data = h.Data
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package raven
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultHeaderDenylist are the request headers never forwarded in the Http
+// interface unless the denylist of a client is replaced.
+var DefaultHeaderDenylist = []string{"Authorization", "Cookie", "X-Api-Key"}
+
+// filteredValue replaces query parameters which are not allowlisted
+const filteredValue = "[Filtered]"
+
+// httpFilter selects the request headers, cookies and query parameters
+// forwarded in the Http interface. Allowlists, when set, take precedence
+// over denylists.
+type httpFilter struct {
+	headerAllow, headerDeny []string
+	cookieAllow, cookieDeny []string
+	queryAllow              []string
+}
+
+func (f httpFilter) header(name string) bool {
+	return allowed(name, f.headerAllow, f.headerDeny, true)
+}
+
+func (f httpFilter) cookie(name string) bool {
+	return allowed(name, f.cookieAllow, f.cookieDeny, false)
+}
+
+// cookies returns the Cookie header of req without the filtered cookies
+func (f httpFilter) cookies(req *http.Request) string {
+	var kept []string
+	for _, cookie := range req.Cookies() {
+		if f.cookie(cookie.Name) {
+			kept = append(kept, cookie.Name+"="+cookie.Value)
+		}
+	}
+	return strings.Join(kept, "; ")
+}
+
+// query replaces the values of query parameters which aren't allowlisted
+// with [Filtered], or masks secret looking ones without allowlist.
+func (f httpFilter) query(query url.Values) url.Values {
+	if len(f.queryAllow) == 0 {
+		return sanitizeQuery(query)
+	}
+	for field := range query {
+		if !allowed(field, f.queryAllow, nil, false) {
+			query[field] = []string{filteredValue}
+		}
+	}
+	return query
+}
+
+// allowed matches name against the allowlist if it is set, else the
+// denylist. Header names are matched case-insensitively, cookie names aren't.
+func allowed(name string, allow, deny []string, fold bool) bool {
+	match := func(list []string) bool {
+		for _, item := range list {
+			if item == name || fold && strings.EqualFold(item, name) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(allow) > 0 {
+		return match(allow)
+	}
+	return !match(deny)
+}
+
+func (client *Client) httpFilter() httpFilter {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.requestFilter
+}
+
+// SetHeaderAllowlist makes given client only forward the listed request
+// headers in the Http interface, an empty list restores the denylist.
+func (client *Client) SetHeaderAllowlist(headers ...string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestFilter.headerAllow = headers
+}
+
+// SetHeaderDenylist replaces the request headers given client never
+// forwards in the Http interface, DefaultHeaderDenylist by default.
+// Denying the Cookie header also drops all cookies.
+func (client *Client) SetHeaderDenylist(headers ...string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestFilter.headerDeny = headers
+}
+
+// SetCookieAllowlist makes given client only forward the listed cookies,
+// as long as the Cookie header isn't denied.
+func (client *Client) SetCookieAllowlist(cookies ...string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestFilter.cookieAllow = cookies
+}
+
+// SetCookieDenylist sets the cookies given client never forwards, e.g. session cookies
+func (client *Client) SetCookieDenylist(cookies ...string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestFilter.cookieDeny = cookies
+}
+
+// SetQueryAllowlist makes given client only forward the values of the listed
+// query parameters verbatim, others are replaced with [Filtered]. Tokens and
+// signatures often travel in urls. Without allowlist, the values of
+// parameters looking like passwords or secrets are masked.
+func (client *Client) SetQueryAllowlist(params ...string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.requestFilter.queryAllow = params
+}
+
+// SetQueryAllowlist sets the query parameters forwarded verbatim by the default *Client
+func SetQueryAllowlist(params ...string) { DefaultClient.SetQueryAllowlist(params...) }
+
+// SetHeaderAllowlist sets the request headers forwarded by the default *Client
+func SetHeaderAllowlist(headers ...string) { DefaultClient.SetHeaderAllowlist(headers...) }
+
+// SetHeaderDenylist sets the request headers never forwarded by the default *Client
+func SetHeaderDenylist(headers ...string) { DefaultClient.SetHeaderDenylist(headers...) }
+
+// SetCookieAllowlist sets the cookies forwarded by the default *Client
+func SetCookieAllowlist(cookies ...string) { DefaultClient.SetCookieAllowlist(cookies...) }
+
+// SetCookieDenylist sets the cookies never forwarded by the default *Client
+func SetCookieDenylist(cookies ...string) { DefaultClient.SetCookieDenylist(cookies...) }
@@ -0,0 +1,126 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxKafkaKeyTag is the length of a tag value accepted by Sentry
+const maxKafkaKeyTag = 200
+
+// KafkaMessage describes a message consumed from or produced to Kafka. The
+// fields map directly to sarama.ConsumerMessage, sarama.ProducerMessage and
+// kafka.Message of segmentio/kafka-go, so this package doesn't depend on
+// either client:
+//
+//	raven.HandleKafkaMessage(raven.KafkaMessage{
+//		Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset, Key: msg.Key,
+//	}, func() error {
+//		return process(msg)
+//	})
+type KafkaMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+}
+
+// Tags returns the topic, partition, offset and key of the message as tags.
+// Keys which aren't printable text are hex-encoded, and keys are truncated
+// to the 200 characters of a tag value.
+func (m KafkaMessage) Tags() map[string]string {
+	tags := map[string]string{
+		"kafka.topic":     m.Topic,
+		"kafka.partition": strconv.FormatInt(int64(m.Partition), 10),
+		"kafka.offset":    strconv.FormatInt(m.Offset, 10),
+	}
+	if len(m.Key) > 0 {
+		tags["kafka.key"] = kafkaKeyTag(m.Key)
+	}
+	return tags
+}
+
+// kafkaKeyTag returns key as a tag value, see KafkaMessage.Tags
+func kafkaKeyTag(key []byte) string {
+	printable := utf8.Valid(key) && bytes.IndexFunc(key, func(r rune) bool { return !unicode.IsPrint(r) }) < 0
+	if !printable {
+		if len(key) > maxKafkaKeyTag/2 {
+			key = key[:maxKafkaKeyTag/2]
+		}
+		return hex.EncodeToString(key)
+	}
+	if len(key) > maxKafkaKeyTag {
+		n := maxKafkaKeyTag
+		for !utf8.RuneStart(key[n]) {
+			n--
+		}
+		key = key[:n]
+	}
+	return string(key)
+}
+
+// KafkaBreadcrumb returns a breadcrumb of a message consumed or produced,
+// operation being "consume" or "produce", its level is error if err is set.
+func KafkaBreadcrumb(operation string, msg KafkaMessage, err error) *Breadcrumb {
+	level := INFO
+	data := map[string]interface{}{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+	}
+	// failed produces have no offset
+	if err != nil {
+		level = ERROR
+		data["error"] = err.Error()
+	} else {
+		data["offset"] = msg.Offset
+	}
+	return &Breadcrumb{
+		Type:     "default",
+		Category: "kafka." + operation,
+		Message:  msg.Topic,
+		Level:    level,
+		Data:     data,
+	}
+}
+
+// HandleKafkaMessage runs handler for a consumed message, capturing the
+// error it returns and recovering a panic, which is returned as error, so
+// that the consumer keeps running. Events are tagged with the message, and
+// a consume breadcrumb is recorded.
+func (client *Client) HandleKafkaMessage(msg KafkaMessage, handler func() error) error {
+	client.AddBreadcrumb(KafkaBreadcrumb("consume", msg, nil))
+	return client.captureHandler(msg.Tags(), handler)
+}
+
+// RecordKafkaProduce records a produce breadcrumb of msg, e.g. from the
+// Successes channel of a sarama.AsyncProducer or once WriteMessages of a
+// kafka.Writer returned, so that later events show what was sent.
+func (client *Client) RecordKafkaProduce(msg KafkaMessage) {
+	client.AddBreadcrumb(KafkaBreadcrumb("produce", msg, nil))
+}
+
+// CaptureKafkaProduceError records a failed produce breadcrumb and captures
+// an error producing msg, e.g. from the Errors channel of a
+// sarama.AsyncProducer, and returns the event id.
+func (client *Client) CaptureKafkaProduceError(msg KafkaMessage, err error) EventID {
+	client.AddBreadcrumb(KafkaBreadcrumb("produce", msg, err))
+	tags := msg.Tags()
+	delete(tags, "kafka.offset")
+	return client.CaptureError(err, tags)
+}
+
+// HandleKafkaMessage runs handler for a consumed message with the default *Client
+func HandleKafkaMessage(msg KafkaMessage, handler func() error) error {
+	return DefaultClient.HandleKafkaMessage(msg, handler)
+}
+
+// RecordKafkaProduce records a produce breadcrumb of msg with the default *Client
+func RecordKafkaProduce(msg KafkaMessage) { DefaultClient.RecordKafkaProduce(msg) }
+
+// CaptureKafkaProduceError captures an error producing msg with the default *Client
+func CaptureKafkaProduceError(msg KafkaMessage, err error) EventID {
+	return DefaultClient.CaptureKafkaProduceError(msg, err)
+}
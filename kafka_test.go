@@ -0,0 +1,79 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleKafkaMessage(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+	msg := KafkaMessage{Topic: "orders", Partition: 3, Offset: 42, Key: []byte("order-1")}
+
+	if err := client.HandleKafkaMessage(msg, func() error { return nil }); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	failure := errors.New("invalid order")
+	if err := client.HandleKafkaMessage(msg, func() error { return failure }); err != failure {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+	err := client.HandleKafkaMessage(msg, func() error { panic("nil order") })
+	if err == nil || !strings.Contains(err.Error(), "nil order") {
+		t.Errorf("expected the panic as error, got %v", err)
+	}
+	client.RecordKafkaProduce(msg)
+	client.CaptureKafkaProduceError(msg, errors.New("broker unavailable"))
+	client.Wait()
+
+	var categories []string
+	for _, crumb := range client.context.breadcrumbs {
+		categories = append(categories, crumb.Category)
+		if crumb.Data["topic"] != "orders" || crumb.Data["partition"] != int32(3) {
+			t.Errorf("incorrect breadcrumb data %+v", crumb.Data)
+		}
+	}
+	expected := []string{"kafka.consume", "kafka.consume", "kafka.consume", "kafka.produce", "kafka.produce"}
+	if !reflect.DeepEqual(categories, expected) {
+		t.Errorf("expected breadcrumbs %v, got %v", expected, categories)
+	}
+	if crumb := client.context.breadcrumbs[4]; crumb.Level != ERROR || crumb.Data["offset"] != nil {
+		t.Errorf("incorrect failed produce breadcrumb %+v", crumb)
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 3 {
+		t.Fatalf("expected 3 packets, got %d", len(transport.packets))
+	}
+	for i, packet := range transport.packets {
+		tags := map[string]string{}
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["kafka.topic"] != "orders" || tags["kafka.partition"] != "3" || tags["kafka.key"] != "order-1" {
+			t.Errorf("packet %d: incorrect tags %+v", i, packet.Tags)
+		}
+		if _, ok := tags["kafka.offset"]; ok != (i < 2) {
+			t.Errorf("packet %d: offset tags only apply to consumed messages: %+v", i, packet.Tags)
+		}
+	}
+}
+
+func TestKafkaMessageKeyTag(t *testing.T) {
+	for _, test := range []struct {
+		key      []byte
+		expected string
+	}{
+		{[]byte("order-1"), "order-1"},
+		{[]byte{0x00, 0xff, 0x10}, "00ff10"},
+		{[]byte(strings.Repeat("é", 150)), strings.Repeat("é", 100)},
+		{make([]byte, 150), strings.Repeat("00", 100)},
+	} {
+		if tag := (KafkaMessage{Key: test.key}).Tags()["kafka.key"]; tag != test.expected {
+			t.Errorf("key %q: expected tag %q, got %q", test.key, test.expected, tag)
+		}
+	}
+}
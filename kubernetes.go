@@ -0,0 +1,69 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kubernetesServiceAccountDir is where the namespace of the pod is mounted
+var kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// detectKubernetes returns the k8s context of the pod the process runs in,
+// or nil outside of a cluster. Kubernetes only exposes the pod metadata
+// through the Downward API, so the POD_NAME, POD_NAMESPACE, NODE_NAME and
+// CONTAINER_IMAGE environment variables are read when the deployment sets
+// them, falling back to the hostname and service account namespace.
+func detectKubernetes() map[string]string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+
+	k8s := map[string]string{
+		"pod":       os.Getenv("POD_NAME"),
+		"namespace": os.Getenv("POD_NAMESPACE"),
+		"node":      os.Getenv("NODE_NAME"),
+		"image":     os.Getenv("CONTAINER_IMAGE"),
+	}
+	if k8s["pod"] == "" {
+		k8s["pod"] = Hostname()
+	}
+	if k8s["namespace"] == "" {
+		namespace, _ := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "namespace"))
+		k8s["namespace"] = strings.TrimSpace(string(namespace))
+	}
+	for key, value := range k8s {
+		if value == "" {
+			delete(k8s, key)
+		}
+	}
+	return k8s
+}
+
+// setKubernetes tags packets of given client with the pod, namespace and
+// node of k8s, without overriding the tags of the client, and attaches the
+// k8s context.
+func (client *Client) setKubernetes(k8s map[string]string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	tags := make(map[string]string, len(client.Tags)+3)
+	for _, key := range []string{"pod", "namespace", "node"} {
+		if value, ok := k8s[key]; ok {
+			tags["k8s."+key] = value
+		}
+	}
+	for key, value := range client.Tags {
+		tags[key] = value
+	}
+	client.Tags = tags
+
+	context := make(map[string]interface{}, len(k8s))
+	for key, value := range k8s {
+		context[key] = value
+	}
+	client.contextCollectors = append(client.contextCollectors, func(packet *Packet) (string, interface{}) {
+		return "k8s", context
+	})
+}
@@ -0,0 +1,62 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectKubernetes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("payments\n"), 0644)
+	defer func(dir string) { kubernetesServiceAccountDir = dir }(kubernetesServiceAccountDir)
+	kubernetesServiceAccountDir = dir
+
+	if k8s := detectKubernetes(); os.Getenv("KUBERNETES_SERVICE_HOST") == "" && k8s != nil {
+		t.Errorf("expected no k8s metadata outside of a cluster, got %+v", k8s)
+	}
+
+	for key, value := range map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"POD_NAME":                "api-7d9f",
+		"NODE_NAME":               "node-1",
+	} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+	defer os.Setenv("POD_NAMESPACE", os.Getenv("POD_NAMESPACE"))
+	os.Unsetenv("POD_NAMESPACE")
+	defer os.Setenv("CONTAINER_IMAGE", os.Getenv("CONTAINER_IMAGE"))
+	os.Unsetenv("CONTAINER_IMAGE")
+
+	k8s := detectKubernetes()
+	expected := map[string]string{"pod": "api-7d9f", "namespace": "payments", "node": "node-1"}
+	if !reflect.DeepEqual(k8s, expected) {
+		t.Errorf("expected %+v, got %+v", expected, k8s)
+	}
+
+	client := newClient(map[string]string{"k8s.node": "override"})
+	client.Transport = &recordingTransport{}
+	client.SetContextCollectors()
+	client.setKubernetes(k8s)
+	packet := NewPacket("foo")
+	_, ch := client.Capture(packet, nil)
+	<-ch
+
+	tags := map[string]string{}
+	for _, tag := range packet.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["k8s.pod"] != "api-7d9f" || tags["k8s.namespace"] != "payments" || tags["k8s.node"] != "override" {
+		t.Errorf("incorrect k8s tags: %+v", packet.Tags)
+	}
+	if context, ok := packet.Contexts["k8s"].(map[string]interface{}); !ok || context["pod"] != "api-7d9f" {
+		t.Errorf("incorrect k8s context: %+v", packet.Contexts)
+	}
+}
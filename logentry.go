@@ -0,0 +1,99 @@
+package raven
+
+// LogEntry is a minimal structured logger bound to a client, for logging
+// straight to Sentry: Debug and Info record breadcrumbs, Warn and Error
+// capture events, both carrying the fields and error of the entry. Entries
+// are immutable, WithField and WithError return new ones.
+//
+//	raven.WithField("order", id).WithError(err).Error("checkout failed")
+type LogEntry struct {
+	client *Client
+	fields map[string]interface{}
+	err    error
+}
+
+// WithField returns an entry logging to given client with the field key
+func (client *Client) WithField(key string, value interface{}) *LogEntry {
+	return (&LogEntry{client: client}).WithField(key, value)
+}
+
+// WithError returns an entry logging err to given client
+func (client *Client) WithError(err error) *LogEntry {
+	return &LogEntry{client: client, err: err}
+}
+
+// WithField returns an entry logging to the default *Client with the field key
+func WithField(key string, value interface{}) *LogEntry { return DefaultClient.WithField(key, value) }
+
+// WithError returns an entry logging err to the default *Client
+func WithError(err error) *LogEntry { return DefaultClient.WithError(err) }
+
+// WithField returns a copy of the entry with the field key added
+func (e *LogEntry) WithField(key string, value interface{}) *LogEntry {
+	fields := make(map[string]interface{}, len(e.fields)+1)
+	for k, v := range e.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return &LogEntry{client: e.client, fields: fields, err: e.err}
+}
+
+// WithError returns a copy of the entry logging err
+func (e *LogEntry) WithError(err error) *LogEntry {
+	return &LogEntry{client: e.client, fields: e.fields, err: err}
+}
+
+// Debug records message as a debug breadcrumb
+func (e *LogEntry) Debug(message string) { e.breadcrumb(DEBUG, message) }
+
+// Info records message as an info breadcrumb
+func (e *LogEntry) Info(message string) { e.breadcrumb(INFO, message) }
+
+// Warn captures message as a warning event
+func (e *LogEntry) Warn(message string) EventID { return e.capture(WARNING, message) }
+
+// Error captures message as an error event, with the exception and
+// stacktrace of the error of the entry if it has one
+func (e *LogEntry) Error(message string) EventID { return e.capture(ERROR, message) }
+
+func (e *LogEntry) breadcrumb(level Severity, message string) {
+	data := make(map[string]interface{}, len(e.fields)+1)
+	for k, v := range e.fields {
+		data[k] = v
+	}
+	if e.err != nil {
+		data["error"] = e.err.Error()
+	}
+	e.client.AddBreadcrumb(DefaultBreadcrumb(level, "log", message, data))
+}
+
+func (e *LogEntry) capture(level Severity, message string) EventID {
+	client := e.client
+	if client == nil {
+		return ""
+	}
+	extra := Extra{}
+	if e.err != nil {
+		extra = extractExtra(e.err)
+	}
+	for k, v := range e.fields {
+		extra[k] = v
+	}
+
+	interfaces := client.contextInterfaces()
+
+	if e.err != nil {
+		message += ": " + e.err.Error()
+		cause := Cause(e.err)
+		interfaces = append(interfaces, NewException(cause, GetOrNewStacktrace(cause, 2, 3, client.includePaths)))
+	} else {
+		interfaces = append(interfaces, &Message{message, nil})
+	}
+	if client.shouldExcludeErr(message) {
+		return ""
+	}
+	packet := NewPacketWithExtra(message, extra, interfaces...)
+	packet.Level = level
+	eventID, _ := client.Capture(packet, nil)
+	return eventID
+}
@@ -0,0 +1,53 @@
+package raven
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLogEntry(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+
+	entry := client.WithField("order", 42)
+	entry.WithField("step", "payment").Info("charging card")
+	entry.Debug("debugging")
+	if eventID := entry.WithError(errors.New("card declined")).Error("checkout failed"); eventID == "" {
+		t.Fatal("expected an event")
+	}
+	entry.Warn("slow checkout")
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.packets))
+	}
+	failed := transport.packets[0]
+	if failed.Message != "checkout failed: card declined" || failed.Level != ERROR || failed.Extra["order"] != 42 {
+		t.Errorf("incorrect error event %+v", failed)
+	}
+	var breadcrumbs *Breadcrumbs
+	var exception *Exception
+	for _, inter := range failed.Interfaces {
+		switch inter := inter.(type) {
+		case *Breadcrumbs:
+			breadcrumbs = inter
+		case *Exception:
+			exception = inter
+		}
+	}
+	if exception == nil || exception.Value != "card declined" || exception.Stacktrace == nil {
+		t.Errorf("expected the exception of the error, got %+v", exception)
+	}
+	if breadcrumbs == nil || len(breadcrumbs.Values) != 2 || breadcrumbs.Values[0].Data["step"] != "payment" || breadcrumbs.Values[1].Level != DEBUG {
+		t.Errorf("expected the info and debug breadcrumbs, got %+v", breadcrumbs)
+	}
+	if _, ok := entry.fields["step"]; ok {
+		t.Error("expected WithField to copy the entry")
+	}
+
+	if slow := transport.packets[1]; slow.Level != WARNING || !strings.HasPrefix(slow.Message, "slow") {
+		t.Errorf("incorrect warning event %+v", slow)
+	}
+}
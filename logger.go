@@ -0,0 +1,89 @@
+package raven
+
+import (
+	"io"
+	"log"
+	"regexp"
+)
+
+// Logger receives the internal diagnostics of a Client and its transport.
+// Implement it to route SDK messages into the application's own logging.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger adapts a stdlib *log.Logger to the Logger interface
+type StdLogger struct {
+	*log.Logger
+}
+
+// NewStdLogger creates a StdLogger writing to w with the "raven: " prefix
+func NewStdLogger(w io.Writer) *StdLogger {
+	return &StdLogger{log.New(w, "raven: ", 0)}
+}
+
+// Debugf logs a diagnostic message
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	l.Printf(format, args...)
+}
+
+// Errorf logs an error message
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.Printf("error: "+format, args...)
+}
+
+// noopLogger discards everything, it is used until debugging is enabled
+type noopLogger struct{}
+
+func (noopLogger) Debugf(format string, args ...interface{}) {}
+func (noopLogger) Errorf(format string, args ...interface{}) {}
+
+// DebugLevel is the verbosity of the diagnostics enabled with SetDebugWriter
+type DebugLevel int
+
+// Debug levels, each including the messages of the previous ones
+const (
+	// DebugErrors only logs errors, e.g. failed deliveries
+	DebugErrors DebugLevel = iota
+	// DebugVerbose also logs debug messages
+	DebugVerbose
+	// DebugTrace also dumps every request to Sentry and its response,
+	// including serialized payloads
+	DebugTrace
+)
+
+// errorLogger is a StdLogger skipping debug messages, see DebugErrors
+type errorLogger struct {
+	*StdLogger
+}
+
+func (errorLogger) Debugf(format string, args ...interface{}) {}
+
+// SetDebugWriter enables printing of internal diagnostics up to level to w,
+// replacing any Logger previously configured with SetLogger. DebugTrace
+// dumps the requests of the default HTTPTransport, with the secret of the
+// DSN redacted, to diagnose problems with self-hosted Sentry.
+func (client *Client) SetDebugWriter(w io.Writer, level DebugLevel) {
+	if level >= DebugVerbose {
+		client.SetLogger(NewStdLogger(w))
+	} else {
+		client.SetLogger(errorLogger{NewStdLogger(w)})
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if t := findHTTPTransport(client.Transport); t != nil {
+		t.update(func() { t.DumpRequests = level >= DebugTrace })
+	}
+}
+
+// SetDebugWriter enables printing of internal diagnostics of the default *Client to w
+func SetDebugWriter(w io.Writer, level DebugLevel) { DefaultClient.SetDebugWriter(w, level) }
+
+var secretPattern = regexp.MustCompile(`sentry_secret=[^,\s]+`)
+
+// redactSecret removes the secret of a DSN from a dumped request
+func redactSecret(dump []byte) []byte {
+	return secretPattern.ReplaceAll(dump, []byte("sentry_secret="+filteredValue))
+}
@@ -0,0 +1,95 @@
+package raven
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testLogger struct {
+	debug []string
+	error []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Errorf(format string, args ...interface{}) {
+	l.error = append(l.error, fmt.Sprintf(format, args...))
+}
+
+func TestSetLoggerPerClient(t *testing.T) {
+	a, b := newClient(nil), newClient(nil)
+	logger := &testLogger{}
+	a.SetLogger(logger)
+
+	if a.Logger() != logger {
+		t.Error("incorrect logger on first client")
+	}
+	if _, ok := b.Logger().(noopLogger); !ok {
+		t.Errorf("second client should keep discarding diagnostics, got %T", b.Logger())
+	}
+	if tr := a.Transport.(*HTTPTransport); tr.Logger != logger {
+		t.Error("logger was not propagated to the HTTPTransport")
+	}
+
+	a.SetLogger(nil)
+	if _, ok := a.Logger().(noopLogger); !ok {
+		t.Errorf("nil logger should discard diagnostics, got %T", a.Logger())
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewStdLogger(buf)
+	logger.Debugf("hello %s", "world")
+	logger.Errorf("failed %d", 1)
+
+	expected := "raven: hello world\nraven: error: failed 1\n"
+	if buf.String() != expected {
+		t.Errorf("incorrect output: got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestSetDebug(t *testing.T) {
+	client := newClient(nil)
+	client.SetDebug(true)
+	if _, ok := client.Logger().(*StdLogger); !ok {
+		t.Errorf("expected *StdLogger, got %T", client.Logger())
+	}
+	client.SetDebug(false)
+	if _, ok := client.Logger().(noopLogger); !ok {
+		t.Errorf("expected noopLogger, got %T", client.Logger())
+	}
+}
+
+func TestSetDebugWriter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, _ := New(strings.Replace(server.URL, "http://", "http://public:secret@", 1) + "/42")
+	buf := &bytes.Buffer{}
+	client.SetDebugWriter(buf, DebugErrors)
+	client.Logger().Debugf("hidden")
+	client.Logger().Errorf("shown")
+	if buf.String() != "raven: error: shown\n" {
+		t.Errorf("expected only errors, got %q", buf.String())
+	}
+
+	buf.Reset()
+	client.SetDebugWriter(buf, DebugTrace)
+	client.CaptureMessageAndWait("dumped", nil)
+	output := buf.String()
+	if !strings.Contains(output, "POST /api/42/store/") || !strings.Contains(output, "400 Bad Request") {
+		t.Errorf("expected the request and response to be dumped, got %q", output)
+	}
+	if strings.Contains(output, "sentry_secret=secret") || !strings.Contains(output, "sentry_secret=[Filtered]") {
+		t.Errorf("expected the secret to be redacted, got %q", output)
+	}
+}
@@ -0,0 +1,198 @@
+package raven
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults of the batching and rate limiting of structured logs
+const (
+	defaultLogBatchSize   = 100
+	defaultLogBatchWindow = 5 * time.Second
+	defaultLogRateLimit   = 1000
+)
+
+// logItem is a single entry of a log envelope item - https://develop.sentry.dev/sdk/telemetry/logs/
+type logItem struct {
+	Timestamp  float64                 `json:"timestamp"`
+	TraceID    string                  `json:"trace_id,omitempty"`
+	Level      string                  `json:"level"`
+	Body       string                  `json:"body"`
+	Attributes map[string]logAttribute `json:"attributes,omitempty"`
+}
+
+type logAttribute struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// newLogAttribute types a value as string, boolean, integer or double
+func newLogAttribute(value interface{}) logAttribute {
+	switch v := value.(type) {
+	case string:
+		return logAttribute{v, "string"}
+	case bool:
+		return logAttribute{v, "boolean"}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return logAttribute{v, "integer"}
+	case float32, float64:
+		return logAttribute{v, "double"}
+	}
+	return logAttribute{fmt.Sprint(value), "string"}
+}
+
+// logLevel maps a Severity to the levels of Sentry logs
+func logLevel(level Severity) string {
+	if level == WARNING {
+		return "warn"
+	}
+	if level == "" {
+		return string(INFO)
+	}
+	return string(level)
+}
+
+// logBuffer batches structured logs independently from the event queue and
+// limits how many are accepted per second.
+type logBuffer struct {
+	mu    sync.Mutex
+	items []logItem
+	timer *time.Timer
+
+	window      time.Time
+	windowCount int
+	dropped     int
+}
+
+// CaptureLog records a structured log for Sentry Logs. Logs are sent in
+// batches of up to 100 logs, at least every 5 seconds and by Wait, as log
+// envelope items, which requires the transport to be an EnvelopeTransport.
+// At most 1000 logs per second are accepted, see SetLogRateLimit, the rest
+// is dropped so that a log storm can't flood Sentry.
+func (client *Client) CaptureLog(level Severity, message string, attrs map[string]interface{}) {
+	client.captureLog(nil, level, message, attrs)
+}
+
+// CaptureLogContext is CaptureLog correlating the log with the trace of the
+// request or task run ctx is the context of, see WrapHandler and WrapTask.
+func (client *Client) CaptureLogContext(ctx context.Context, level Severity, message string, attrs map[string]interface{}) {
+	trace, _ := ctx.Value(traceKey{}).(*requestTrace)
+	if scope := TaskScopeFromContext(ctx); scope != nil && scope.trace != nil {
+		trace = scope.trace
+	}
+	client.captureLog(trace, level, message, attrs)
+}
+
+// CaptureLogContext records a structured log of the trace of ctx with the default *Client
+func CaptureLogContext(ctx context.Context, level Severity, message string, attrs map[string]interface{}) {
+	DefaultClient.CaptureLogContext(ctx, level, message, attrs)
+}
+
+// captureLog records a log of trace, which may be nil
+func (client *Client) captureLog(trace *requestTrace, level Severity, message string, attrs map[string]interface{}) {
+	client.mu.RLock()
+	release, environment, limit := client.release, client.environment, client.logRateLimit
+	client.mu.RUnlock()
+
+	item := logItem{
+		Timestamp:  float64(time.Now().UnixNano()) / 1e9,
+		Level:      logLevel(level),
+		Body:       message,
+		Attributes: make(map[string]logAttribute, len(attrs)+4),
+	}
+	for key, value := range attrs {
+		item.Attributes[key] = newLogAttribute(value)
+	}
+	item.Attributes["sentry.sdk.name"] = newLogAttribute("raven-go")
+	if release != "" {
+		item.Attributes["sentry.release"] = newLogAttribute(release)
+	}
+	if environment != "" {
+		item.Attributes["sentry.environment"] = newLogAttribute(environment)
+	}
+	if trace != nil {
+		item.TraceID = trace.traceID
+		item.Attributes["sentry.trace.parent_span_id"] = newLogAttribute(trace.spanID)
+	}
+
+	logs := &client.logs
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(logs.window) >= time.Second {
+		if logs.dropped > 0 {
+			client.Logger().Debugf("dropped %d logs over the rate limit", logs.dropped)
+		}
+		logs.window, logs.windowCount, logs.dropped = now, 0, 0
+	}
+	if limit > 0 && logs.windowCount >= limit {
+		logs.dropped++
+		return
+	}
+	logs.windowCount++
+
+	logs.items = append(logs.items, item)
+	if len(logs.items) >= defaultLogBatchSize {
+		client.sendLogs(logs.takeLocked())
+	} else if logs.timer == nil {
+		logs.timer = time.AfterFunc(defaultLogBatchWindow, client.flushLogs)
+	}
+}
+
+// CaptureLog records a structured log with the default *Client
+func CaptureLog(level Severity, message string, attrs map[string]interface{}) {
+	DefaultClient.CaptureLog(level, message, attrs)
+}
+
+// SetLogRateLimit sets how many logs per second given client accepts, zero disables the limit
+func (client *Client) SetLogRateLimit(limit int) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.logRateLimit = limit
+}
+
+// SetLogRateLimit sets how many logs per second the default *Client accepts
+func SetLogRateLimit(limit int) { DefaultClient.SetLogRateLimit(limit) }
+
+// takeLocked returns the buffered logs and resets the buffer, callers must hold mu
+func (b *logBuffer) takeLocked() []logItem {
+	items := b.items
+	b.items = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return items
+}
+
+// flushLogs sends the buffered logs
+func (client *Client) flushLogs() {
+	client.logs.mu.Lock()
+	items := client.logs.takeLocked()
+	client.logs.mu.Unlock()
+	client.sendLogs(items)
+}
+
+// sendLogs sends items in the background, tracked by Wait
+func (client *Client) sendLogs(items []logItem) {
+	if len(items) == 0 {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{"items": items})
+	if err != nil {
+		client.Logger().Errorf("error marshaling logs: %v", err)
+		return
+	}
+	client.sendEnvelope("logs", "", nil, envelopeItem{
+		Type:    "log",
+		Payload: payload,
+		Headers: map[string]interface{}{
+			"item_count":   len(items),
+			"content_type": "application/vnd.sentry.items.log+json",
+		},
+	})
+}
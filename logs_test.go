@@ -0,0 +1,125 @@
+package raven
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCaptureLog(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client, err := New(strings.Replace(server.URL, "http://", "http://public:secret@", 1) + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetRelease("1.0.0")
+	client.SetLogRateLimit(2)
+
+	client.CaptureLog(WARNING, "disk almost full", map[string]interface{}{"free": 12, "path": "/", "ratio": 0.9, "critical": false})
+	client.CaptureLog(INFO, "second", nil)
+	client.CaptureLog(INFO, "over the rate limit", nil)
+	client.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("expected 1 envelope, got %d", len(bodies))
+	}
+	lines := strings.Split(strings.TrimSpace(bodies[0]), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("incorrect envelope %q", bodies[0])
+	}
+
+	var header struct {
+		Type        string `json:"type"`
+		ItemCount   int    `json:"item_count"`
+		ContentType string `json:"content_type"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Type != "log" || header.ItemCount != 2 || header.ContentType != "application/vnd.sentry.items.log+json" {
+		t.Errorf("incorrect item header %s", lines[1])
+	}
+
+	var payload struct {
+		Items []struct {
+			Level      string                  `json:"level"`
+			Body       string                  `json:"body"`
+			Attributes map[string]logAttribute `json:"attributes"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Items) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(payload.Items))
+	}
+	log := payload.Items[0]
+	if log.Level != "warn" || log.Body != "disk almost full" {
+		t.Errorf("incorrect log %+v", log)
+	}
+	expected := map[string]string{
+		"free":           "integer",
+		"path":           "string",
+		"ratio":          "double",
+		"critical":       "boolean",
+		"sentry.release": "string",
+	}
+	for key, typ := range expected {
+		if log.Attributes[key].Type != typ {
+			t.Errorf("expected attribute %s of type %s, got %+v", key, typ, log.Attributes[key])
+		}
+	}
+	if log.Attributes["sentry.release"].Value != "1.0.0" {
+		t.Errorf("incorrect release attribute %+v", log.Attributes["sentry.release"])
+	}
+}
+
+func TestCaptureLogContext(t *testing.T) {
+	client := newClient(nil)
+	var traces []map[string]interface{}
+	handler := client.WrapHandler(nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traces = append(traces, traceContext(r))
+		client.CaptureLogContext(r.Context(), INFO, "handled", nil)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	client.WrapTask("cleanup", func(ctx context.Context) error {
+		scope := TaskScopeFromContext(ctx)
+		traces = append(traces, map[string]interface{}{"trace_id": scope.trace.traceID, "span_id": scope.trace.spanID})
+		client.CaptureLogContext(ctx, INFO, "cleaned up", nil)
+		return nil
+	})(context.Background())
+	client.CaptureLogContext(context.Background(), INFO, "untraced", nil)
+
+	client.logs.mu.Lock()
+	items := client.logs.takeLocked()
+	client.logs.mu.Unlock()
+	if len(items) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(items))
+	}
+	for i, trace := range traces {
+		if items[i].TraceID == "" || items[i].TraceID != trace["trace_id"] || items[i].Attributes["sentry.trace.parent_span_id"].Value != trace["span_id"] {
+			t.Errorf("expected log %q of trace %v, got %+v", items[i].Body, trace, items[i])
+		}
+	}
+	if items[2].TraceID != "" {
+		t.Errorf("expected no trace outside of a request, got %q", items[2].TraceID)
+	}
+}
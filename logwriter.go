@@ -0,0 +1,62 @@
+package raven
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// DefaultErrorPattern matches log lines which look like errors
+var DefaultErrorPattern = regexp.MustCompile(`(?i)\b(error|err|fail(ed|ure)?|panic|fatal|refused|timeout)\b|\btls: `)
+
+// ErrorLogWriter is an io.Writer for log.New, e.g. the ErrorLog of an
+// http.Server, capturing the lines which look like errors, like TLS
+// handshake failures, as events. All lines are passed through to Output.
+//
+//	server := &http.Server{
+//		ErrorLog: log.New(raven.NewErrorLogWriter(os.Stderr), "", log.LstdFlags),
+//	}
+type ErrorLogWriter struct {
+	Client *Client
+
+	// Output receives every line written, it may be nil
+	Output io.Writer
+
+	// Pattern selects the lines captured, DefaultErrorPattern by default
+	Pattern *regexp.Regexp
+
+	Level  Severity
+	Logger string // Logger name reported to Sentry
+}
+
+// NewErrorLogWriter creates an ErrorLogWriter of the default *Client passing lines to output
+func NewErrorLogWriter(output io.Writer) *ErrorLogWriter {
+	return &ErrorLogWriter{
+		Client:  DefaultClient,
+		Output:  output,
+		Pattern: DefaultErrorPattern,
+		Level:   ERROR,
+	}
+}
+
+// Write captures the lines of p matching the pattern and writes p to Output
+func (w *ErrorLogWriter) Write(p []byte) (int, error) {
+	pattern := w.Pattern
+	if pattern == nil {
+		pattern = DefaultErrorPattern
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line == "" || !pattern.MatchString(line) {
+			continue
+		}
+		packet := NewPacket(line, &Message{line, nil})
+		packet.Level = w.Level
+		packet.Logger = w.Logger
+		w.Client.Capture(packet, nil)
+	}
+
+	if w.Output != nil {
+		return w.Output.Write(p)
+	}
+	return len(p), nil
+}
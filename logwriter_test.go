@@ -0,0 +1,33 @@
+package raven
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestErrorLogWriter(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	output := &bytes.Buffer{}
+	writer := &ErrorLogWriter{Client: client, Output: output, Level: ERROR, Logger: "http"}
+	logger := log.New(writer, "", 0)
+	logger.Print("http: TLS handshake error from 127.0.0.1:1234: remote error: tls: bad certificate")
+	logger.Print("http: superfluous response.WriteHeader call")
+	logger.Print("accept: connection refused; retrying in 5ms")
+	client.Wait()
+
+	if output.String() != "http: TLS handshake error from 127.0.0.1:1234: remote error: tls: bad certificate\nhttp: superfluous response.WriteHeader call\naccept: connection refused; retrying in 5ms\n" {
+		t.Errorf("lines should be passed through, got %q", output.String())
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	if packet := transport.packets[0]; packet.Logger != "http" || packet.Level != ERROR {
+		t.Errorf("incorrect packet %+v", packet)
+	}
+}
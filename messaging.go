@@ -0,0 +1,19 @@
+package raven
+
+import "fmt"
+
+// captureHandler runs handler, capturing the error it returns and recovering
+// and capturing a panic, which is returned as error, both tagged with tags.
+// It backs the wrappers of message consumers, which must keep running.
+func (client *Client) captureHandler(tags map[string]string, handler func() error) (err error) {
+	rval, _ := client.CapturePanic(func() {
+		err = handler()
+	}, tags)
+	if rval != nil {
+		return fmt.Errorf("raven: handler panic: %v", rval)
+	}
+	if err != nil {
+		client.CaptureError(err, tags)
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// RequestScope is the state of a request handled by a handler wrapped with
+// WrapHandler, shared with its hooks and stored in the context of the
+// request, see RequestScopeFromContext. Handlers set the user, tags and
+// breadcrumbs of their request on it rather than on the client, whose
+// context is shared by all the requests served concurrently. Its User and
+// Tags fields are attached to the events of the request.
+type RequestScope struct {
+	eventScope
+
+	// Request carries the recorded body, response, transaction and trace in its
+	// context, hooks may replace it before the handler runs.
+	Request *http.Request
+
+	// Writer records the status code and size of the response
+	Writer http.ResponseWriter
+
+	recorder *responseRecorder
+
+	// captured is set once an event was captured through the scope, which
+	// marks the session of the request as errored
+	captured bool
+}
+
+// requestScopeKey stores the *RequestScope of a request in its context
+type requestScopeKey struct{}
+
+// RequestScopeFromContext returns the scope of the request handled by
+// WrapHandler, or Recoverer, which ctx is the context of, nil outside of one.
+func RequestScopeFromContext(ctx context.Context) *RequestScope {
+	scope, _ := ctx.Value(requestScopeKey{}).(*RequestScope)
+	return scope
+}
+
+// CaptureError captures err like Client.CaptureError, with the request, user,
+// tags and breadcrumbs of the scope instead of the context of the client.
+func (s *RequestScope) CaptureError(err error, tags map[string]string) EventID {
+	packet := s.errorPacket(err, s.interfaces())
+	if packet == nil {
+		return ""
+	}
+	eventID, _ := s.client.Capture(s.decorate(packet), withErrorTraits(s.captureTags(tags), err))
+	s.setCaptured()
+	return eventID
+}
+
+// CaptureMessage captures message like Client.CaptureMessage, with the
+// request, user, tags and breadcrumbs of the scope.
+func (s *RequestScope) CaptureMessage(message string, tags map[string]string) EventID {
+	if s.client.shouldExcludeErr(message) {
+		return ""
+	}
+	packet := NewPacket(message, append(s.interfaces(), &Message{message, nil})...)
+	eventID, _ := s.client.Capture(s.decorate(packet), s.captureTags(tags))
+	s.setCaptured()
+	return eventID
+}
+
+func (s *RequestScope) setCaptured() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captured = true
+}
+
+// errored reports whether an event was captured through the scope
+func (s *RequestScope) errored() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.captured
+}
+
+// interfaces returns the request, user and breadcrumbs of the scope
+func (s *RequestScope) interfaces() []Interface {
+	user, breadcrumbs := s.snapshot()
+	if user == nil {
+		user = &User{}
+	}
+	if user.IP == "" {
+		// the IP defaults to the client IP, without modifying the user of the scope
+		user.IP = s.client.ClientIP(s.Request)
+	}
+	interfaces := []Interface{s.client.NewHttp(s.Request), user}
+	if breadcrumbs != nil {
+		interfaces = append(interfaces, breadcrumbs)
+	}
+	return interfaces
+}
+
+// captureTags returns the response tags of the request, see ResponseTags,
+// with the tags of the scope and tags added
+func (s *RequestScope) captureTags(tags map[string]string) map[string]string {
+	merged := ResponseTags(s.Request)
+	if merged == nil {
+		merged = make(map[string]string)
+	}
+	for key, value := range s.eventScope.captureTags(tags) {
+		merged[key] = value
+	}
+	return merged
+}
+
+// decorate sets the transaction, timing and trace of the request on packet
+func (s *RequestScope) decorate(packet *Packet) *Packet {
+	r := s.Request
+	packet.Transaction = RequestTransaction(r)
+	packet.SetTiming(RequestTiming(r))
+	packet.Contexts = Contexts{"trace": traceContext(r)}
+	packet.dsc = s.client.RequestSamplingContext(r)
+	return packet
+}
+
+// Status returns the status code written so far, zero before the header was written
+func (s *RequestScope) Status() int { return s.recorder.status }
+
+// BeginFunc is called before the wrapped handler runs
+type BeginFunc func(scope *RequestScope)
+
+// EndFunc is called after the wrapped handler returned, rval is the value
+// it panicked with, nil otherwise. It runs before the panic is captured.
+type EndFunc func(scope *RequestScope, rval interface{})
+
+// RecoverFunc writes the response of a request whose handler panicked, after
+// the panic was captured as event eventID.
+type RecoverFunc func(scope *RequestScope, rval interface{}, eventID EventID)
+
+// WrapHandler builds the recovery middleware of given client, which Recoverer
+// is built on, with custom hooks. Adapters for frameworks exposing the
+// underlying net/http request, like Iris, Beego or Buffalo, wrap the call of
+// the next handler of the framework into an http.Handler and only customize
+// what differs. Nil hooks are skipped, a nil onRecover responds with a status
+// 500 unless the handler already wrote the header.
+func (client *Client) WrapHandler(begin BeginFunc, end EndFunc, onRecover RecoverFunc) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = client.RecordRequestBody(r)
+			recorder, r := recordResponse(w, r)
+			r, restoreLabels := withTraceLabels(client.withTrace(withTransaction(r)))
+			scope := &RequestScope{eventScope: eventScope{client: client}, Writer: recorder, recorder: recorder}
+			scope.Request = r.WithContext(context.WithValue(r.Context(), requestScopeKey{}, scope))
+			if begin != nil {
+				begin(scope)
+			}
+			profile := client.StartProfile(RequestTransaction(scope.Request))
+
+			defer func() {
+				rval := recover()
+				restoreLabels()
+				if profile != nil {
+					profile.transaction = RequestTransaction(scope.Request)
+					profile.dsc = client.RequestSamplingContext(scope.Request)
+					profile.op = "http.server"
+					if trace := traceContext(scope.Request); trace != nil {
+						profile.traceID, profile.spanID = trace["trace_id"].(string), trace["span_id"].(string)
+					}
+					profile.Stop()
+				}
+				switch {
+				case rval != nil:
+					client.RecordSession(recorder.start, SessionCrashed)
+				case recorder.status >= http.StatusInternalServerError || scope.errored():
+					client.RecordSession(recorder.start, SessionErrored)
+				default:
+					client.RecordSession(recorder.start, SessionExited)
+				}
+				if end != nil {
+					end(scope, rval)
+				}
+				if rval == nil {
+					return
+				}
+
+				debug.PrintStack()
+				rvalStr := fmt.Sprint(rval)
+				var packet *Packet
+				if err, ok := rval.(error); ok {
+					packet = NewPacket(rvalStr, append(scope.interfaces(), NewException(errors.New(rvalStr), GetOrNewStacktrace(err, 2, 3, nil)))...)
+				} else {
+					packet = NewPacket(rvalStr, append(scope.interfaces(), NewException(errors.New(rvalStr), NewStacktrace(2, 3, nil)))...)
+				}
+
+				tags := scope.captureTags(nil)
+				if recorder.status == 0 && tags["http.status_code"] == "" {
+					tags["http.status_code"] = "500"
+				}
+				eventID, _ := client.Capture(scope.decorate(packet), tags)
+
+				if onRecover != nil {
+					onRecover(scope, rval, eventID)
+				} else if recorder.status == 0 {
+					recorder.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			handler.ServeHTTP(scope.Writer, scope.Request)
+		})
+	}
+}
+
+// WrapHandler builds a recovery middleware of the default *Client with custom hooks
+func WrapHandler(begin BeginFunc, end EndFunc, onRecover RecoverFunc) func(http.Handler) http.Handler {
+	return DefaultClient.WrapHandler(begin, end, onRecover)
+}
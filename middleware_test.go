@@ -0,0 +1,127 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWrapHandler(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	var (
+		ended    interface{}
+		captured EventID
+	)
+	middleware := client.WrapHandler(func(scope *RequestScope) {
+		SetRequestTransaction(scope.Request, "GET /users/:id")
+		scope.Tags = map[string]string{"framework": "custom"}
+	}, func(scope *RequestScope, rval interface{}) {
+		ended = rval
+	}, func(scope *RequestScope, rval interface{}, eventID EventID) {
+		captured = eventID
+		scope.Writer.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	w := httptest.NewRecorder()
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})).ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	client.Wait()
+
+	if ended != "boom" || captured == "" || w.Code != http.StatusServiceUnavailable {
+		t.Errorf("hooks were not called: %v %q %d", ended, captured, w.Code)
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	packet := transport.packets[0]
+	tags := map[string]string{}
+	for _, tag := range packet.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if EventID(packet.EventID) != captured || packet.Transaction != "GET /users/:id" || tags["framework"] != "custom" || tags["http.status_code"] != "500" {
+		t.Errorf("incorrect packet %+v", packet)
+	}
+	if packet.StartTimestamp == nil || time.Time(*packet.StartTimestamp).IsZero() {
+		t.Errorf("expected the start of the request, got %v", packet.StartTimestamp)
+	}
+}
+
+func TestRequestScope(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+	client.SetUserContext(&User{ID: "global"})
+
+	handler := client.WrapHandler(nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := RequestScopeFromContext(r.Context())
+		scope.SetUser(&User{ID: r.URL.Query().Get("user")})
+		scope.SetTag("user", r.URL.Query().Get("user"))
+		scope.AddBreadcrumb(DefaultBreadcrumb(INFO, "handler", "handling "+r.URL.Query().Get("user"), nil))
+		w.WriteHeader(http.StatusAccepted)
+		scope.CaptureMessage("handled", nil)
+	}))
+
+	var wg sync.WaitGroup
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?user="+user, nil))
+		}(user)
+	}
+	wg.Wait()
+	// requests which captured an event are errored sessions
+	var errored int
+	for _, bucket := range client.sessions.take(time.Now().Add(time.Minute)) {
+		errored += bucket.Errored
+	}
+	if errored != 4 {
+		t.Errorf("expected 4 errored sessions, got %d", errored)
+	}
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(transport.packets))
+	}
+	for _, packet := range transport.packets {
+		var tag, status string
+		for _, t := range packet.Tags {
+			switch t.Key {
+			case "user":
+				tag = t.Value
+			case "http.status_code":
+				status = t.Value
+			}
+		}
+		if status != "202" {
+			t.Errorf("expected the response tags of the request, got %+v", packet.Tags)
+		}
+		for _, inter := range packet.Interfaces {
+			switch inter := inter.(type) {
+			case *User:
+				if inter.ID != tag || inter.IP == "" {
+					t.Errorf("expected the user %q of the request, got %+v", tag, inter)
+				}
+			case *Breadcrumbs:
+				if len(inter.Values) != 1 || inter.Values[0].Message != "handling "+tag {
+					t.Errorf("expected the breadcrumbs of the request %q, got %+v", tag, inter.Values)
+				}
+			}
+		}
+		if packet.Transaction != "GET /" {
+			t.Errorf("incorrect transaction %q", packet.Transaction)
+		}
+	}
+
+	if RequestScopeFromContext(httptest.NewRequest("GET", "/", nil).Context()) != nil {
+		t.Error("expected no scope outside of a wrapped handler")
+	}
+}
@@ -0,0 +1,94 @@
+package raven
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MultiTransport delivers every packet to the client's own DSN and to each
+// additional DSN it was created with, e.g. a team project plus a
+// company-wide aggregation project. Destinations are sent to concurrently
+// and a failing destination does not prevent delivery to the others.
+type MultiTransport struct {
+	// Transport performs the actual delivery to every destination
+	Transport Transport
+
+	destinations []multiDestination
+}
+
+type multiDestination struct {
+	url        string
+	authHeader string
+}
+
+// NewMultiTransport creates a MultiTransport fanning packets out to dsns
+// through transport, in addition to the DSN configured on the client.
+func NewMultiTransport(transport Transport, dsns ...string) (*MultiTransport, error) {
+	t := &MultiTransport{Transport: transport}
+	for _, dsn := range dsns {
+		d, err := ParseDsn(dsn)
+		if err != nil {
+			return nil, err
+		}
+		t.destinations = append(t.destinations, multiDestination{d.StoreAPIURL(), d.AuthHeader()})
+	}
+	return t, nil
+}
+
+// Unwrap returns the transport delivering to every destination
+func (t *MultiTransport) Unwrap() Transport { return t.Transport }
+
+// Send delivers the packet to all destinations and returns a
+// MultiTransportError describing the ones that failed.
+func (t *MultiTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx
+func (t *MultiTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	transport := AsTransportV2(t.Transport)
+	destinations := t.destinations
+	if url != "" {
+		destinations = append([]multiDestination{{url, authHeader}}, destinations...)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := MultiTransportError{}
+	for _, d := range destinations {
+		wg.Add(1)
+		go func(d multiDestination) {
+			defer wg.Done()
+			if err := transport.SendWithContext(ctx, d.url, d.authHeader, packet); err != nil {
+				mu.Lock()
+				errs[d.url] = err
+				mu.Unlock()
+			}
+		}(d)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MultiTransportError holds the errors of failed MultiTransport destinations keyed by their url
+type MultiTransportError map[string]error
+
+func (e MultiTransportError) Error() string {
+	urls := make([]string, 0, len(e))
+	for u := range e {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	msgs := make([]string, len(urls))
+	for i, u := range urls {
+		msgs[i] = u + ": " + e[u].Error()
+	}
+	return "raven: delivery failed for " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,57 @@
+package raven
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+	fail map[string]bool
+}
+
+func (t *recordingTransport) Send(url, authHeader string, packet *Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.urls = append(t.urls, url)
+	if t.fail[url] {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestMultiTransport(t *testing.T) {
+	team := "https://example.com/api/1/store/"
+	company := "https://example.com/api/2/store/"
+	backup := "https://backup.example.com/api/3/store/"
+	rt := &recordingTransport{fail: map[string]bool{company: true}}
+
+	transport, err := NewMultiTransport(rt, "https://u@example.com/2", "https://u:p@backup.example.com/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = transport.Send(team, "auth", &Packet{Message: "foo"})
+	if len(rt.urls) != 3 {
+		t.Fatalf("expected 3 deliveries, got %v", rt.urls)
+	}
+
+	multiErr, ok := err.(MultiTransportError)
+	if !ok {
+		t.Fatalf("expected MultiTransportError, got %T", err)
+	}
+	if len(multiErr) != 1 || multiErr[company] == nil {
+		t.Errorf("expected only %s to fail, got %v", company, multiErr)
+	}
+	if multiErr[backup] != nil || multiErr[team] != nil {
+		t.Error("a failing destination should not affect the others")
+	}
+}
+
+func TestNewMultiTransportInvalidDSN(t *testing.T) {
+	if _, err := NewMultiTransport(&recordingTransport{}, "https://example.com/1"); err != ErrMissingUser {
+		t.Errorf("expected ErrMissingUser, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package raven
+
+// NATSMessage describes a message received by a NATS subscription, the
+// fields map to nats.Msg and the queue group of the subscription:
+//
+//	nc.QueueSubscribe("orders", "workers", func(m *nats.Msg) {
+//		raven.HandleNATSMessage(raven.NATSMessage{
+//			Subject: m.Subject, Reply: m.Reply, Queue: "workers",
+//		}, func(tags map[string]string) error {
+//			tags["order"] = orderID(m.Data)
+//			return process(m)
+//		})
+//	})
+type NATSMessage struct {
+	Subject string
+	Reply   string
+	Queue   string
+}
+
+// Tags returns the subject, reply subject and queue group of the message as tags
+func (m NATSMessage) Tags() map[string]string {
+	tags := map[string]string{"nats.subject": m.Subject}
+	if m.Reply != "" {
+		tags["nats.reply"] = m.Reply
+	}
+	if m.Queue != "" {
+		tags["nats.queue"] = m.Queue
+	}
+	return tags
+}
+
+// HandleNATSMessage runs handler for a received message, capturing the error
+// it returns and recovering a panic, which is returned as error. handler gets
+// the tags of the events of this message, which it may extend without
+// affecting other messages handled concurrently.
+func (client *Client) HandleNATSMessage(msg NATSMessage, handler func(tags map[string]string) error) error {
+	tags := msg.Tags()
+	return client.captureHandler(tags, func() error {
+		return handler(tags)
+	})
+}
+
+// HandleNATSMessage runs handler for a received message with the default *Client
+func HandleNATSMessage(msg NATSMessage, handler func(tags map[string]string) error) error {
+	return DefaultClient.HandleNATSMessage(msg, handler)
+}
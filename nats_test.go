@@ -0,0 +1,46 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHandleNATSMessage(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	for _, order := range []string{"1", "2"} {
+		order := order
+		msg := NATSMessage{Subject: "orders.created", Reply: "_INBOX.1", Queue: "workers"}
+		client.HandleNATSMessage(msg, func(tags map[string]string) error {
+			tags["order"] = order
+			if order == "2" {
+				panic("nil order")
+			}
+			return errors.New("invalid order")
+		})
+	}
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	for i, packet := range transport.packets {
+		tags := map[string]string{}
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		expected := map[string]string{"nats.subject": "orders.created", "nats.reply": "_INBOX.1", "nats.queue": "workers"}
+		for key, value := range expected {
+			if tags[key] != value {
+				t.Errorf("packet %d: expected tag %s=%s, got %+v", i, key, value, tags)
+			}
+		}
+		if tags["order"] != []string{"1", "2"}[i] {
+			t.Errorf("packet %d: tags of messages should be isolated, got %+v", i, tags)
+		}
+	}
+}
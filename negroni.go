@@ -0,0 +1,19 @@
+package raven
+
+import "net/http"
+
+// NegroniRecovery is a negroni.Handler recovering panics of the rest of the
+// middleware stack like Recoverer: the request is attached as Http interface,
+// the response is recorded and each request gets its own transaction.
+//
+//	n := negroni.New()
+//	n.Use(raven.NewNegroniRecovery())
+type NegroniRecovery struct{}
+
+// NewNegroniRecovery creates a negroni recovery middleware reporting to the default *Client
+func NewNegroniRecovery() *NegroniRecovery { return &NegroniRecovery{} }
+
+// ServeHTTP implements negroni.Handler
+func (h *NegroniRecovery) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	Recoverer(next).ServeHTTP(w, r)
+}
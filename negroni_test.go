@@ -0,0 +1,22 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegroniRecovery(t *testing.T) {
+	var transaction string
+	w := httptest.NewRecorder()
+	NewNegroniRecovery().ServeHTTP(w, httptest.NewRequest("GET", "/orders", nil), func(w http.ResponseWriter, r *http.Request) {
+		transaction = RequestTransaction(r)
+		panic("boom")
+	})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	if transaction != "GET /orders" {
+		t.Errorf("expected a request transaction, got %q", transaction)
+	}
+}
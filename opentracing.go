@@ -0,0 +1,382 @@
+//go:build opentracing
+// +build opentracing
+
+package raven
+
+import (
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Tracer adapts a client to the opentracing.Tracer interface, so that code
+// instrumented with opentracing-go sends its traces to Sentry. It is only
+// built with the opentracing build tag, which requires
+// github.com/opentracing/opentracing-go:
+//
+//	opentracing.SetGlobalTracer(raven.NewTracer(nil))
+//
+// A span started without a local parent starts a transaction, named after
+// its operation, which is sent with the spans of its children finished
+// before it when it finishes. Transactions are only sent when sampled
+// according to SetTracesSampleRate, or by the upstream service whose
+// sentry-trace header was extracted. Span logs are discarded.
+type Tracer struct {
+	client *Client
+}
+
+// NewTracer returns a Tracer sending transactions with client, or the default *Client when nil
+func NewTracer(client *Client) *Tracer {
+	return &Tracer{client: client}
+}
+
+func (t *Tracer) getClient() *Client {
+	if t.client == nil {
+		return DefaultClient
+	}
+	return t.client
+}
+
+// tracerContext is the opentracing.SpanContext of spans started by a Tracer
+type tracerContext struct {
+	traceID string
+	spanID  string
+	sampled string
+
+	// dsc received from the upstream service, frozen for the whole trace
+	dsc     DynamicSamplingContext
+	baggage map[string]string
+
+	// transaction of the local root span, nil for extracted contexts
+	transaction *tracerTransaction
+}
+
+func (c tracerContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+// tracerTransaction collects the finished children of a root span
+type tracerTransaction struct {
+	mu    sync.Mutex
+	spans []map[string]interface{}
+}
+
+func (tx *tracerTransaction) add(span map[string]interface{}) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.spans = append(tx.spans, span)
+}
+
+// StartSpan starts a span of operationName, the child of the first
+// reference to a span of this Tracer, or the root of a transaction
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	var options opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
+	span := &tracerSpan{
+		tracer:    t,
+		operation: operationName,
+		start:     options.StartTime,
+		tags:      make(map[string]interface{}),
+	}
+	if span.start.IsZero() {
+		span.start = time.Now()
+	}
+	for key, value := range options.Tags {
+		span.tags[key] = value
+	}
+
+	var parent *tracerContext
+	for _, ref := range options.References {
+		if c, ok := ref.ReferencedContext.(tracerContext); ok {
+			parent = &c
+			break
+		}
+	}
+	if parent == nil {
+		span.context = tracerContext{traceID: randomID(32), sampled: t.sampled()}
+	} else {
+		span.context = tracerContext{traceID: parent.traceID, sampled: parent.sampled, dsc: parent.dsc}
+		span.parentID = parent.spanID
+		if len(parent.baggage) > 0 {
+			span.context.baggage = make(map[string]string, len(parent.baggage))
+			for k, v := range parent.baggage {
+				span.context.baggage[k] = v
+			}
+		}
+		span.context.transaction = parent.transaction
+	}
+	if span.context.transaction == nil {
+		span.context.transaction = &tracerTransaction{}
+		span.root = true
+	}
+	span.context.spanID = randomID(16)
+	return span
+}
+
+// sampled decides whether a trace started by the tracer is sampled, like withTrace
+func (t *Tracer) sampled() string {
+	client := t.getClient()
+	client.mu.RLock()
+	tracing := client.tracing
+	client.mu.RUnlock()
+
+	if tracing.disabled {
+		return "0"
+	}
+	if !tracing.hasSampleRate {
+		return ""
+	}
+	if tracing.sampleRate > 0 && mrand.Float32() < tracing.sampleRate {
+		return "1"
+	}
+	return "0"
+}
+
+// Inject sets the sentry-trace and baggage headers of carrier, an
+// opentracing.TextMapWriter in the TextMap or HTTPHeaders format
+func (t *Tracer) Inject(sm opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	c, ok := sm.(tracerContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return opentracing.ErrUnsupportedFormat
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	sentryTrace := c.traceID + "-" + c.spanID
+	if c.sampled != "" {
+		sentryTrace += "-" + c.sampled
+	}
+	writer.Set(sentryTraceHeader, sentryTrace)
+	dsc := c.dsc
+	if dsc == nil {
+		dsc = t.getClient().samplingContext(&requestTrace{traceID: c.traceID, sampled: c.sampled}, "")
+	}
+	writer.Set(baggageHeader, dsc.Baggage(""))
+	return nil
+}
+
+// Extract continues the trace of the sentry-trace and baggage headers of
+// carrier, an opentracing.TextMapReader in the TextMap or HTTPHeaders format
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+
+	var sentryTrace, baggage string
+	err := reader.ForeachKey(func(key, value string) error {
+		switch http.CanonicalHeaderKey(key) {
+		case http.CanonicalHeaderKey(sentryTraceHeader):
+			sentryTrace = value
+		case http.CanonicalHeaderKey(baggageHeader):
+			baggage = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(sentryTrace, "-")
+	if len(parts) < 2 || len(parts[0]) != 32 || len(parts[1]) != 16 {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	c := tracerContext{traceID: parts[0], spanID: parts[1]}
+	if len(parts) > 2 {
+		c.sampled = parts[2]
+	}
+	if dsc := ParseBaggage(baggage); len(dsc) > 0 {
+		c.dsc = dsc
+	}
+	return c, nil
+}
+
+// tracerSpan is the opentracing.Span of a Tracer
+type tracerSpan struct {
+	tracer   *Tracer
+	parentID string
+	root     bool
+
+	mu        sync.Mutex
+	context   tracerContext
+	operation string
+	start     time.Time
+	tags      map[string]interface{}
+	finished  bool
+}
+
+func (s *tracerSpan) Finish() { s.FinishWithOptions(opentracing.FinishOptions{}) }
+
+// FinishWithOptions finishes the span, adding it to its transaction, or
+// sending the transaction when it is the root span
+func (s *tracerSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	end := opts.FinishTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	span := map[string]interface{}{
+		"trace_id":        s.context.traceID,
+		"span_id":         s.context.spanID,
+		"op":              s.operation,
+		"start_timestamp": s.start.UTC().Format(time.RFC3339Nano),
+		"timestamp":       end.UTC().Format(time.RFC3339Nano),
+	}
+	if s.parentID != "" {
+		span["parent_span_id"] = s.parentID
+	}
+	tags := make(map[string]string, len(s.tags))
+	for key, value := range s.tags {
+		if key == "error" {
+			if isError, _ := value.(bool); isError {
+				span["status"] = "internal_error"
+			}
+			continue
+		}
+		tags[key] = fmt.Sprint(value)
+	}
+	if len(tags) > 0 {
+		span["tags"] = tags
+	}
+	s.mu.Unlock()
+
+	if !s.root {
+		s.context.transaction.add(span)
+		return
+	}
+	if s.context.sampled == "1" {
+		s.tracer.getClient().sendTransaction(s.operation, s.context, span)
+	}
+}
+
+// sendTransaction sends the transaction of root, the trace context of the
+// root span, and the spans finished before it
+func (client *Client) sendTransaction(name string, c tracerContext, root map[string]interface{}) {
+	client.mu.RLock()
+	release, environment := client.release, client.environment
+	client.mu.RUnlock()
+
+	eventID, err := uuid()
+	if err != nil {
+		return
+	}
+	c.transaction.mu.Lock()
+	spans := c.transaction.spans
+	c.transaction.mu.Unlock()
+
+	trace := map[string]interface{}{
+		"trace_id": root["trace_id"],
+		"span_id":  root["span_id"],
+		"op":       root["op"],
+	}
+	for _, key := range []string{"parent_span_id", "status"} {
+		if value, ok := root[key]; ok {
+			trace[key] = value
+		}
+	}
+	transaction := map[string]interface{}{
+		"type":            "transaction",
+		"event_id":        eventID,
+		"platform":        "go",
+		"transaction":     name,
+		"start_timestamp": root["start_timestamp"],
+		"timestamp":       root["timestamp"],
+		"release":         release,
+		"environment":     environment,
+		"contexts":        map[string]interface{}{"trace": trace},
+		"spans":           spans,
+	}
+	if tags, ok := root["tags"]; ok {
+		transaction["tags"] = tags
+	}
+	payload, err := json.Marshal(transaction)
+	if err != nil {
+		client.Logger().Errorf("error marshaling transaction: %v", err)
+		return
+	}
+	dsc := c.dsc
+	if dsc == nil {
+		dsc = client.samplingContext(&requestTrace{traceID: c.traceID, sampled: c.sampled}, name)
+	}
+	client.sendEnvelope("transaction", eventID, dsc, envelopeItem{Type: "transaction", Payload: payload})
+}
+
+func (s *tracerSpan) Context() opentracing.SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context
+}
+
+func (s *tracerSpan) SetOperationName(operationName string) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operation = operationName
+	return s
+}
+
+func (s *tracerSpan) SetTag(key string, value interface{}) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[key] = value
+	return s
+}
+
+// SetBaggageItem sets a baggage item propagated to the children of the
+// span, it is not propagated to other services.
+func (s *tracerSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	baggage := make(map[string]string, len(s.context.baggage)+1)
+	for k, v := range s.context.baggage {
+		baggage[k] = v
+	}
+	baggage[restrictedKey] = value
+	s.context.baggage = baggage
+	return s
+}
+
+func (s *tracerSpan) BaggageItem(restrictedKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context.baggage[restrictedKey]
+}
+
+func (s *tracerSpan) Tracer() opentracing.Tracer { return s.tracer }
+
+func (s *tracerSpan) LogFields(fields ...log.Field) {}
+
+func (s *tracerSpan) LogKV(alternatingKeyValues ...interface{}) {}
+
+func (s *tracerSpan) LogEvent(event string) {}
+
+func (s *tracerSpan) LogEventWithPayload(event string, payload interface{}) {}
+
+func (s *tracerSpan) Log(data opentracing.LogData) {}
@@ -0,0 +1,116 @@
+//go:build opentracing
+// +build opentracing
+
+package raven
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func TestTracerSendsTransaction(t *testing.T) {
+	client := newClient(nil)
+	transport := &envelopeRecorder{}
+	client.Transport = transport
+	if err := client.SetTracesSampleRate(1); err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(client)
+
+	root := tracer.StartSpan("GET /", opentracing.Tag{Key: "http.method", Value: "GET"})
+	child := tracer.StartSpan("query", opentracing.ChildOf(root.Context()))
+	child.SetTag("error", true)
+	child.Finish()
+	root.Finish()
+	root.Finish()
+	client.Wait()
+
+	if len(transport.envelopes) != 1 {
+		t.Fatalf("expected a single transaction, got %q", transport.envelopes)
+	}
+	lines := strings.Split(transport.envelopes[0], "\n")
+	if !strings.Contains(lines[1], `"type":"transaction"`) {
+		t.Fatalf("expected a transaction item, got %s", lines[1])
+	}
+	var transaction struct {
+		Transaction string
+		Tags        map[string]string
+		Contexts    struct {
+			Trace map[string]string
+		}
+		Spans []map[string]string
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &transaction); err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Transaction != "GET /" || transaction.Tags["http.method"] != "GET" {
+		t.Errorf("incorrect transaction %+v", transaction)
+	}
+	trace := transaction.Contexts.Trace
+	if len(transaction.Spans) != 1 {
+		t.Fatalf("expected a single span, got %v", transaction.Spans)
+	}
+	span := transaction.Spans[0]
+	if span["op"] != "query" || span["trace_id"] != trace["trace_id"] || span["parent_span_id"] != trace["span_id"] || span["status"] != "internal_error" {
+		t.Errorf("incorrect span %v of trace %v", span, trace)
+	}
+}
+
+func TestTracerUnsampled(t *testing.T) {
+	client := newClient(nil)
+	transport := &envelopeRecorder{}
+	client.Transport = transport
+	tracer := NewTracer(client)
+
+	tracer.StartSpan("unsampled").Finish()
+	client.Wait()
+	if len(transport.envelopes) != 0 {
+		t.Errorf("expected no transaction without a sample rate, got %q", transport.envelopes)
+	}
+}
+
+func TestTracerInjectExtract(t *testing.T) {
+	client := newClient(nil)
+	client.SetRelease("1.0")
+	if err := client.SetTracesSampleRate(1); err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(client)
+	span := tracer.StartSpan("GET /")
+
+	header := http.Header{}
+	if err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		t.Fatal(err)
+	}
+	c := span.Context().(tracerContext)
+	if expected := c.traceID + "-" + c.spanID + "-1"; header.Get("sentry-trace") != expected {
+		t.Errorf("incorrect sentry-trace header %q, expected %q", header.Get("sentry-trace"), expected)
+	}
+	if !strings.Contains(header.Get("baggage"), "sentry-release=1.0") {
+		t.Errorf("expected the release in the baggage header, got %q", header.Get("baggage"))
+	}
+
+	extracted, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := extracted.(tracerContext)
+	if e.traceID != c.traceID || e.spanID != c.spanID || e.sampled != "1" || e.dsc["release"] != "1.0" {
+		t.Errorf("incorrect extracted context %+v", e)
+	}
+	child := tracer.StartSpan("handle", opentracing.ChildOf(extracted)).(*tracerSpan)
+	if !child.root || child.parentID != c.spanID || child.context.traceID != c.traceID {
+		t.Errorf("expected the root of a transaction continuing the trace, got %+v", child)
+	}
+
+	if _, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{})); err != opentracing.ErrSpanContextNotFound {
+		t.Errorf("expected ErrSpanContextNotFound, got %v", err)
+	}
+	if _, err := tracer.Extract(opentracing.Binary, nil); err != opentracing.ErrUnsupportedFormat {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
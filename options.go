@@ -0,0 +1,164 @@
+package raven
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// Errors of invalid ClientOptions, see OptionError
+var (
+	ErrInvalidQueueSize = errors.New("raven: queue size should not be negative")
+	ErrInvalidTimeout   = errors.New("raven: timeout should not be negative")
+)
+
+// ClientOptions configures a client created with NewWithOptions. Zero values
+// keep the defaults, the DSN, release and environment default to the
+// SENTRY_DSN, SENTRY_RELEASE and SENTRY_ENVIRONMENT environment variables
+// and Tags are completed by SENTRY_TAGS.
+type ClientOptions struct {
+	DSN         string
+	Tags        map[string]string
+	Release     string
+	Environment string
+	// DetectEnvironment infers the environment when none is set, see Client.DetectEnvironment
+	DetectEnvironment bool
+
+	// SampleRate is the share of events sent, between 0 and 1, 0 sends all events
+	SampleRate float32
+	// TracesSampleRate is the share of traces sampled, see SetTracesSampleRate
+	TracesSampleRate float32
+	// ProfilesSampleRate is the share of requests profiled, see SetProfilesSampleRate
+	ProfilesSampleRate float32
+
+	// QueueSize is the number of packets buffered for delivery, MaxQueueBuffer by default
+	QueueSize int
+	// SendTimeout is the deadline of a single send, see SetSendTimeout
+	SendTimeout time.Duration
+
+	// Logger receives the internal diagnostics of the client, including
+	// those about the environment it is configured from, see SetLogger
+	Logger Logger
+}
+
+// OptionError describes an invalid option, Err is one of the sentinel errors
+// ErrInvalidSampleRate, ErrInvalidQueueSize, ErrInvalidTimeout, ErrMissingUser
+// and ErrMissingProjectID, or the error parsing the DSN.
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string { return e.Option + ": " + e.Err.Error() }
+
+// Cause returns the underlying error
+func (e *OptionError) Cause() error { return e.Err }
+
+// OptionsError enumerates every invalid option passed to NewWithOptions
+type OptionsError []*OptionError
+
+func (e OptionsError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "raven: invalid options: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks all options and returns an OptionsError of the invalid
+// ones, nil if they are valid
+func (o ClientOptions) Validate() error {
+	var errs OptionsError
+	invalid := func(option string, err error) {
+		errs = append(errs, &OptionError{option, err})
+	}
+
+	dsn := o.dsn()
+	if dsn != "" && !strings.HasPrefix(dsn, dryRunScheme+"://") {
+		if _, err := ParseDsn(dsn); err != nil {
+			invalid("DSN", err)
+		}
+	}
+	for option, rate := range map[string]float32{
+		"SampleRate":         o.SampleRate,
+		"TracesSampleRate":   o.TracesSampleRate,
+		"ProfilesSampleRate": o.ProfilesSampleRate,
+	} {
+		if rate < 0 || rate > 1 {
+			invalid(option, ErrInvalidSampleRate)
+		}
+	}
+	if o.QueueSize < 0 {
+		invalid("QueueSize", ErrInvalidQueueSize)
+	}
+	if o.SendTimeout < 0 {
+		invalid("SendTimeout", ErrInvalidTimeout)
+	}
+
+	if len(errs) > 0 {
+		// sample rates are checked in random map order
+		sortOptionErrors(errs)
+		return errs
+	}
+	return nil
+}
+
+func sortOptionErrors(errs OptionsError) {
+	for i := 1; i < len(errs); i++ {
+		for j := i; j > 0 && errs[j].Option < errs[j-1].Option; j-- {
+			errs[j], errs[j-1] = errs[j-1], errs[j]
+		}
+	}
+}
+
+func (o ClientOptions) dsn() string {
+	if o.DSN != "" {
+		return o.DSN
+	}
+	return os.Getenv("SENTRY_DSN")
+}
+
+// NewWithOptions constructs a client configured with options, unlike New it
+// validates all options and returns an OptionsError enumerating every
+// invalid one, including a malformed SENTRY_DSN, instead of logging it.
+func NewWithOptions(options ClientOptions) (*Client, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := newBareClient(options.Tags)
+	if options.Logger != nil {
+		client.SetLogger(options.Logger)
+	}
+	client.setFromEnv()
+	if err := client.SetDSN(options.DSN); err != nil {
+		return nil, err
+	}
+	if options.Release != "" {
+		client.SetRelease(options.Release)
+	}
+	if options.Environment != "" {
+		client.SetEnvironment(options.Environment)
+	}
+	if options.DetectEnvironment {
+		client.DetectEnvironment()
+	}
+	if options.SampleRate > 0 {
+		client.SetSampleRate(options.SampleRate)
+	}
+	if options.TracesSampleRate > 0 {
+		client.SetTracesSampleRate(options.TracesSampleRate)
+	}
+	if options.ProfilesSampleRate > 0 {
+		client.SetProfilesSampleRate(options.ProfilesSampleRate)
+	}
+	if options.QueueSize > 0 {
+		// the worker is not started before the first capture
+		client.queue = make(chan *outgoingPacket, options.QueueSize)
+	}
+	if options.SendTimeout > 0 {
+		client.SetSendTimeout(options.SendTimeout)
+	}
+	return client, nil
+}
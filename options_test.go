@@ -0,0 +1,73 @@
+package raven
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	client, err := NewWithOptions(ClientOptions{
+		DSN:              "https://public@sentry.example.com/1",
+		Release:          "1.0",
+		SampleRate:       0.5,
+		TracesSampleRate: 0.1,
+		QueueSize:        10,
+		SendTimeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ProjectID() != "1" || client.Release() != "1.0" || client.sampleRate != 0.5 || client.tracing.sampleRate != 0.1 || cap(client.queue) != 10 || client.sendTimeout != time.Second {
+		t.Errorf("options not applied to %+v", client)
+	}
+}
+
+func TestNewWithOptionsLogger(t *testing.T) {
+	os.Setenv("SENTRY_TAGS", "invalid")
+	defer os.Unsetenv("SENTRY_TAGS")
+
+	logger := &testLogger{}
+	client, err := NewWithOptions(ClientOptions{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Logger() != logger {
+		t.Errorf("expected the logger option, got %T", client.Logger())
+	}
+	if len(logger.error) != 1 || !strings.Contains(logger.error[0], "SENTRY_TAGS") {
+		t.Errorf("expected the SENTRY_TAGS diagnostic, got %q", logger.error)
+	}
+}
+
+func TestNewWithOptionsValidation(t *testing.T) {
+	os.Setenv("SENTRY_DSN", "https://sentry.example.com/1")
+	defer os.Unsetenv("SENTRY_DSN")
+
+	_, err := NewWithOptions(ClientOptions{
+		SampleRate:         2,
+		ProfilesSampleRate: -1,
+		QueueSize:          -1,
+		SendTimeout:        -time.Second,
+	})
+	errs, ok := err.(OptionsError)
+	if !ok {
+		t.Fatalf("expected an OptionsError, got %v", err)
+	}
+	expected := []OptionError{
+		{"DSN", ErrMissingUser},
+		{"ProfilesSampleRate", ErrInvalidSampleRate},
+		{"QueueSize", ErrInvalidQueueSize},
+		{"SampleRate", ErrInvalidSampleRate},
+		{"SendTimeout", ErrInvalidTimeout},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errs)
+	}
+	for i, e := range expected {
+		if errs[i].Option != e.Option || errs[i].Err != e.Err {
+			t.Errorf("expected %v, got %v", &e, errs[i])
+		}
+	}
+}
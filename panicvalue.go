@@ -0,0 +1,109 @@
+package raven
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Limits of the serialization of panic values
+const (
+	panicValueMaxDepth = 5
+	panicValueMaxItems = 100
+)
+
+// panicValueExtra returns the extra describing a recovered value which is
+// not an error, "panic.type" and, for structs, maps, slices and arrays, the
+// serialized "panic.value" which fmt.Sprint would collapse into the message.
+func panicValueExtra(rval interface{}) Extra {
+	v := reflect.ValueOf(rval)
+	extra := Extra{"panic.type": v.Type().String()}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		extra["panic.value"] = serializeValue(v, panicValueMaxDepth)
+	}
+	return extra
+}
+
+// serializeValue converts v, including unexported struct fields, into values
+// marshaled to JSON as they are, up to depth nested levels and
+// panicValueMaxItems elements of maps, slices and arrays. Fields and keys
+// with a secret looking name are masked, NaN and infinite floats, which JSON
+// can't represent, are converted to strings.
+func serializeValue(v reflect.Value, depth int) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprint(f)
+		}
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return v.Type().String()
+	}
+
+	if depth <= 0 {
+		return v.Type().String()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return serializeValue(v.Elem(), depth-1)
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			if isSecretField(name) {
+				fields[name] = "********"
+				continue
+			}
+			fields[name] = serializeValue(v.Field(i), depth-1)
+		}
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]interface{}, v.Len())
+		for i, key := range v.MapKeys() {
+			if i == panicValueMaxItems {
+				break
+			}
+			name := fmt.Sprint(key)
+			if isSecretField(name) {
+				entries[name] = "********"
+				continue
+			}
+			entries[name] = serializeValue(v.MapIndex(key), depth-1)
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		n := v.Len()
+		if n > panicValueMaxItems {
+			n = panicValueMaxItems
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = serializeValue(v.Index(i), depth-1)
+		}
+		return items
+	}
+	return fmt.Sprint(v)
+}
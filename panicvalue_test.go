@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+type panicState struct {
+	Order   int
+	items   []string
+	secret  string
+	ratio   float64
+	Details map[string]interface{}
+	Next    *panicState
+}
+
+func TestPanicValueExtra(t *testing.T) {
+	state := &panicState{Order: 42, items: []string{"a"}, secret: "s3cr3t", ratio: math.NaN(), Details: map[string]interface{}{"retry": true, "password": "hunter2", "limit": math.Inf(1)}}
+	state.Next = state
+
+	extra := panicValueExtra(state)
+	if extra["panic.type"] != "*raven.panicState" {
+		t.Errorf("incorrect type %v", extra["panic.type"])
+	}
+	value, ok := extra["panic.value"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the fields of the struct, got %#v", extra["panic.value"])
+	}
+	if value["Order"] != int64(42) || len(value["items"].([]interface{})) != 1 || value["Details"].(map[string]interface{})["retry"] != true {
+		t.Errorf("incorrect fields %v", value)
+	}
+	details := value["Details"].(map[string]interface{})
+	if value["secret"] != "********" || details["password"] != "********" {
+		t.Errorf("expected secret fields to be masked, got %v", value)
+	}
+	if value["ratio"] != "NaN" || details["limit"] != "+Inf" {
+		t.Errorf("expected non-finite floats as strings, got %v", value)
+	}
+	if _, err := json.Marshal(extra); err != nil {
+		t.Errorf("expected the cyclic value to be depth limited: %v", err)
+	}
+
+	if extra := panicValueExtra("boom"); len(extra) != 1 || extra["panic.type"] != "string" {
+		t.Errorf("expected only the type of a string, got %v", extra)
+	}
+}
+
+func TestCapturePanicValue(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.CapturePanicAndWait(func() {
+		panic(map[string]int{"attempts": 3})
+	}, nil)
+
+	value, ok := transport.packets[0].Extra["panic.value"].(map[string]interface{})
+	if !ok || value["attempts"] != int64(3) {
+		t.Errorf("expected the panic value in extra, got %v", transport.packets[0].Extra)
+	}
+}
@@ -0,0 +1,105 @@
+package raven
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrMissingDSN is returned by Ping for a client without DSN
+var ErrMissingDSN = errors.New("raven: client has no dsn")
+
+// PingErrorKind tells apart the misconfigurations a failed Ping points to
+type PingErrorKind string
+
+// Kinds of Ping failures
+const (
+	// PingNetwork means Sentry could not be reached, e.g. because of DNS,
+	// firewall or proxy issues
+	PingNetwork PingErrorKind = "network"
+	// PingAuth means Sentry rejected the keys of the DSN
+	PingAuth PingErrorKind = "auth"
+	// PingProjectNotFound means the project of the DSN does not exist
+	PingProjectNotFound PingErrorKind = "project_not_found"
+	// PingServer means Sentry responded with any other error
+	PingServer PingErrorKind = "server"
+)
+
+// PingError is returned by Ping when Sentry can't be reached or rejects the DSN
+type PingError struct {
+	Kind PingErrorKind
+
+	// StatusCode of the Sentry response, zero for network errors
+	StatusCode int
+
+	Err error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("raven: ping failed (%s): %v", e.Kind, e.Err)
+}
+
+// Cause returns the underlying error
+func (e *PingError) Cause() error { return e.Err }
+
+// Ping sends an empty envelope to the envelope endpoint of the DSN of given
+// client to verify the DSN, its keys and the network path to Sentry, e.g. at
+// startup, before the first event is captured. It returns ErrMissingDSN
+// without DSN, a *PingError if Sentry can't be reached or rejects the DSN,
+// and nil otherwise. The request bypasses the Transport of the client but
+// uses the http.Client of an HTTPTransport.
+func (client *Client) Ping(ctx context.Context) error {
+	client.mu.RLock()
+	dsn, authHeader, transport := client.dsn, client.authHeader, client.Transport
+	client.mu.RUnlock()
+	if dsn == nil {
+		return ErrMissingDSN
+	}
+
+	body, err := envelope("", nil)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", dsn.EnvelopeAPIURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("raven: can't create new request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Sentry-Auth", authHeader)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", envelopeContentType)
+
+	httpClient := http.DefaultClient
+	if t := findHTTPTransport(transport); t != nil && t.Client != nil {
+		httpClient = t.Client
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return &PingError{Kind: PingNetwork, Err: err}
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode < 300 {
+		return nil
+	}
+	pingErr := &PingError{
+		Kind:       PingServer,
+		StatusCode: res.StatusCode,
+		Err:        fmt.Errorf("got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error")),
+	}
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		pingErr.Kind = PingAuth
+	case http.StatusNotFound:
+		pingErr.Kind = PingProjectNotFound
+	}
+	return pingErr
+}
+
+// Ping verifies the DSN of the default *Client
+func Ping(ctx context.Context) error { return DefaultClient.Ping(ctx) }
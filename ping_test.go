@@ -0,0 +1,49 @@
+package raven
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/404/envelope/":
+			w.WriteHeader(http.StatusNotFound)
+		case !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public"):
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/api/42/envelope/" || r.Header.Get("Content-Type") != envelopeContentType:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := newClient(nil)
+	if err := client.Ping(context.Background()); err != ErrMissingDSN {
+		t.Errorf("expected ErrMissingDSN, got %v", err)
+	}
+
+	tests := map[string]PingErrorKind{
+		"http://public@" + host + "/42":  "",
+		"http://revoked@" + host + "/42": PingAuth,
+		"http://public@" + host + "/404": PingProjectNotFound,
+		"http://public@127.0.0.1:1/42":   PingNetwork,
+	}
+	for dsn, kind := range tests {
+		client.SetDSN(dsn)
+		err := client.Ping(context.Background())
+		if kind == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", dsn, err)
+			}
+			continue
+		}
+		if pingErr, ok := err.(*PingError); !ok || pingErr.Kind != kind {
+			t.Errorf("%s: expected a %s error, got %v", dsn, kind, err)
+		}
+	}
+}
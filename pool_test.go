@@ -0,0 +1,69 @@
+package raven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAcquireReleasePacket(t *testing.T) {
+	packet := AcquirePacket("foo", &Message{Message: "foo"})
+	if packet.Message != "foo" || len(packet.Interfaces) != 1 || packet.Extra["runtime.Version"] == nil {
+		t.Fatalf("incorrect acquired packet: %+v", packet)
+	}
+	packet.AddTags(map[string]string{"foo": "bar"})
+	packet.Fingerprint = []string{"foo"}
+
+	ReleasePacket(packet)
+	if packet.Message != "" || len(packet.Interfaces) != 0 || len(packet.Tags) != 0 || len(packet.Extra) != 0 || packet.Fingerprint != nil {
+		t.Errorf("released packet was not reset: %+v", packet)
+	}
+}
+
+func TestReleasePacketKeepsCallerData(t *testing.T) {
+	extra := Extra{"foo": "bar"}
+	interfaces := []Interface{&Message{Message: "foo"}}
+
+	packet := AcquirePacket("foo")
+	packet.Extra = extra
+	packet.Interfaces = interfaces
+	ReleasePacket(packet)
+	if extra["foo"] != "bar" || interfaces[0] == nil {
+		t.Error("the caller's extra and interfaces should be left untouched")
+	}
+
+	packet = AcquirePacket("bar")
+	defer ReleasePacket(packet)
+	packet.Extra["baz"] = true
+	if len(extra) != 1 {
+		t.Error("the caller's extra should not be reused by the pool")
+	}
+}
+
+func TestPooledPacketReleasedAfterSend(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+
+	packet := AcquirePacket("foo")
+	_, ch := client.Capture(packet, map[string]string{"foo": "bar"})
+	if err := <-ch; err != nil {
+		t.Fatal(err)
+	}
+	if packet.Message != "" || packet.EventID != "" {
+		t.Errorf("pooled packet should be reset after send: %+v", packet)
+	}
+}
+
+func TestPacketClone(t *testing.T) {
+	packet := NewPacket("foo", &Message{Message: "foo"})
+	packet.AddTags(map[string]string{"foo": "bar"})
+	clone := packet.Clone()
+	if !reflect.DeepEqual(clone, packet) {
+		t.Errorf("incorrect clone: %+v", clone)
+	}
+
+	clone.Extra["foo"] = "bar"
+	clone.Tags[0].Value = "baz"
+	if packet.Extra["foo"] != nil || packet.Tags[0].Value != "bar" {
+		t.Error("clone should not share data with the original")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.9
+// +build go1.9
+
+package raven
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// pprof labels set on the goroutines handling requests
+const (
+	pprofTraceIDLabel     = "sentry.trace_id"
+	pprofTransactionLabel = "sentry.transaction"
+)
+
+// withTraceLabels adds the trace id and transaction, as named before the
+// handler runs, of r as pprof labels to its context and to the calling
+// goroutine, so that samples of CPU profiles taken while handling r can be
+// correlated with its events. restore resets the labels of the goroutine,
+// which net/http reuses for further requests.
+func withTraceLabels(r *http.Request) (labeled *http.Request, restore func()) {
+	trace, ok := r.Context().Value(traceKey{}).(*requestTrace)
+	if !ok {
+		return r, func() {}
+	}
+	previous := r.Context()
+	ctx := pprof.WithLabels(previous, pprof.Labels(
+		pprofTraceIDLabel, trace.traceID,
+		pprofTransactionLabel, RequestTransaction(r),
+	))
+	pprof.SetGoroutineLabels(ctx)
+	return r.WithContext(ctx), func() { pprof.SetGoroutineLabels(previous) }
+}
+
+// DoWithTraceLabels calls f with the trace id and transaction of req, which
+// must be handled by Recoverer, as pprof labels of ctx and the current
+// goroutine, e.g. for work handed over to other goroutines.
+func DoWithTraceLabels(ctx context.Context, req *http.Request, f func(ctx context.Context)) {
+	trace, ok := req.Context().Value(traceKey{}).(*requestTrace)
+	if !ok {
+		f(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(
+		pprofTraceIDLabel, trace.traceID,
+		pprofTransactionLabel, RequestTransaction(req),
+	), f)
+}
+
+// PprofLabelTags returns the pprof labels of ctx as tags prefixed by "pprof.",
+// e.g. to capture errors with the labels of the CPU profile samples taken
+// while they occurred.
+func PprofLabelTags(ctx context.Context) map[string]string {
+	tags := map[string]string{}
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		tags["pprof."+key] = value
+		return true
+	})
+	return tags
+}
@@ -0,0 +1,11 @@
+//go:build !go1.9
+// +build !go1.9
+
+package raven
+
+import "net/http"
+
+// withTraceLabels is a no-op before Go 1.9, which introduced pprof labels
+func withTraceLabels(r *http.Request) (labeled *http.Request, restore func()) {
+	return r, func() {}
+}
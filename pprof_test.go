@@ -0,0 +1,33 @@
+//go:build go1.9
+// +build go1.9
+
+package raven
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofLabelTags(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &packetTransport{}
+
+	var tags, done map[string]string
+	handler := client.WrapHandler(nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tags = PprofLabelTags(r.Context())
+		DoWithTraceLabels(context.Background(), r, func(ctx context.Context) {
+			done = PprofLabelTags(ctx)
+		})
+	}))
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("sentry-trace", "771a43a4192642f0b136d5159a501700-b136d5159a501700")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, labels := range []map[string]string{tags, done} {
+		if labels["pprof.sentry.trace_id"] != "771a43a4192642f0b136d5159a501700" || labels["pprof.sentry.transaction"] != "GET /users" {
+			t.Errorf("incorrect labels %v", labels)
+		}
+	}
+}
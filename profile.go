@@ -0,0 +1,395 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	mrand "math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sampling interval and maximum duration of a profile, the maximum number of
+// goroutine samples a profile records and the maximum size of the stacks
+// dumped by a sampling, which bound the memory of profiles of processes
+// running many goroutines
+const (
+	profileInterval     = 10 * time.Millisecond
+	profileMaxDuration  = 30 * time.Second
+	profileMaxSamples   = 100000
+	profileMaxStackSize = 4 << 20
+)
+
+// Profile samples the stacks of all goroutines while a transaction runs,
+// started with StartProfile and sent to Sentry Profiling by Stop. A nil
+// *Profile, returned when the transaction was not sampled, is a no-op.
+type Profile struct {
+	client      *Client
+	transaction string
+	eventID     string
+	start       time.Time
+	activeID    uint64
+	// prefixes of in app frames
+	includePaths []string
+
+	// trace of the transaction, see withTrace
+	traceID string
+	spanID  string
+	op      string
+	dsc     DynamicSamplingContext
+
+	end  time.Time
+	once sync.Once
+
+	// guarded by the mu of profileSampler
+	frames     []profileFrame
+	frameIndex map[string]int
+	stacks     [][]int
+	stackIndex map[string]int
+	samples    []profileSample
+	threads    map[string]map[string]string
+}
+
+type profileFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type profileSample struct {
+	ElapsedSinceStartNS uint64 `json:"elapsed_since_start_ns"`
+	ThreadID            uint64 `json:"thread_id,string"`
+	StackID             int    `json:"stack_id"`
+}
+
+// SetProfilesSampleRate sets the share of transactions given client profiles,
+// profiling is disabled by default
+func (client *Client) SetProfilesSampleRate(rate float32) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if rate < 0 || rate > 1 {
+		return ErrInvalidSampleRate
+	}
+	client.profilesSampleRate = rate
+	return nil
+}
+
+// SetProfilesSampleRate sets the share of transactions the default *Client profiles
+func SetProfilesSampleRate(rate float32) error { return DefaultClient.SetProfilesSampleRate(rate) }
+
+// StartProfile starts profiling transaction, sampling the stacks of all
+// goroutines every 10ms for up to 30s, if it is sampled according to
+// SetProfilesSampleRate, and returns nil otherwise. Requests handled by a
+// handler wrapped with Recoverer or WrapHandler are profiled automatically.
+// Concurrent profiles share the samples of a single sampler, as each one
+// stops the world.
+func (client *Client) StartProfile(transaction string) *Profile {
+	client.mu.RLock()
+	rate := client.profilesSampleRate
+	client.mu.RUnlock()
+	if rate <= 0 || (rate < 1 && mrand.Float32() > rate) {
+		return nil
+	}
+
+	eventID, err := uuid()
+	if err != nil {
+		return nil
+	}
+	p := &Profile{
+		client:       client,
+		transaction:  transaction,
+		eventID:      eventID,
+		start:        time.Now(),
+		activeID:     currentGoroutineID(),
+		includePaths: client.IncludePaths(),
+		traceID:      randomID(32),
+		spanID:       randomID(16),
+		frameIndex:   make(map[string]int),
+		stackIndex:   make(map[string]int),
+		threads:      make(map[string]map[string]string),
+	}
+	sampler.add(p)
+	return p
+}
+
+// StartProfile starts profiling transaction with the default *Client
+func StartProfile(transaction string) *Profile { return DefaultClient.StartProfile(transaction) }
+
+// profileSampler samples the stacks of all goroutines for every running
+// profile, its goroutine only runs while there are profiles.
+type profileSampler struct {
+	mu       sync.Mutex
+	profiles map[*Profile]struct{}
+	running  bool
+}
+
+var sampler = &profileSampler{profiles: make(map[*Profile]struct{})}
+
+func (s *profileSampler) add(p *Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[p] = struct{}{}
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+// remove stops sampling p, which is no longer written to once it returns
+func (s *profileSampler) remove(p *Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.profiles, p)
+}
+
+func (s *profileSampler) run() {
+	ticker := time.NewTicker(profileInterval)
+	defer ticker.Stop()
+	buf := make([]byte, 64<<10)
+
+	for {
+		var goroutines []goroutineStack
+		goroutines, buf = stackSample(buf, profileMaxStackSize)
+		now := time.Now()
+
+		s.mu.Lock()
+		for p := range s.profiles {
+			if now.Sub(p.start) > profileMaxDuration || len(p.samples) >= profileMaxSamples {
+				delete(s.profiles, p)
+				continue
+			}
+			p.sample(goroutines, now)
+		}
+		if len(s.profiles) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		<-ticker.C
+	}
+}
+
+// stackSample parses the stacks of all goroutines, growing buf until they
+// fit or it reaches max bytes. The goroutines which don't fit are skipped.
+func stackSample(buf []byte, max int) ([]goroutineStack, []byte) {
+	n := runtime.Stack(buf, true)
+	for n == len(buf) && len(buf) < max {
+		size := 2 * len(buf)
+		if size > max {
+			size = max
+		}
+		buf = make([]byte, size)
+		n = runtime.Stack(buf, true)
+	}
+	trace := buf[:n]
+	if n == len(buf) {
+		// the last goroutine of a truncated dump is incomplete
+		if idx := bytes.LastIndex(trace, []byte("\n\n")); idx != -1 {
+			trace = trace[:idx]
+		}
+	}
+	return parseGoroutines(trace), buf
+}
+
+// sample records the stacks of goroutines taken at now, up to profileMaxSamples
+func (p *Profile) sample(goroutines []goroutineStack, now time.Time) {
+	elapsed := uint64(now.Sub(p.start).Nanoseconds())
+	for _, g := range goroutines {
+		if len(p.samples) >= profileMaxSamples {
+			return
+		}
+		if g.id == 0 {
+			continue
+		}
+		threadID := strconv.FormatUint(g.id, 10)
+		if _, ok := p.threads[threadID]; !ok {
+			p.threads[threadID] = map[string]string{"name": "Goroutine " + threadID}
+		}
+		p.samples = append(p.samples, profileSample{
+			ElapsedSinceStartNS: elapsed,
+			ThreadID:            g.id,
+			StackID:             p.stackID(g.frames),
+		})
+	}
+}
+
+// stackID returns the index of the stack of frames, adding it and its frames if new
+func (p *Profile) stackID(frames []profileFrame) int {
+	stack := make([]int, 0, len(frames))
+	for _, frame := range frames {
+		key := frame.Module + "." + frame.Function + ":" + frame.AbsPath + ":" + strconv.Itoa(frame.Lineno)
+		index, ok := p.frameIndex[key]
+		if !ok {
+			frame.InApp = isInAppFrame(StacktraceFrame{Module: frame.Module}, p.includePaths)
+			index = len(p.frames)
+			p.frames = append(p.frames, frame)
+			p.frameIndex[key] = index
+		}
+		stack = append(stack, index)
+	}
+
+	ids := make([]string, len(stack))
+	for i, id := range stack {
+		ids[i] = strconv.Itoa(id)
+	}
+	key := strings.Join(ids, ",")
+	index, ok := p.stackIndex[key]
+	if !ok {
+		index = len(p.stacks)
+		p.stacks = append(p.stacks, stack)
+		p.stackIndex[key] = index
+	}
+	return index
+}
+
+// Stop stops sampling and sends the profile together with the transaction
+// event it belongs to, which Sentry requires to ingest it. The profile is
+// serialized and sent in the background, tracked by Wait, so that Stop
+// doesn't delay the request it profiled.
+func (p *Profile) Stop() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		sampler.remove(p)
+		p.end = time.Now()
+		p.client.wg.Add(1)
+		go func() {
+			defer p.client.wg.Done()
+			p.client.sendProfile(p)
+		}()
+	})
+}
+
+func (client *Client) sendProfile(p *Profile) {
+	if len(p.samples) == 0 {
+		return
+	}
+	client.mu.RLock()
+	release, environment := client.release, client.environment
+	client.mu.RUnlock()
+
+	transactionID, err := uuid()
+	if err != nil {
+		return
+	}
+	trace := map[string]string{"trace_id": p.traceID, "span_id": p.spanID}
+	if p.op != "" {
+		trace["op"] = p.op
+	}
+	transaction, err := json.Marshal(map[string]interface{}{
+		"type":            "transaction",
+		"event_id":        transactionID,
+		"platform":        "go",
+		"transaction":     p.transaction,
+		"start_timestamp": p.start.UTC().Format(time.RFC3339Nano),
+		"timestamp":       p.end.UTC().Format(time.RFC3339Nano),
+		"release":         release,
+		"environment":     environment,
+		"contexts":        map[string]interface{}{"trace": trace},
+	})
+	if err != nil {
+		client.Logger().Errorf("error marshaling transaction: %v", err)
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"version":     "1",
+		"platform":    "go",
+		"event_id":    p.eventID,
+		"timestamp":   p.start.UTC().Format(time.RFC3339Nano),
+		"release":     release,
+		"environment": environment,
+		"device":      map[string]string{"architecture": runtime.GOARCH},
+		"os":          map[string]string{"name": runtime.GOOS},
+		"runtime":     map[string]string{"name": "go", "version": runtime.Version()},
+		"transaction": map[string]string{
+			"name":             p.transaction,
+			"id":               transactionID,
+			"trace_id":         p.traceID,
+			"active_thread_id": strconv.FormatUint(p.activeID, 10),
+		},
+		"profile": map[string]interface{}{
+			"samples":         p.samples,
+			"stacks":          p.stacks,
+			"frames":          p.frames,
+			"thread_metadata": p.threads,
+		},
+	})
+	if err != nil {
+		client.Logger().Errorf("error marshaling profile: %v", err)
+		return
+	}
+	client.sendEnvelope("profile", transactionID, p.dsc,
+		envelopeItem{Type: "transaction", Payload: transaction},
+		envelopeItem{Type: "profile", Payload: payload})
+}
+
+type goroutineStack struct {
+	id     uint64
+	frames []profileFrame
+}
+
+// parseGoroutines parses the output of runtime.Stack, where each goroutine
+// starts with a "goroutine N [status]:" line followed by pairs of a function
+// line and a tab indented "file:line +0x.." line, leaf first.
+func parseGoroutines(trace []byte) []goroutineStack {
+	var goroutines []goroutineStack
+	for _, block := range bytes.Split(trace, []byte("\n\n")) {
+		lines := strings.Split(strings.TrimSpace(string(block)), "\n")
+		if len(lines) == 0 || !strings.HasPrefix(lines[0], "goroutine ") {
+			continue
+		}
+		var g goroutineStack
+		fields := strings.Fields(lines[0])
+		if len(fields) > 1 {
+			g.id, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+		for i := 1; i+1 < len(lines); i += 2 {
+			function := lines[i]
+			if strings.HasPrefix(function, "created by ") {
+				function = strings.TrimPrefix(function, "created by ")
+				if idx := strings.Index(function, " in goroutine "); idx != -1 {
+					function = function[:idx]
+				}
+			} else if idx := strings.LastIndex(function, "("); idx != -1 {
+				function = function[:idx]
+			}
+			location := strings.TrimSpace(lines[i+1])
+			if idx := strings.LastIndex(location, " +0x"); idx != -1 {
+				location = location[:idx]
+			}
+			file, lineno := location, 0
+			if idx := strings.LastIndex(location, ":"); idx != -1 {
+				file = location[:idx]
+				lineno, _ = strconv.Atoi(location[idx+1:])
+			}
+			frame := profileFrame{AbsPath: file, Filename: trimPath(file), Lineno: lineno}
+			frame.Module, frame.Function = functionName(function)
+			if frame.Module == "runtime" && frame.Function == "goexit" {
+				continue
+			}
+			g.frames = append(g.frames, frame)
+		}
+		goroutines = append(goroutines, g)
+	}
+	return goroutines
+}
+
+// currentGoroutineID parses the id of the calling goroutine from its stack
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	fields := bytes.Fields(buf)
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
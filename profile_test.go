@@ -0,0 +1,173 @@
+package raven
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseGoroutines(t *testing.T) {
+	trace := `goroutine 7 [running]:
+main.work(0x1)
+	/app/main.go:12 +0x1d
+main.main()
+	/app/main.go:5 +0x25
+
+goroutine 9 [chan receive]:
+net/http.(*conn).serve(0xc000)
+	/usr/local/go/src/net/http/server.go:1995 +0x612
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3089 +0x5cb
+`
+	goroutines := parseGoroutines([]byte(trace))
+	if len(goroutines) != 2 {
+		t.Fatalf("expected 2 goroutines, got %d", len(goroutines))
+	}
+	g := goroutines[0]
+	if g.id != 7 || len(g.frames) != 2 {
+		t.Fatalf("incorrect goroutine %+v", g)
+	}
+	if frame := g.frames[0]; frame.Module != "main" || frame.Function != "work" || frame.AbsPath != "/app/main.go" || frame.Lineno != 12 {
+		t.Errorf("incorrect frame %+v", frame)
+	}
+	g = goroutines[1]
+	if g.id != 9 || len(g.frames) != 2 {
+		t.Fatalf("incorrect goroutine %+v", g)
+	}
+	if frame := g.frames[1]; frame.Module != "net/http.(*Server)" || frame.Function != "Serve" || frame.Lineno != 3089 {
+		t.Errorf("incorrect creator frame %+v", frame)
+	}
+}
+
+func TestStartProfile(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client, err := New(strings.Replace(server.URL, "http://", "http://public:secret@", 1) + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile := client.StartProfile("disabled"); profile != nil {
+		t.Error("expected profiling to be disabled by default")
+	}
+	if err := client.SetProfilesSampleRate(1); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := client.StartProfile("GET /")
+	other := client.StartProfile("task")
+	sampler.mu.Lock()
+	if len(sampler.profiles) != 2 || !sampler.running {
+		t.Errorf("expected a single sampler of 2 profiles, got %d", len(sampler.profiles))
+	}
+	sampler.mu.Unlock()
+	time.Sleep(3 * profileInterval)
+	profile.Stop()
+	profile.Stop()
+	other.Stop()
+	client.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 envelopes, got %d", len(bodies))
+	}
+	var body string
+	for _, b := range bodies {
+		if strings.Contains(b, `"name":"GET /"`) {
+			body = b
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 5 || !strings.Contains(lines[1], `"type":"transaction"`) || !strings.Contains(lines[3], `"type":"profile"`) {
+		t.Fatalf("incorrect envelope %q", body)
+	}
+	var transaction struct {
+		EventID     string                       `json:"event_id"`
+		Transaction string                       `json:"transaction"`
+		Contexts    map[string]map[string]string `json:"contexts"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &transaction); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Platform    string            `json:"platform"`
+		Transaction map[string]string `json:"transaction"`
+		Profile     struct {
+			Samples []profileSample `json:"samples"`
+			Stacks  [][]int         `json:"stacks"`
+			Frames  []profileFrame  `json:"frames"`
+		} `json:"profile"`
+	}
+	if err := json.Unmarshal([]byte(lines[4]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Platform != "go" || payload.Transaction["name"] != "GET /" || payload.Transaction["active_thread_id"] == "0" {
+		t.Errorf("incorrect profile %+v", payload.Transaction)
+	}
+	if transaction.Transaction != "GET /" || payload.Transaction["id"] != transaction.EventID || transaction.Contexts["trace"]["trace_id"] != payload.Transaction["trace_id"] {
+		t.Errorf("expected the profile of transaction %+v, got %+v", transaction, payload.Transaction)
+	}
+	if len(payload.Profile.Samples) == 0 || len(payload.Profile.Stacks) == 0 || len(payload.Profile.Frames) == 0 {
+		t.Fatalf("expected samples, got %+v", payload.Profile)
+	}
+	for _, sample := range payload.Profile.Samples {
+		if sample.StackID >= len(payload.Profile.Stacks) {
+			t.Errorf("incorrect stack id %d", sample.StackID)
+		}
+	}
+}
+
+func TestProfileMaxSamples(t *testing.T) {
+	p := &Profile{
+		start:      time.Now(),
+		frameIndex: make(map[string]int),
+		stackIndex: make(map[string]int),
+		threads:    make(map[string]map[string]string),
+	}
+	goroutines := make([]goroutineStack, 1000)
+	for i := range goroutines {
+		goroutines[i] = goroutineStack{id: uint64(i + 1), frames: []profileFrame{{Module: "main", Function: "work"}}}
+	}
+	for i := 0; i < profileMaxSamples/len(goroutines)+2; i++ {
+		p.sample(goroutines, time.Now())
+	}
+	if len(p.samples) != profileMaxSamples {
+		t.Errorf("expected %d samples, got %d", profileMaxSamples, len(p.samples))
+	}
+}
+
+func TestStackSampleMaxSize(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	for i := 0; i < 100; i++ {
+		go func() { <-done }()
+	}
+
+	goroutines, buf := stackSample(make([]byte, 1024), 4096)
+	if len(buf) != 4096 {
+		t.Errorf("expected the buffer to grow to 4096 bytes, got %d", len(buf))
+	}
+	if len(goroutines) == 0 || len(goroutines) >= 100 {
+		t.Fatalf("expected the goroutines fitting in 4096 bytes, got %d", len(goroutines))
+	}
+	for _, g := range goroutines {
+		if g.id == 0 || len(g.frames) == 0 {
+			t.Errorf("incomplete goroutine %+v", g)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package raven
+
+import "time"
+
+// RecordQuery records a query breadcrumb of sql, a database operation on
+// table which took duration, and captures err with CaptureQueryError. The
+// breadcrumb level is error when err is captured. It is the building block
+// of ORM integrations; with GORM it is registered as an after callback of
+// each operation, excluding gorm.ErrRecordNotFound, timed by a before
+// callback:
+//
+//	db.Callback().Query().Before("gorm:query").Register("raven:start", func(db *gorm.DB) {
+//		db.InstanceSet("raven:start", time.Now())
+//	})
+//	db.Callback().Query().After("gorm:query").Register("raven:query", func(db *gorm.DB) {
+//		start, _ := db.InstanceGet("raven:start")
+//		raven.RecordQuery(db.Statement.SQL.String(), db.Statement.Table, "query",
+//			time.Since(start.(time.Time)), db.Error, gorm.ErrRecordNotFound)
+//	})
+func (client *Client) RecordQuery(sql, table, operation string, duration time.Duration, err error, ignored ...error) EventID {
+	breadcrumb := QueryBreadcrumb(sql, duration)
+	breadcrumb.Data["db.operation"] = operation
+	if table != "" {
+		breadcrumb.Data["db.table"] = table
+	}
+	if err != nil && !ignoredError(err, ignored) {
+		breadcrumb.Level = ERROR
+	}
+	client.AddBreadcrumb(breadcrumb)
+	return client.CaptureQueryError(err, table, operation, ignored...)
+}
+
+// CaptureQueryError captures err, the failure of a database operation on
+// table, unless it is or wraps one of ignored, and returns the event id.
+// See RecordQuery to also record the query as breadcrumb.
+func (client *Client) CaptureQueryError(err error, table, operation string, ignored ...error) EventID {
+	if err == nil || ignoredError(err, ignored) {
+		return ""
+	}
+
+	tags := map[string]string{"db.operation": operation}
+	if table != "" {
+		tags["db.table"] = table
+	}
+	return client.CaptureError(err, tags)
+}
+
+// ignoredError tells whether err is or wraps one of ignored
+func ignoredError(err error, ignored []error) bool {
+	for _, ignore := range ignored {
+		if isError(err, ignore) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordQuery records a query and captures its error with the default *Client
+func RecordQuery(sql, table, operation string, duration time.Duration, err error, ignored ...error) EventID {
+	return DefaultClient.RecordQuery(sql, table, operation, duration, err, ignored...)
+}
+
+// CaptureQueryError captures the failure of a database operation with the default *Client
+func CaptureQueryError(err error, table, operation string, ignored ...error) EventID {
+	return DefaultClient.CaptureQueryError(err, table, operation, ignored...)
+}
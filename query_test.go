@@ -0,0 +1,83 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+func TestCaptureQueryError(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	errNotFound := errors.New("record not found")
+	if client.CaptureQueryError(nil, "users", "query") != "" {
+		t.Error("nil errors should not be captured")
+	}
+	if client.CaptureQueryError(pkgErrors.Wrap(errNotFound, "find user"), "users", "query", errNotFound) != "" {
+		t.Error("ignored errors should not be captured")
+	}
+	wrapped := &wrappingError{"load profile", WrapWithExtra(errNotFound, nil)}
+	if client.CaptureQueryError(wrapped, "users", "query", errNotFound) != "" {
+		t.Error("ignored errors wrapped with Unwrap should not be captured")
+	}
+	if client.CaptureQueryError(errors.New("deadlock"), "users", "update", errNotFound) == "" {
+		t.Error("expected an event id")
+	}
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 {
+		t.Fatalf("expected 1 packet, got %d", len(transport.packets))
+	}
+	tags := map[string]string{}
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["db.table"] != "users" || tags["db.operation"] != "update" {
+		t.Errorf("incorrect tags %+v", tags)
+	}
+}
+
+// wrappingError wraps an error like fmt.Errorf with %w does since Go 1.13
+type wrappingError struct {
+	msg string
+	err error
+}
+
+func (e *wrappingError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *wrappingError) Unwrap() error { return e.err }
+
+func TestRecordQuery(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	errNotFound := errors.New("record not found")
+	client.RecordQuery("SELECT * FROM users WHERE id = 1", "users", "query", time.Millisecond, errNotFound, errNotFound)
+	if client.RecordQuery("UPDATE users SET name = 'foo'", "users", "update", time.Second, errors.New("deadlock"), errNotFound) == "" {
+		t.Error("expected an event id")
+	}
+	client.Wait()
+
+	crumbs := client.context.breadcrumbs
+	if len(crumbs) != 2 {
+		t.Fatalf("expected 2 breadcrumbs, got %d", len(crumbs))
+	}
+	if crumbs[0].Category != "query" || crumbs[0].Message != "SELECT * FROM users WHERE id = ?" || crumbs[0].Level != INFO || crumbs[0].Data["db.table"] != "users" {
+		t.Errorf("incorrect breadcrumb of the ignored error %+v", crumbs[0])
+	}
+	if crumbs[1].Level != ERROR || crumbs[1].Data["db.operation"] != "update" {
+		t.Errorf("incorrect breadcrumb of the failed query %+v", crumbs[1])
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 {
+		t.Errorf("expected only the failed query to be captured, got %d packets", len(transport.packets))
+	}
+}
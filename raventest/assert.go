@@ -0,0 +1,99 @@
+package raventest
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/getsentry/raven-go"
+)
+
+// TestingT is the subset of *testing.T used by the assertion helpers
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCapturedError checks that transport recorded an exception event with
+// the given message and at least the given tags, and returns it.
+func AssertCapturedError(t TestingT, transport *Transport, wantMessage string, wantTags map[string]string) *raven.Packet {
+	events := transport.Events()
+	messages := make([]string, 0, len(events))
+	for _, packet := range events {
+		if packet.Message == wantMessage && exception(packet) != nil && hasTags(packet, wantTags) {
+			return packet
+		}
+		messages = append(messages, packet.Message)
+	}
+	t.Errorf("raventest: no error %q with tags %v captured, got %q", wantMessage, wantTags, messages)
+	return nil
+}
+
+// AssertNoEvents checks that transport did not record any event
+func AssertNoEvents(t TestingT, transport *Transport) {
+	if events := transport.Events(); len(events) > 0 {
+		t.Errorf("raventest: expected no events, got %d, first %q", len(events), events[0].Message)
+	}
+}
+
+// AssertFingerprint checks the custom fingerprint of packet
+func AssertFingerprint(t TestingT, packet *raven.Packet, want ...string) {
+	if packet == nil {
+		t.Errorf("raventest: no packet to check fingerprint %q on", want)
+		return
+	}
+	if !reflect.DeepEqual(packet.Fingerprint, want) {
+		t.Errorf("raventest: incorrect fingerprint: got %q, want %q", packet.Fingerprint, want)
+	}
+}
+
+// AssertStackContains checks that the exception stacktrace of packet has a
+// frame of function, given as "Function" or "package/path.Function".
+func AssertStackContains(t TestingT, packet *raven.Packet, function string) {
+	ex := exception(packet)
+	if ex == nil || ex.Stacktrace == nil {
+		t.Errorf("raventest: packet has no exception stacktrace to find %s in", function)
+		return
+	}
+	for _, frame := range ex.Stacktrace.Frames {
+		if frame.Function == function || frame.Module+"."+frame.Function == function ||
+			strings.HasSuffix(frame.Module+"."+frame.Function, "/"+function) {
+			return
+		}
+	}
+	t.Errorf("raventest: no frame of %s in exception stacktrace", function)
+}
+
+// exception returns the first exception interface of packet
+func exception(packet *raven.Packet) *raven.Exception {
+	if packet == nil {
+		return nil
+	}
+	for _, inter := range packet.Interfaces {
+		switch ex := inter.(type) {
+		case *raven.Exception:
+			return ex
+		case *raven.Exceptions:
+			if len(ex.Values) > 0 {
+				return ex.Values[0]
+			}
+		case raven.Exceptions:
+			if len(ex.Values) > 0 {
+				return ex.Values[0]
+			}
+		}
+	}
+	return nil
+}
+
+// hasTags checks that packet carries every tag of want, the last value of a repeated key wins
+func hasTags(packet *raven.Packet, want map[string]string) bool {
+	tags := make(map[string]string, len(packet.Tags))
+	for _, tag := range packet.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	for k, v := range want {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package raventest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func captureFailure() error {
+	return errors.New("boom")
+}
+
+func TestAssertCapturedError(t *testing.T) {
+	client, transport := NewClient()
+	AssertNoEvents(t, transport)
+
+	client.CaptureError(captureFailure(), map[string]string{"handler": "login"})
+	client.Wait()
+
+	packet := AssertCapturedError(t, transport, "boom", map[string]string{"handler": "login"})
+	AssertStackContains(t, packet, "TestAssertCapturedError")
+	AssertStackContains(t, packet, "raventest.TestAssertCapturedError")
+	AssertFingerprint(t, packet)
+
+	rt := &recordingT{}
+	AssertCapturedError(rt, transport, "boom", map[string]string{"handler": "logout"})
+	AssertCapturedError(rt, transport, "other", nil)
+	AssertStackContains(rt, packet, "missing")
+	AssertFingerprint(rt, packet, "custom")
+	AssertNoEvents(rt, transport)
+	if len(rt.errors) != 5 {
+		t.Errorf("expected 5 failed assertions, got %q", rt.errors)
+	}
+}
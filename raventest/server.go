@@ -0,0 +1,165 @@
+package raventest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// PublicKey and ProjectID are part of the DSN accepted by Server
+const (
+	PublicKey = "public"
+	ProjectID = "1"
+)
+
+// Event is an event received by Server
+type Event struct {
+	Header http.Header
+
+	// Body is the decompressed request body
+	Body []byte
+
+	// Envelope is set when the event was sent inside an envelope
+	Envelope bool
+
+	// Data holds the decoded event JSON
+	Data map[string]interface{}
+}
+
+// Server is a fake Sentry server for end-to-end tests of transports. It
+// validates the auth header, decompresses payloads, records events and can
+// be told to fail requests, e.g. with 429 or 5xx statuses.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	events       []Event
+	authFailures int
+	failures     []int
+}
+
+// NewServer starts a Server, it must be closed when done
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// DSN returns a DSN pointing at the server
+func (s *Server) DSN() string {
+	return strings.Replace(s.URL, "://", "://"+PublicKey+"@", 1) + "/" + ProjectID
+}
+
+// FailNext makes the next n requests fail with status
+func (s *Server) FailNext(status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// Events returns the events received so far
+func (s *Server) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// AuthFailures returns the number of requests rejected for a missing or wrong sentry_key
+func (s *Server) AuthFailures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authFailures
+}
+
+// Reset forgets received events, auth failures and pending failures
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events, s.authFailures, s.failures = nil, 0, nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth := r.Header.Get("X-Sentry-Auth")
+	if !strings.HasPrefix(auth, "Sentry ") || !strings.Contains(auth, "sentry_key="+PublicKey) {
+		s.authFailures++
+		http.Error(w, "invalid auth", http.StatusUnauthorized)
+		return
+	}
+
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "60")
+		}
+		w.Header().Set("X-Sentry-Error", http.StatusText(status))
+		w.WriteHeader(status)
+		return
+	}
+
+	body, err := decompress(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event := Event{Header: r.Header, Body: body}
+	payload := body
+	if r.Header.Get("Content-Type") == "application/x-sentry-envelope" {
+		event.Envelope = true
+		if payload, err = envelopeEvent(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := json.Unmarshal(payload, &event.Data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.events = append(s.events, event)
+	fmt.Fprintf(w, `{"id":%q}`, event.Data["event_id"])
+}
+
+func decompress(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	var err error
+	switch r.Header.Get("Content-Encoding") {
+	case "deflate":
+		reader, err = zlib.NewReader(r.Body)
+	case "gzip":
+		reader, err = gzip.NewReader(r.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// envelopeEvent extracts the payload of the event item of an envelope
+func envelopeEvent(body []byte) ([]byte, error) {
+	lines := bytes.Split(body, []byte{'\n'})
+	for i := 1; i+1 < len(lines); i += 2 {
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(lines[i], &header); err != nil {
+			return nil, err
+		}
+		if header.Type == "event" {
+			return lines[i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("envelope has no event item")
+}
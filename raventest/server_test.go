@@ -0,0 +1,42 @@
+package raventest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := raven.New(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetCompressionThreshold(1)
+
+	client.CaptureMessageAndWait("first", nil)
+	server.FailNext(http.StatusTooManyRequests, 1)
+	_, ch := client.Capture(raven.NewPacket("limited"), nil)
+	err = <-ch
+	if transportErr, ok := err.(*raven.TransportError); !ok || transportErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected a 429 TransportError, got %v", err)
+	}
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Data["message"] != "first" {
+		t.Fatalf("incorrect events: %+v", events)
+	}
+	if events[0].Header.Get("Content-Encoding") != "deflate" {
+		t.Error("expected a compressed payload")
+	}
+
+	bad, _ := raven.New(strings.Replace(server.DSN(), PublicKey, "other", 1))
+	bad.CaptureMessageAndWait("unauthorized", nil)
+	if server.AuthFailures() != 1 {
+		t.Errorf("expected 1 auth failure, got %d", server.AuthFailures())
+	}
+}
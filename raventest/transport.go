@@ -0,0 +1,61 @@
+// Package raventest provides helpers to test code reporting to Sentry with
+// raven, without spinning up an HTTP server.
+package raventest
+
+import (
+	"sync"
+
+	"github.com/getsentry/raven-go"
+)
+
+// Transport records every packet sent through it instead of delivering them
+type Transport struct {
+	mu      sync.Mutex
+	packets []*raven.Packet
+}
+
+// NewTransport creates an empty Transport
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// NewClient creates a client delivering to a new Transport. Captures are
+// asynchronous, call client.Wait() before asserting on the transport.
+func NewClient() (*raven.Client, *Transport) {
+	client, _ := raven.New("")
+	transport := NewTransport()
+	client.Transport = transport
+	return client, transport
+}
+
+// Send records a copy of the packet, as pooled packets are reused once sent
+func (t *Transport) Send(url, authHeader string, packet *raven.Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.packets = append(t.packets, packet.Clone())
+	return nil
+}
+
+// Events returns all packets recorded since the last Reset, oldest first
+func (t *Transport) Events() []*raven.Packet {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*raven.Packet(nil), t.packets...)
+}
+
+// LastEvent returns the most recently recorded packet, or nil
+func (t *Transport) LastEvent() *raven.Packet {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.packets) == 0 {
+		return nil
+	}
+	return t.packets[len(t.packets)-1]
+}
+
+// Reset forgets all recorded packets
+func (t *Transport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.packets = nil
+}
@@ -0,0 +1,31 @@
+package raventest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	client, transport := NewClient()
+	if transport.LastEvent() != nil {
+		t.Error("expected no event")
+	}
+
+	client.CaptureMessage("first", nil)
+	client.Wait()
+	client.CaptureError(errors.New("second"), nil)
+	client.Wait()
+
+	events := transport.Events()
+	if len(events) != 2 || events[0].Message != "first" {
+		t.Fatalf("incorrect events: %v", events)
+	}
+	if transport.LastEvent().Message != "second" {
+		t.Errorf("incorrect last event: %s", transport.LastEvent().Message)
+	}
+
+	transport.Reset()
+	if len(transport.Events()) != 0 {
+		t.Error("expected Reset to forget events")
+	}
+}
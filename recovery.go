@@ -0,0 +1,89 @@
+package raven
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RecoveryOptions customize the recovery handler built by RecovererWithOptions
+type RecoveryOptions struct {
+	// User extracts the user of a request, e.g. from its session or JWT,
+	// unless the handler set one with RequestScope.SetUser
+	User func(r *http.Request) *User
+
+	// Tags returns tags added to the event of a request, tags the handler
+	// set with RequestScope.SetTag take precedence
+	Tags func(r *http.Request) map[string]string
+
+	// ErrorResponse writes the response of a request whose handler
+	// panicked, e.g. an error page mentioning the event id for support
+	// requests. By default a plain status 500 is written.
+	ErrorResponse func(w http.ResponseWriter, r *http.Request, eventID EventID)
+
+	// Repanic panics again with the original value once the panic was
+	// captured and the response written, for outer middleware or the server
+	// to handle it.
+	Repanic bool
+}
+
+// RecovererWithOptions wraps handler like Recoverer, customized by options
+func (client *Client) RecovererWithOptions(handler http.Handler, options RecoveryOptions) http.Handler {
+	end := func(scope *RequestScope, rval interface{}) {
+		if rval == nil {
+			return
+		}
+		var user *User
+		var tags map[string]string
+		if options.User != nil {
+			user = options.User(scope.Request)
+		}
+		if options.Tags != nil {
+			tags = options.Tags(scope.Request)
+		}
+
+		scope.mu.Lock()
+		defer scope.mu.Unlock()
+		if scope.User == nil {
+			scope.User = user
+		}
+		if len(tags) > 0 && scope.Tags == nil {
+			scope.Tags = make(map[string]string, len(tags))
+		}
+		for key, value := range tags {
+			if _, ok := scope.Tags[key]; !ok {
+				scope.Tags[key] = value
+			}
+		}
+	}
+	onRecover := func(scope *RequestScope, rval interface{}, eventID EventID) {
+		if scope.Status() == 0 {
+			if options.ErrorResponse != nil {
+				options.ErrorResponse(scope.Writer, scope.Request, eventID)
+			} else {
+				scope.Writer.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+		if options.Repanic {
+			panic(rval)
+		}
+	}
+	return client.WrapHandler(nil, end, onRecover)(handler)
+}
+
+// RecovererWithOptions wraps handler like Recoverer with the default *Client
+func RecovererWithOptions(handler http.Handler, options RecoveryOptions) http.Handler {
+	return DefaultClient.RecovererWithOptions(handler, options)
+}
+
+// EventIDErrorResponse is an ErrorResponse writing a status 500 with a plain
+// text body referencing the event, which users can quote in support requests.
+// The reference is left out when the event was dropped, e.g. sampled out.
+func EventIDErrorResponse(w http.ResponseWriter, r *http.Request, eventID EventID) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	if eventID == "" {
+		fmt.Fprintln(w, "Internal Server Error")
+		return
+	}
+	fmt.Fprintf(w, "Internal Server Error (event %s)\n", eventID)
+}
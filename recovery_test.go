@@ -0,0 +1,90 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecovererWithOptions(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	handler := client.RecovererWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := RequestScopeFromContext(r.Context())
+		scope.SetTag("feature", "search")
+		if r.URL.Path == "/scoped" {
+			scope.SetUser(&User{ID: "scoped"})
+		}
+		panic("boom")
+	}), RecoveryOptions{
+		User: func(r *http.Request) *User {
+			return &User{ID: r.Header.Get("X-User")}
+		},
+		Tags: func(r *http.Request) map[string]string {
+			return map[string]string{"tenant": "acme", "feature": "default"}
+		},
+		ErrorResponse: EventIDErrorResponse,
+		Repanic:       true,
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User", "42")
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if rval := recover(); rval != "boom" {
+				t.Errorf("expected the panic to be repanicked, got %v", rval)
+			}
+		}()
+		handler.ServeHTTP(w, req)
+	}()
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/scoped", nil))
+	}()
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	packet := transport.packets[0]
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), packet.EventID) {
+		t.Errorf("expected the event id in the error response, got %d %q", w.Code, w.Body.String())
+	}
+	var user *User
+	for _, iface := range packet.Interfaces {
+		if u, ok := iface.(*User); ok {
+			user = u
+		}
+	}
+	if user == nil || user.ID != "42" || user.IP != "192.0.2.1" {
+		t.Errorf("incorrect user %+v", user)
+	}
+	tags := map[string]string{}
+	for _, tag := range packet.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["tenant"] != "acme" || tags["feature"] != "search" {
+		t.Errorf("incorrect tags %+v", tags)
+	}
+
+	user = nil
+	for _, iface := range transport.packets[1].Interfaces {
+		if u, ok := iface.(*User); ok {
+			user = u
+		}
+	}
+	if user == nil || user.ID != "scoped" {
+		t.Errorf("expected the user set by the handler, got %+v", user)
+	}
+}
+
+func TestEventIDErrorResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	EventIDErrorResponse(w, httptest.NewRequest("GET", "/", nil), "")
+	if w.Body.String() != "Internal Server Error\n" {
+		t.Errorf("expected no event reference, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+package raven
+
+import "sync"
+
+// registry holds the clients registered by name, see Register
+var registry = struct {
+	sync.RWMutex
+	clients map[string]*Client
+}{clients: map[string]*Client{}}
+
+// Register names client so that subsystems reporting to their own Sentry
+// project can look it up with Get, e.g.
+//
+//	client, _ := raven.New(paymentsDSN)
+//	raven.Register("payments", client)
+//	...
+//	raven.Get("payments").CaptureError(err, nil)
+//
+// Registering a nil client removes name.
+func Register(name string, client *Client) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if client == nil {
+		delete(registry.clients, name)
+		return
+	}
+	registry.clients[name] = client
+}
+
+// Get returns the client registered as name, or DefaultClient if there is
+// none, so that subsystems keep reporting while their client is not set up.
+func Get(name string) *Client {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if client, ok := registry.clients[name]; ok {
+		return client
+	}
+	return DefaultClient
+}
+
+// Registered returns the names of all registered clients
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.clients))
+	for name := range registry.clients {
+		names = append(names, name)
+	}
+	return names
+}
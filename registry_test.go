@@ -0,0 +1,24 @@
+package raven
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	payments := newClient(nil)
+	Register("payments", payments)
+	defer Register("payments", nil)
+
+	if Get("payments") != payments {
+		t.Error("expected the registered client")
+	}
+	if Get("search") != DefaultClient {
+		t.Error("expected the default client for unknown names")
+	}
+	if names := Registered(); len(names) != 1 || names[0] != "payments" {
+		t.Errorf("incorrect names %v", names)
+	}
+
+	Register("payments", nil)
+	if Get("payments") != DefaultClient {
+		t.Error("expected the client to be removed")
+	}
+}
@@ -0,0 +1,49 @@
+package raven
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors of release names
+var (
+	ErrInvalidRelease = errors.New("raven: release must be at most 200 characters without slashes, tabs or newlines and not . , .. or latest")
+	ErrNoBuildInfo    = errors.New("raven: binary built without module build information")
+)
+
+// maxReleaseLength is the longest release name Sentry accepts
+const maxReleaseLength = 200
+
+// ValidateRelease returns ErrInvalidRelease unless Sentry accepts release as
+// the name of a release.
+func ValidateRelease(release string) error {
+	switch strings.TrimSpace(release) {
+	case "", ".", "..", "latest":
+		return ErrInvalidRelease
+	}
+	if len(release) > maxReleaseLength || strings.ContainsAny(release, "/\\\t\n\r") {
+		return ErrInvalidRelease
+	}
+	return nil
+}
+
+// FormatRelease returns the release name of version of pkg built from the
+// VCS revision, formatted as "pkg@version+revision" so that Sentry parses
+// version as semver, e.g. "myapp@1.4.2+0a1b2c3d4e5f". The leading v of the
+// version and all but 12 characters of the revision are dropped, an empty
+// version is replaced by the revision, an empty revision is omitted.
+func FormatRelease(pkg, version, revision string) string {
+	version = strings.TrimPrefix(version, "v")
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	switch {
+	case version == "" && revision == "":
+		return pkg
+	case version == "":
+		return pkg + "@" + revision
+	case revision == "":
+		return pkg + "@" + version
+	}
+	return pkg + "@" + version + "+" + revision
+}
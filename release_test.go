@@ -0,0 +1,45 @@
+package raven
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRelease(t *testing.T) {
+	tests := []struct {
+		version, revision, expected string
+	}{
+		{"v1.4.2", "0a1b2c3d4e5f6a7b8c9d", "myapp@1.4.2+0a1b2c3d4e5f"},
+		{"1.4.2", "", "myapp@1.4.2"},
+		{"", "0a1b2c3d", "myapp@0a1b2c3d"},
+		{"", "", "myapp"},
+	}
+	for _, test := range tests {
+		if release := FormatRelease("myapp", test.version, test.revision); release != test.expected {
+			t.Errorf("%q %q: got %q, want %q", test.version, test.revision, release, test.expected)
+		}
+	}
+}
+
+func TestValidateRelease(t *testing.T) {
+	for _, release := range []string{"myapp@1.4.2+0a1b2c3d4e5f", "721e41770371db95eee98ca2707686226b993eda"} {
+		if err := ValidateRelease(release); err != nil {
+			t.Errorf("%q: unexpected error %v", release, err)
+		}
+	}
+	for _, release := range []string{"", " ", ".", "..", "latest", "feature/login", `feature\login`, "a\tb", strings.Repeat("a", 201)} {
+		if err := ValidateRelease(release); err != ErrInvalidRelease {
+			t.Errorf("%q: expected ErrInvalidRelease, got %v", release, err)
+		}
+	}
+}
+
+func TestReleaseFromBuildInfo(t *testing.T) {
+	release, err := ReleaseFromBuildInfo("raven")
+	if err == ErrNoBuildInfo {
+		t.Skip("no build information")
+	}
+	if err != nil || !strings.HasPrefix(release, "raven") {
+		t.Errorf("incorrect release %q, %v", release, err)
+	}
+}
@@ -0,0 +1,157 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadableConfig is the part of the configuration of a client WatchConfig
+// updates at runtime
+type reloadableConfig struct {
+	DSN         string   `json:"dsn"`
+	SampleRate  *float32 `json:"sample_rate"`
+	Environment *string  `json:"environment"`
+}
+
+// readConfig reads path, either a JSON file with the keys dsn, sample_rate
+// and environment, or a directory holding a file per key, like the mount of
+// a Kubernetes secret. raw is compared to detect changes.
+func readConfig(path string) (config reloadableConfig, raw []byte, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return config, nil, err
+	}
+	if !info.IsDir() {
+		raw, err = ioutil.ReadFile(path)
+		if err != nil {
+			return config, nil, err
+		}
+		return config, raw, json.Unmarshal(raw, &config)
+	}
+
+	read := func(name string) (string, bool, error) {
+		value, err := ioutil.ReadFile(filepath.Join(path, name))
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		raw = append(append(raw, name+"="...), value...)
+		return strings.TrimSpace(string(value)), err == nil, err
+	}
+	if config.DSN, _, err = read("dsn"); err != nil {
+		return config, nil, err
+	}
+	rate, ok, err := read("sample_rate")
+	if err != nil {
+		return config, nil, err
+	}
+	if ok {
+		value, err := strconv.ParseFloat(rate, 32)
+		if err != nil {
+			return config, nil, err
+		}
+		sampleRate := float32(value)
+		config.SampleRate = &sampleRate
+	}
+	environment, ok, err := read("environment")
+	if err != nil {
+		return config, nil, err
+	}
+	if ok {
+		config.Environment = &environment
+	}
+	return config, raw, nil
+}
+
+// applyConfig validates config and updates given client with it at once,
+// so that events are never sent with a mix of old and new settings
+func (client *Client) applyConfig(config reloadableConfig) error {
+	if config.SampleRate != nil && (*config.SampleRate < 0 || *config.SampleRate > 1) {
+		return ErrInvalidSampleRate
+	}
+	if config.DSN != "" {
+		if _, err := ParseDsn(config.DSN); err != nil && !strings.HasPrefix(config.DSN, dryRunScheme+"://") {
+			return err
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if config.DSN != "" {
+		if err := client.setDSN(config.DSN); err != nil {
+			return err
+		}
+	}
+	if config.SampleRate != nil {
+		client.sampleRate = *config.SampleRate
+	}
+	if config.Environment != nil {
+		client.environment = *config.Environment
+	}
+	return nil
+}
+
+// WatchConfig loads the DSN, sample rate and environment of given client
+// from path and checks it for changes every interval, for platforms rotating
+// credentials without restarting processes. path is either a JSON file
+//
+//	{"dsn": "https://public@sentry.example.com/1", "sample_rate": 0.5, "environment": "production"}
+//
+// or a directory with the files dsn, sample_rate and environment, like a
+// mounted Kubernetes secret. Missing keys leave the setting unchanged. An
+// error is returned if path can't be loaded initially, later failures are
+// logged and keep the previous configuration. The watch runs until stop is
+// called. It returns ErrInvalidInterval if interval isn't positive.
+func (client *Client) WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	config, last, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.applyConfig(config); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			config, raw, err := readConfig(path)
+			if err != nil {
+				client.Logger().Errorf("error reloading config from %s: %v", path, err)
+				continue
+			}
+			if bytes.Equal(raw, last) {
+				continue
+			}
+			if err := client.applyConfig(config); err != nil {
+				client.Logger().Errorf("error reloading config from %s: %v", path, err)
+				continue
+			}
+			last = raw
+			client.Logger().Debugf("reloaded config from %s", path)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// WatchConfig loads and watches the configuration of the default *Client from path
+func WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	return DefaultClient.WatchConfig(path, interval)
+}
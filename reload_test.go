@@ -0,0 +1,115 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sentry.json")
+	ioutil.WriteFile(path, []byte(`{"dsn": "https://old@sentry.example.com/1", "sample_rate": 0.5, "environment": "staging"}`), 0600)
+
+	client := newClient(nil)
+	if _, err := client.WatchConfig(path, 0); err != ErrInvalidInterval {
+		t.Errorf("expected ErrInvalidInterval, got %v", err)
+	}
+	stop, err := client.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if client.URL() != "https://sentry.example.com/api/1/store/" || client.sampleRate != 0.5 || client.environment != "staging" {
+		t.Fatalf("config not loaded: %s %v %s", client.URL(), client.sampleRate, client.environment)
+	}
+
+	// an invalid config keeps the previous one
+	ioutil.WriteFile(path, []byte(`{"dsn": "https://new@sentry.example.com/2", "sample_rate": 2}`), 0600)
+	time.Sleep(20 * time.Millisecond)
+	if client.URL() != "https://sentry.example.com/api/1/store/" {
+		t.Errorf("expected the previous dsn, got %s", client.URL())
+	}
+
+	ioutil.WriteFile(path, []byte(`{"dsn": "https://new@sentry.example.com/2"}`), 0600)
+	deadline := time.Now().Add(time.Second)
+	for client.ProjectID() != "2" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.ProjectID() != "2" || client.Dsn().PublicKey != "new" {
+		t.Errorf("dsn not reloaded: %s", client.URL())
+	}
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if client.sampleRate != 0.5 || client.environment != "staging" {
+		t.Errorf("expected missing keys to be unchanged, got %v %s", client.sampleRate, client.environment)
+	}
+}
+
+func TestReadConfigDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "dsn"), []byte("https://public@sentry.example.com/1\n"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "sample_rate"), []byte("0.25"), 0600)
+
+	config, _, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DSN != "https://public@sentry.example.com/1" || config.SampleRate == nil || *config.SampleRate != 0.25 || config.Environment != nil {
+		t.Errorf("incorrect config %+v", config)
+	}
+}
+
+func TestWatchConfigKeepsDryRunOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sentry.json")
+	dsn := "dryrun://" + filepath.Join(dir, "events.json")
+	ioutil.WriteFile(path, []byte(`{"dsn": "`+dsn+`", "sample_rate": 1}`), 0600)
+
+	client := newClient(nil)
+	stop, err := client.WatchConfig(path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	client.mu.RLock()
+	transport := client.Transport
+	client.mu.RUnlock()
+
+	// captures read the sample rate while it is reloaded
+	ioutil.WriteFile(path, []byte(`{"dsn": "`+dsn+`", "sample_rate": 0.99}`), 0600)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		client.Capture(NewPacket("reloading"), nil)
+		client.mu.RLock()
+		reloaded := client.sampleRate != 1
+		client.mu.RUnlock()
+		if reloaded {
+			break
+		}
+	}
+	client.Wait()
+
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if client.sampleRate != 0.99 {
+		t.Fatalf("sample rate not reloaded: %v", client.sampleRate)
+	}
+	if client.Transport != transport {
+		t.Error("expected the unchanged dry run DSN to keep its output open")
+	}
+}
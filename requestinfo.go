@@ -0,0 +1,116 @@
+package raven
+
+import (
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestInfo describes an incoming request independently of the server
+// stack, so that integrations for fasthttp, gRPC gateways or custom
+// protocols populate the Http interface with the same filtering and
+// scrubbing as NewHttp, see NewHttpFromRequestInfo.
+type RequestInfo interface {
+	Method() string
+
+	// URL is absolute, including scheme and host
+	URL() *url.URL
+
+	Headers() http.Header
+	RemoteAddr() string
+
+	// Body returns the captured request body, nil when it wasn't captured
+	Body() []byte
+}
+
+// truncatedBody is implemented by RequestInfo adapters whose body may be
+// just the prefix of the request body.
+type truncatedBody interface {
+	bodyTruncated() bool
+}
+
+// NewRequestInfo creates a RequestInfo from its parts, e.g. for stacks that
+// don't use net/http.
+func NewRequestInfo(method string, u *url.URL, headers http.Header, remoteAddr string, body []byte) RequestInfo {
+	return &requestInfo{method: method, url: u, headers: headers, remoteAddr: remoteAddr, body: body}
+}
+
+type requestInfo struct {
+	method     string
+	url        *url.URL
+	headers    http.Header
+	remoteAddr string
+	body       []byte
+	truncated  bool
+}
+
+func (r *requestInfo) Method() string       { return r.method }
+func (r *requestInfo) URL() *url.URL        { return r.url }
+func (r *requestInfo) Headers() http.Header { return r.headers }
+func (r *requestInfo) RemoteAddr() string   { return r.remoteAddr }
+func (r *requestInfo) Body() []byte         { return r.body }
+func (r *requestInfo) bodyTruncated() bool  { return r.truncated }
+
+// HTTPRequestInfo adapts a net/http request, its body is the one recorded by
+// Client.RecordRequestBody.
+func HTTPRequestInfo(req *http.Request) RequestInfo {
+	u := *req.URL
+	u.Scheme = "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		u.Scheme = "https"
+	}
+	u.Host = req.Host
+
+	info := &requestInfo{method: req.Method, url: &u, headers: req.Header, remoteAddr: req.RemoteAddr}
+	if body := recordedBody(req); body != nil {
+		info.body, info.truncated = body.data, body.truncated
+	}
+	return info
+}
+
+// NewHttpFromRequestInfo creates a new Http interface from info, filtered and
+// scrubbed like NewHttp.
+func (client *Client) NewHttpFromRequestInfo(info RequestInfo) *Http {
+	filter := client.httpFilter()
+	u, headers := info.URL(), info.Headers()
+
+	h := &Http{
+		Method:  info.Method(),
+		Query:   filter.query(u.Query()).Encode(),
+		URL:     u.Scheme + "://" + u.Host + u.Path,
+		Headers: make(map[string]string, len(headers)),
+	}
+	if data := info.Body(); data != nil {
+		body := &requestBody{data: data}
+		body.contentType, _, _ = mime.ParseMediaType(headers.Get("Content-Type"))
+		if t, ok := info.(truncatedBody); ok {
+			body.truncated = t.bodyTruncated()
+		}
+		h.Data = httpData(body)
+	}
+	if addr, port, err := net.SplitHostPort(info.RemoteAddr()); err == nil {
+		h.Env = map[string]string{"REMOTE_ADDR": addr, "REMOTE_PORT": port}
+	}
+	for k, v := range headers {
+		if filter.header(k) {
+			h.Headers[k] = strings.Join(v, ",")
+		}
+	}
+	if filter.header("Cookie") {
+		h.Cookies = filter.cookies(&http.Request{Header: headers})
+		if h.Cookies != "" {
+			h.Headers["Cookie"] = h.Cookies
+		} else {
+			delete(h.Headers, "Cookie")
+		}
+	}
+	h.Headers["Host"] = u.Host
+	return h
+}
+
+// NewHttpFromRequestInfo creates a new Http interface from info with the filters of the default *Client
+func NewHttpFromRequestInfo(info RequestInfo) *Http {
+	return DefaultClient.NewHttpFromRequestInfo(info)
+}
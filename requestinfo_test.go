@@ -0,0 +1,32 @@
+package raven
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestNewHttpFromRequestInfo(t *testing.T) {
+	client := newClient(nil)
+	u, _ := url.Parse("https://example.com/orders?page=1&secret=abc")
+	headers := http.Header{
+		"Content-Type":  {"application/json"},
+		"Authorization": {"Bearer token"},
+		"Foo":           {"bar"},
+	}
+	info := NewRequestInfo("POST", u, headers, "127.0.0.1:8000", []byte(`{"password":"foo","id":1}`))
+
+	h := client.NewHttpFromRequestInfo(info)
+	expected := &Http{
+		Method:  "POST",
+		URL:     "https://example.com/orders",
+		Query:   "page=1&secret=%2A%2A%2A%2A%2A%2A%2A%2A",
+		Headers: map[string]string{"Content-Type": "application/json", "Foo": "bar", "Host": "example.com"},
+		Env:     map[string]string{"REMOTE_ADDR": "127.0.0.1", "REMOTE_PORT": "8000"},
+		Data:    `{"id":1,"password":"********"}`,
+	}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("incorrect Http: got %+v, want %+v", h, expected)
+	}
+}
@@ -0,0 +1,124 @@
+package raven
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	responseRecorderKey struct{}
+	transactionKey      struct{}
+)
+
+// responseRecorder wraps the ResponseWriter of a request handled by
+// Recoverer to record the status code and the number of bytes written.
+type responseRecorder struct {
+	http.ResponseWriter
+	start  time.Time
+	status int
+	size   int
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped ResponseWriter does
+func (w *responseRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the wrapped ResponseWriter does
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("raven: ResponseWriter does not implement http.Hijacker")
+	}
+	return hijacker.Hijack()
+}
+
+// recordResponse wraps w and stores the recorder in the context of the returned request
+func recordResponse(w http.ResponseWriter, r *http.Request) (*responseRecorder, *http.Request) {
+	recorder := &responseRecorder{ResponseWriter: w, start: time.Now()}
+	return recorder, r.WithContext(context.WithValue(r.Context(), responseRecorderKey{}, recorder))
+}
+
+// ResponseTags returns the status code, bytes written and duration so far of
+// the response to req, which must be handled by Recoverer, as tags for
+// events captured during the request, e.g.
+//
+//	raven.CaptureError(err, raven.ResponseTags(r))
+//
+// The status code is omitted while the handler didn't write the header.
+// Events captured through the RequestScope of the request carry them already.
+func ResponseTags(req *http.Request) map[string]string {
+	recorder, ok := req.Context().Value(responseRecorderKey{}).(*responseRecorder)
+	if !ok {
+		return nil
+	}
+	tags := map[string]string{
+		"http.response_size": strconv.Itoa(recorder.size),
+		"http.duration_ms":   strconv.FormatInt(int64(time.Since(recorder.start)/time.Millisecond), 10),
+	}
+	if recorder.status != 0 {
+		tags["http.status_code"] = strconv.Itoa(recorder.status)
+	}
+	return tags
+}
+
+// RequestTiming returns when the handling of req, which must be handled by
+// Recoverer, started and its duration so far, for Packet.SetTiming, e.g.
+//
+//	packet.SetTiming(raven.RequestTiming(r))
+//
+// Both are zero for other requests.
+func RequestTiming(req *http.Request) (start time.Time, duration time.Duration) {
+	recorder, ok := req.Context().Value(responseRecorderKey{}).(*responseRecorder)
+	if !ok {
+		return time.Time{}, 0
+	}
+	return recorder.start, time.Since(recorder.start)
+}
+
+// withTransaction stores the transaction of r, METHOD /path until a router
+// names it after its route with SetRequestTransaction, in its context.
+func withTransaction(r *http.Request) *http.Request {
+	name := r.Method + " " + r.URL.Path
+	return r.WithContext(context.WithValue(r.Context(), transactionKey{}, &name))
+}
+
+// SetRequestTransaction names the transaction of req, which must be handled
+// by Recoverer, after its route, e.g. "GET /users/:id", to group events by
+// route instead of by path.
+func SetRequestTransaction(req *http.Request, name string) {
+	if transaction, ok := req.Context().Value(transactionKey{}).(*string); ok {
+		*transaction = name
+	}
+}
+
+// RequestTransaction returns the transaction of req, which must be handled by Recoverer
+func RequestTransaction(req *http.Request) string {
+	if transaction, ok := req.Context().Value(transactionKey{}).(*string); ok {
+		return *transaction
+	}
+	return ""
+}
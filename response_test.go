@@ -0,0 +1,110 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestResponseTags(t *testing.T) {
+	var tags map[string]string
+	handler := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello"))
+		w.(http.Flusher).Flush()
+		tags = ResponseTags(r)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusAccepted || !w.Flushed {
+		t.Errorf("response was not passed through: %d %v", w.Code, w.Flushed)
+	}
+	if tags["http.status_code"] != "202" || tags["http.response_size"] != "5" || tags["http.duration_ms"] == "" {
+		t.Errorf("incorrect response tags: %+v", tags)
+	}
+	if ResponseTags(httptest.NewRequest("GET", "/", nil)) != nil {
+		t.Error("expected no tags outside of Recoverer")
+	}
+}
+
+func TestRecovererDoesNotOverrideStatus(t *testing.T) {
+	handler := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		panic("after header")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected the written status to be kept, got %d", w.Code)
+	}
+}
+
+// packetTransport keeps the packets it was given
+type packetTransport struct {
+	mu      sync.Mutex
+	packets []*Packet
+}
+
+func (t *packetTransport) Send(url, authHeader string, packet *Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.packets = append(t.packets, packet)
+	return nil
+}
+
+func TestRequestTransaction(t *testing.T) {
+	// packets of other tests must not reach transport
+	DefaultClient.Wait()
+	transport := &packetTransport{}
+	DefaultClient.mu.Lock()
+	previous := DefaultClient.Transport
+	DefaultClient.Transport = transport
+	DefaultClient.mu.Unlock()
+	defer func() {
+		DefaultClient.mu.Lock()
+		DefaultClient.Transport = previous
+		DefaultClient.mu.Unlock()
+	}()
+
+	handler := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/42" {
+			SetRequestTransaction(r, "GET /users/:id")
+		}
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+	DefaultClient.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	for i, expected := range []string{"GET /users/:id", "POST /orders"} {
+		packet := transport.packets[i]
+		if packet.Transaction != expected || packet.Culprit != expected {
+			t.Errorf("expected transaction and culprit %q, got %q and %q", expected, packet.Transaction, packet.Culprit)
+		}
+	}
+}
+
+func TestSetTransaction(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+	client.SetTransactionContext("worker.process")
+
+	packet := NewPacket("foo")
+	_, ch := client.Capture(packet, nil)
+	<-ch
+	if packet.Transaction != "worker.process" {
+		t.Errorf("expected the client transaction, got %q", packet.Transaction)
+	}
+
+	client.ClearContext()
+	packet = NewPacket("foo")
+	_, ch = client.Capture(packet, nil)
+	<-ch
+	if packet.Transaction != "" {
+		t.Errorf("expected no transaction after ClearContext, got %q", packet.Transaction)
+	}
+}
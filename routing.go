@@ -0,0 +1,150 @@
+package raven
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// RouteRule selects the packets a RoutingTransport sends to the DSN of its
+// route. All fields set must match, a rule without fields matches all packets.
+type RouteRule struct {
+	// Logger is the exact logger name of the packet
+	Logger string
+
+	// PackagePrefix is a prefix of the package of the innermost in app frame
+	// of the stacktrace of the packet, e.g. "github.com/acme/app/payments"
+	PackagePrefix string
+
+	// TagKey and TagValue match a tag of the packet, any value when TagValue is empty
+	TagKey   string
+	TagValue string
+}
+
+func (r RouteRule) matches(packet *Packet) bool {
+	if r.Logger != "" && packet.Logger != r.Logger {
+		return false
+	}
+	if r.PackagePrefix != "" && !strings.HasPrefix(topInAppModule(packet), r.PackagePrefix) {
+		return false
+	}
+	if r.TagKey != "" {
+		for _, tag := range packet.Tags {
+			if tag.Key == r.TagKey && (r.TagValue == "" || tag.Value == r.TagValue) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// topInAppModule returns the package of the innermost in app frame of the
+// stacktrace of packet, frames are ordered from outermost to innermost. The
+// stacktraces of chained exceptions are searched from the innermost cause.
+func topInAppModule(packet *Packet) string {
+	for _, inter := range packet.Interfaces {
+		var stacktraces []*Stacktrace
+		switch i := inter.(type) {
+		case *Exception:
+			stacktraces = append(stacktraces, i.Stacktrace)
+		case Exceptions:
+			for _, exception := range i.Values {
+				stacktraces = append(stacktraces, exception.Stacktrace)
+			}
+		case *Stacktrace:
+			stacktraces = append(stacktraces, i)
+		}
+		for _, stacktrace := range stacktraces {
+			if stacktrace == nil {
+				continue
+			}
+			for i := len(stacktrace.Frames) - 1; i >= 0; i-- {
+				if frame := stacktrace.Frames[i]; frame != nil && frame.InApp {
+					return frame.Module
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// RoutingTransport sends every packet to the DSN of the first route whose
+// rule matches it and to the client's own DSN otherwise, so that a single
+// binary can report to the projects of the teams owning its parts.
+type RoutingTransport struct {
+	// Transport performs the actual delivery
+	Transport Transport
+
+	mu     sync.RWMutex
+	routes []route
+}
+
+type route struct {
+	rule       RouteRule
+	url        string
+	authHeader string
+}
+
+// NewRoutingTransport creates a RoutingTransport delivering through transport
+func NewRoutingTransport(transport Transport) *RoutingTransport {
+	return &RoutingTransport{Transport: transport}
+}
+
+// AddRoute sends the packets matching rule, unless an earlier route matches
+// them, to dsn
+func (t *RoutingTransport) AddRoute(rule RouteRule, dsn string) error {
+	d, err := ParseDsn(dsn)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.routes = append(t.routes, route{rule, d.StoreAPIURL(), d.AuthHeader()})
+	return nil
+}
+
+// Unwrap returns the transport delivering to the routes
+func (t *RoutingTransport) Unwrap() Transport { return t.Transport }
+
+// Send delivers packet to the DSN of its route
+func (t *RoutingTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx
+func (t *RoutingTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	t.mu.RLock()
+	for _, route := range t.routes {
+		if route.rule.matches(packet) {
+			url, authHeader = route.url, route.authHeader
+			break
+		}
+	}
+	t.mu.RUnlock()
+	return AsTransportV2(t.Transport).SendWithContext(ctx, url, authHeader, packet)
+}
+
+// AddRoute wraps the transport of given client in a RoutingTransport, unless
+// it already is or wraps one, and adds a route of the packets matching rule
+// to dsn.
+func (client *Client) AddRoute(rule RouteRule, dsn string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if t, ok := findTransport(client.Transport, func(t Transport) bool {
+		_, ok := t.(*RoutingTransport)
+		return ok
+	}).(*RoutingTransport); ok {
+		return t.AddRoute(rule, dsn)
+	}
+	t := NewRoutingTransport(client.Transport)
+	if err := t.AddRoute(rule, dsn); err != nil {
+		return err
+	}
+	client.Transport = t
+	return nil
+}
+
+// AddRoute adds a route of the packets matching rule to dsn to the default *Client
+func AddRoute(rule RouteRule, dsn string) error { return DefaultClient.AddRoute(rule, dsn) }
@@ -0,0 +1,63 @@
+package raven
+
+import "testing"
+
+func TestRoutingTransport(t *testing.T) {
+	recorder := &recordingTransport{}
+	transport := NewRoutingTransport(recorder)
+	transport.AddRoute(RouteRule{Logger: "billing"}, "https://public@sentry.example.com/1")
+	transport.AddRoute(RouteRule{PackagePrefix: "github.com/acme/app/search"}, "https://public@sentry.example.com/2")
+	transport.AddRoute(RouteRule{TagKey: "team", TagValue: "infra"}, "https://public@sentry.example.com/3")
+	if err := transport.AddRoute(RouteRule{}, "https://sentry.example.com/4"); err != ErrMissingUser {
+		t.Errorf("expected an invalid dsn to be rejected, got %v", err)
+	}
+
+	search := &Stacktrace{Frames: []*StacktraceFrame{
+		{Module: "main", InApp: true},
+		{Module: "github.com/acme/app/search/index", InApp: true},
+		{Module: "net/http", InApp: false},
+	}}
+	wrapper := &Stacktrace{Frames: []*StacktraceFrame{{Module: "main", InApp: true}}}
+	packets := []*Packet{
+		{Logger: "billing", Tags: Tags{{"team", "infra"}}},
+		{Logger: "root", Interfaces: []Interface{&Exception{Stacktrace: search}}},
+		// chained exceptions are ordered from the innermost cause
+		{Logger: "root", Interfaces: []Interface{Exceptions{Values: []*Exception{{Stacktrace: search}, {Stacktrace: wrapper}}}}},
+		{Logger: "root", Tags: Tags{{"team", "infra"}}},
+		{Logger: "root", Tags: Tags{{"team", "web"}}},
+	}
+	for _, packet := range packets {
+		transport.Send("https://sentry.example.com/api/0/store/", "", packet)
+	}
+
+	expected := []string{
+		"https://sentry.example.com/api/1/store/",
+		"https://sentry.example.com/api/2/store/",
+		"https://sentry.example.com/api/2/store/",
+		"https://sentry.example.com/api/3/store/",
+		"https://sentry.example.com/api/0/store/",
+	}
+	if len(recorder.urls) != len(expected) {
+		t.Fatalf("expected %d packets, got %v", len(expected), recorder.urls)
+	}
+	for i, url := range expected {
+		if recorder.urls[i] != url {
+			t.Errorf("packet %d: expected %s, got %s", i, url, recorder.urls[i])
+		}
+	}
+}
+
+func TestClientAddRoute(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+	client.AddRoute(RouteRule{Logger: "billing"}, "https://public@sentry.example.com/1")
+	client.AddRoute(RouteRule{Logger: "search"}, "https://public@sentry.example.com/2")
+
+	t2, ok := client.Transport.(*RoutingTransport)
+	if !ok || len(t2.routes) != 2 {
+		t.Fatalf("expected a single routing transport with 2 routes, got %#v", client.Transport)
+	}
+	if _, ok := t2.Transport.(*recordingTransport); !ok {
+		t.Error("expected the previous transport to be wrapped")
+	}
+}
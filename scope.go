@@ -0,0 +1,67 @@
+package raven
+
+// Scope overrides the context of a single capture, see CaptureWithScope
+type Scope struct {
+	// User replaces the user of the packet
+	User *User
+
+	// Tags are added to the tags of the packet and the client
+	Tags map[string]string
+
+	// Level, Fingerprint and Transaction replace the ones of the packet when set
+	Level       Severity
+	Fingerprint []string
+	Transaction string
+
+	// Extra is merged into the extra of the packet
+	Extra Extra
+}
+
+// CaptureWithScope captures packet like Capture after configure set the user,
+// tags, level or fingerprint of this capture only on scope. Unlike
+// SetUserContext and SetTagsContext followed by ClearContext, it doesn't
+// touch the context of the client shared with concurrent captures.
+func (client *Client) CaptureWithScope(packet *Packet, configure func(scope *Scope)) (eventID EventID, ch chan error) {
+	if packet == nil || configure == nil {
+		return client.Capture(packet, nil)
+	}
+	scope := &Scope{}
+	configure(scope)
+	scope.apply(packet)
+	return client.Capture(packet, scope.Tags)
+}
+
+// CaptureWithScope captures packet with a scope configured for this capture only with the default *Client
+func CaptureWithScope(packet *Packet, configure func(scope *Scope)) (eventID EventID, ch chan error) {
+	return DefaultClient.CaptureWithScope(packet, configure)
+}
+
+// apply sets the fields of scope on packet, except the tags passed to Capture
+func (scope *Scope) apply(packet *Packet) {
+	if scope.User != nil {
+		interfaces := make([]Interface, 0, len(packet.Interfaces)+1)
+		for _, inter := range packet.Interfaces {
+			if _, ok := inter.(*User); !ok {
+				interfaces = append(interfaces, inter)
+			}
+		}
+		packet.Interfaces = append(interfaces, scope.User)
+	}
+	if scope.Level != "" {
+		packet.Level = scope.Level
+	}
+	if scope.Fingerprint != nil {
+		packet.Fingerprint = scope.Fingerprint
+	}
+	if scope.Transaction != "" {
+		packet.Transaction = scope.Transaction
+	}
+	if len(scope.Extra) > 0 {
+		if packet.Extra == nil {
+			packet.Extra = Extra{}
+		}
+		for key, value := range scope.Extra {
+			packet.Extra[key] = value
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package raven
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCaptureWithScope(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetUserContext(&User{ID: "shared"})
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			packet := NewPacket("checkout failed", &User{ID: "stale"})
+			_, ch := client.CaptureWithScope(packet, func(scope *Scope) {
+				scope.User = &User{ID: id}
+				scope.Tags = map[string]string{"order": id}
+				scope.Level = WARNING
+				scope.Fingerprint = []string{"checkout"}
+				scope.Extra = Extra{"attempt": 2}
+			})
+			<-ch
+		}(id)
+	}
+	wg.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	for _, packet := range transport.packets {
+		var users []*User
+		for _, inter := range packet.Interfaces {
+			if user, ok := inter.(*User); ok {
+				users = append(users, user)
+			}
+		}
+		var order string
+		for _, tag := range packet.Tags {
+			if tag.Key == "order" {
+				order = tag.Value
+			}
+		}
+		if len(users) != 1 || users[0].ID != order {
+			t.Errorf("expected the user of the scope, got %v for order %q", users, order)
+		}
+		if packet.Level != WARNING || !reflect.DeepEqual(packet.Fingerprint, []string{"checkout"}) || packet.Extra["attempt"] != 2 {
+			t.Errorf("scope was not applied: %+v", packet)
+		}
+	}
+	if client.context.user.ID != "shared" {
+		t.Errorf("expected the context of the client to be untouched, got %v", client.context.user)
+	}
+}
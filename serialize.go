@@ -0,0 +1,157 @@
+package raven
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// serializedPacket streams the packet JSON, or an event envelope when the
+// packet is sent as one, see sendsEnvelope, into the returned body together
+// with its Content-Type and Content-Encoding. It's compressed
+// when it is bigger than threshold bytes. The first threshold bytes are
+// buffered to pick the Content-Encoding, everything after that flows straight
+// through the compressor into the request, so large events are never held in
+// memory as a whole. The body has no known length and is sent chunked, it
+// must be closed before the packet is released, see packetBody.
+func serializedPacket(packet *Packet, compression Compression, level, threshold int) (io.ReadCloser, string, string, error) {
+	if compression == "" {
+		compression = CompressionDeflate
+	}
+	if level == 0 {
+		level = zlib.BestCompression
+	}
+	// Only compress the packet if it is bigger than 1KB by default, as there is an overhead
+	if threshold == 0 {
+		threshold = defaultCompressionThreshold
+	} else if threshold < 0 {
+		threshold = 0
+	}
+
+	switch compression {
+	case CompressionNone, CompressionDeflate, CompressionGzip:
+	default:
+		return nil, "", "", fmt.Errorf("raven: unknown compression %q", compression)
+	}
+	if level < zlib.HuffmanOnly || level > zlib.BestCompression {
+		return nil, "", "", fmt.Errorf("raven: invalid compression level %d", level)
+	}
+
+	pr, pw := io.Pipe()
+	sw := &switchWriter{
+		pipe:        pw,
+		compression: compression,
+		level:       level,
+		threshold:   threshold,
+		decided:     make(chan string, 1),
+	}
+	if compression == CompressionNone {
+		sw.decide("")
+		sw.out = pw
+	}
+
+	write := packet.writeJSON
+	if packet.sendsEnvelope() {
+		write = packet.writeEnvelope
+	}
+	body := &packetBody{PipeReader: pr, done: make(chan struct{})}
+	failed := make(chan error, 1)
+	go func() {
+		defer close(body.done)
+		err := write(sw)
+		if err == nil {
+			err = sw.Close()
+		}
+		if err != nil {
+			failed <- err
+		}
+		pw.CloseWithError(err)
+	}()
+
+	select {
+	case encoding := <-sw.decided:
+		switch {
+		case packet.sendsEnvelope():
+			return body, envelopeContentType, encoding, nil
+		case encoding == "":
+			return body, "application/json", "", nil
+		}
+		return body, "application/octet-stream", encoding, nil
+	case err := <-failed:
+		body.Close()
+		return nil, "", "", fmt.Errorf("raven: error marshaling packet %+v to JSON: %v", packet, err)
+	}
+}
+
+// switchWriter buffers up to threshold bytes, then switches to compressing
+// everything into pipe. The chosen Content-Encoding is sent on decided
+// before anything is written to pipe.
+type switchWriter struct {
+	pipe        io.Writer
+	compression Compression
+	level       int
+	threshold   int
+
+	head       bytes.Buffer
+	out        io.Writer
+	compressor io.WriteCloser
+	decided    chan string
+}
+
+func (w *switchWriter) decide(encoding string) {
+	w.decided <- encoding
+}
+
+func (w *switchWriter) Write(p []byte) (int, error) {
+	if w.out != nil {
+		return w.out.Write(p)
+	}
+
+	w.head.Write(p)
+	if w.head.Len() <= w.threshold {
+		return len(p), nil
+	}
+
+	w.decide(string(w.compression))
+	if w.compression == CompressionGzip {
+		w.compressor, _ = gzip.NewWriterLevel(w.pipe, w.level)
+	} else {
+		w.compressor, _ = zlib.NewWriterLevel(w.pipe, w.level)
+	}
+	w.out = w.compressor
+	if _, err := w.head.WriteTo(w.out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close flushes the buffered head uncompressed if the threshold was never
+// reached, or finishes the compressed stream.
+func (w *switchWriter) Close() error {
+	if w.out == nil {
+		w.decide("")
+		_, err := w.head.WriteTo(w.pipe)
+		return err
+	}
+	if w.compressor != nil {
+		return w.compressor.Close()
+	}
+	return nil
+}
+
+// packetBody is the body streaming a packet encoded by a goroutine
+type packetBody struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+// Close stops the encoding of the packet and waits for the goroutine, so that
+// the packet isn't read anymore once Close returned, e.g. after a request
+// failed before reading the whole body. It may be called several times.
+func (b *packetBody) Close() error {
+	err := b.PipeReader.Close()
+	<-b.done
+	return err
+}
@@ -0,0 +1,74 @@
+package raven
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// serverlessRuntime describes the function platform the process runs on
+type serverlessRuntime struct {
+	platform string
+	function string
+}
+
+// detectServerless recognizes AWS Lambda, Google Cloud Functions and Cloud
+// Run from the environment variables they set, or returns nil.
+func detectServerless() *serverlessRuntime {
+	if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+		return &serverlessRuntime{platform: "aws_lambda", function: name}
+	}
+	if name := os.Getenv("FUNCTION_TARGET"); name != "" {
+		return &serverlessRuntime{platform: "gcp_functions", function: name}
+	}
+	// Cloud Functions before the Node.js 10 runtime generation
+	if name := os.Getenv("FUNCTION_NAME"); name != "" && os.Getenv("GCP_PROJECT") != "" {
+		return &serverlessRuntime{platform: "gcp_functions", function: name}
+	}
+	if name := os.Getenv("K_SERVICE"); name != "" {
+		return &serverlessRuntime{platform: "gcp_cloud_run", function: name}
+	}
+	return nil
+}
+
+// setServerless configures given client for a function runtime: the
+// process may be frozen as soon as a request was handled, so packets are
+// sent synchronously, the function name replaces the meaningless hostname as
+// server name and the first packet of each instance is tagged as cold start.
+func (client *Client) setServerless(serverless *serverlessRuntime) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.synchronous = true
+	client.serverName = serverless.function
+	client.serverless = serverless.platform
+}
+
+// serverlessTags returns the tags of packets captured on a function runtime
+func (client *Client) serverlessTags(platform string) map[string]string {
+	coldStart := "false"
+	if atomic.CompareAndSwapUint32(&client.coldStarted, 0, 1) {
+		coldStart = "true"
+	}
+	return map[string]string{"serverless": platform, "cold_start": coldStart}
+}
+
+// SetSynchronous makes Capture of given client wait until the packet was
+// sent, e.g. on function runtimes freezing the process between requests.
+// It is enabled automatically on AWS Lambda, Cloud Functions and Cloud Run.
+func (client *Client) SetSynchronous(synchronous bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.synchronous = synchronous
+}
+
+// SetSynchronous makes Capture of the default *Client wait until the packet was sent
+func SetSynchronous(synchronous bool) { DefaultClient.SetSynchronous(synchronous) }
+
+// SetServerName overrides the hostname sent as server name by given client
+func (client *Client) SetServerName(name string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.serverName = name
+}
+
+// SetServerName overrides the hostname sent as server name by the default *Client
+func SetServerName(name string) { DefaultClient.SetServerName(name) }
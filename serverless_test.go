@@ -0,0 +1,75 @@
+package raven
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetectServerless(t *testing.T) {
+	keys := []string{"AWS_LAMBDA_FUNCTION_NAME", "FUNCTION_TARGET", "FUNCTION_NAME", "GCP_PROJECT", "K_SERVICE"}
+	for _, key := range keys {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+
+	testCases := []struct {
+		Env      map[string]string
+		Expected *serverlessRuntime
+	}{
+		{map[string]string{}, nil},
+		{map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "checkout"}, &serverlessRuntime{"aws_lambda", "checkout"}},
+		{map[string]string{"FUNCTION_TARGET": "HandleCheckout", "K_SERVICE": "checkout"}, &serverlessRuntime{"gcp_functions", "HandleCheckout"}},
+		{map[string]string{"FUNCTION_NAME": "checkout"}, nil},
+		{map[string]string{"K_SERVICE": "checkout"}, &serverlessRuntime{"gcp_cloud_run", "checkout"}},
+	}
+	for i, test := range testCases {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+		for key, value := range test.Env {
+			os.Setenv(key, value)
+		}
+		runtime := detectServerless()
+		if (runtime == nil) != (test.Expected == nil) || runtime != nil && *runtime != *test.Expected {
+			t.Errorf("Case [%d]: expected %+v, got %+v", i, test.Expected, runtime)
+		}
+	}
+}
+
+func TestServerlessClient(t *testing.T) {
+	client := newClient(nil)
+	transport := &recordingTransport{}
+	client.Transport = transport
+	client.setServerless(&serverlessRuntime{"aws_lambda", "checkout"})
+
+	var packets []*Packet
+	for i := 0; i < 2; i++ {
+		packet := NewPacket("foo")
+		_, ch := client.Capture(packet, nil)
+		// synchronous captures were sent before returning
+		transport.mu.Lock()
+		sent := len(transport.urls)
+		transport.mu.Unlock()
+		if sent != i+1 {
+			t.Errorf("expected %d sent packets, got %d", i+1, sent)
+		}
+		if err := <-ch; err != nil {
+			t.Error(err)
+		}
+		packets = append(packets, packet)
+	}
+
+	for i, coldStart := range []string{"true", "false"} {
+		packet := packets[i]
+		if packet.ServerName != "checkout" {
+			t.Errorf("expected function name as server name, got %q", packet.ServerName)
+		}
+		tags := map[string]string{}
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["cold_start"] != coldStart || tags["serverless"] != "aws_lambda" {
+			t.Errorf("incorrect tags of packet %d: %+v", i, packet.Tags)
+		}
+	}
+}
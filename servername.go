@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Resolvers of FQDN, replaced in tests
+var (
+	lookupHost = net.LookupHost
+	lookupAddr = net.LookupAddr
+)
+
+// FQDN returns the fully qualified domain name of the host, resolving the
+// addresses of its hostname back to names. Container hostnames are often
+// random, while their FQDN names the service.
+func FQDN() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	return fqdn(host)
+}
+
+func fqdn(host string) (string, error) {
+	if strings.Contains(host, ".") {
+		return host, nil
+	}
+	addrs, err := lookupHost(host)
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		names, err := lookupAddr(addr)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if name = strings.TrimSuffix(name, "."); strings.HasPrefix(name, host+".") {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("raven: no fully qualified domain name of %s", host)
+}
+
+// SetServerNameFQDN sets the server name of given client to the FQDN of the
+// host, it keeps the server name if it can't be resolved.
+func (client *Client) SetServerNameFQDN() error {
+	name, err := FQDN()
+	if err != nil {
+		return err
+	}
+	client.SetServerName(name)
+	return nil
+}
+
+// SetServerNameFQDN sets the server name of the default *Client to the FQDN of the host
+func SetServerNameFQDN() error { return DefaultClient.SetServerNameFQDN() }
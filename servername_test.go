@@ -0,0 +1,44 @@
+package raven
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestFQDN(t *testing.T) {
+	defer func() { lookupHost, lookupAddr = net.LookupHost, net.LookupAddr }()
+	lookupHost = func(host string) ([]string, error) {
+		if host != "web-7d9f" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"10.0.0.7"}, nil
+	}
+	lookupAddr = func(addr string) ([]string, error) {
+		return []string{"localhost.", "web-7d9f.checkout.svc.cluster.local."}, nil
+	}
+
+	if name, err := fqdn("web-7d9f"); err != nil || name != "web-7d9f.checkout.svc.cluster.local" {
+		t.Errorf("incorrect fqdn %q, %v", name, err)
+	}
+	if name, err := fqdn("web.example.com"); err != nil || name != "web.example.com" {
+		t.Errorf("expected a qualified name to be kept, got %q, %v", name, err)
+	}
+	if _, err := fqdn("unknown"); err == nil {
+		t.Error("expected an error for an unresolved host")
+	}
+}
+
+func TestServerNameFromEnv(t *testing.T) {
+	os.Setenv("SENTRY_SERVER_NAME", "checkout-1")
+	defer os.Unsetenv("SENTRY_SERVER_NAME")
+
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.CaptureMessageAndWait("message", nil)
+	if name := transport.packets[0].ServerName; name != "checkout-1" {
+		t.Errorf("incorrect server name %q", name)
+	}
+}
@@ -0,0 +1,154 @@
+package raven
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvelopeTransport is implemented by transports able to deliver envelopes
+// to the envelope endpoint, e.g. the session aggregates of Release Health.
+type EnvelopeTransport interface {
+	SendEnvelope(url, authHeader string, envelope []byte) error
+}
+
+// SendEnvelope posts a serialized envelope, url is the store url of the DSN
+func (t *HTTPTransport) SendEnvelope(url, authHeader string, envelope []byte) error {
+	if url == "" {
+		return nil
+	}
+	req, err := http.NewRequest("POST", envelopeURL(url), bytes.NewReader(envelope))
+	if err != nil {
+		return fmt.Errorf("raven: can't create new request: %v", err)
+	}
+	req.Header.Set("X-Sentry-Auth", authHeader)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", envelopeContentType)
+	return t.post(context.Background(), req, t.use())
+}
+
+// envelopeURL derives the envelope endpoint from the store endpoint of a DSN
+func envelopeURL(storeURL string) string {
+	return strings.TrimSuffix(storeURL, "/store/") + "/envelope/"
+}
+
+// SessionStatus is the outcome of a request tracked as a Release Health session
+type SessionStatus string
+
+// Session outcomes, a request is errored when it failed with a server error
+// and crashed when its handler panicked.
+const (
+	SessionExited  = SessionStatus("exited")
+	SessionErrored = SessionStatus("errored")
+	SessionCrashed = SessionStatus("crashed")
+)
+
+// sessionBucket counts the outcomes of the sessions started within a minute
+type sessionBucket struct {
+	Started string `json:"started"`
+	Exited  int    `json:"exited,omitempty"`
+	Errored int    `json:"errored,omitempty"`
+	Crashed int    `json:"crashed,omitempty"`
+}
+
+// sessionAggregates collects request sessions in per minute buckets, as
+// Sentry recommends for servers instead of one session per request.
+type sessionAggregates struct {
+	mu      sync.Mutex
+	buckets map[time.Time]*sessionBucket
+}
+
+func (a *sessionAggregates) record(started time.Time, status SessionStatus) {
+	minute := started.UTC().Truncate(time.Minute)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.buckets == nil {
+		a.buckets = make(map[time.Time]*sessionBucket)
+	}
+	bucket, ok := a.buckets[minute]
+	if !ok {
+		bucket = &sessionBucket{Started: minute.Format(time.RFC3339)}
+		a.buckets[minute] = bucket
+	}
+	switch status {
+	case SessionErrored:
+		bucket.Errored++
+	case SessionCrashed:
+		bucket.Crashed++
+	default:
+		bucket.Exited++
+	}
+}
+
+// take removes and returns the buckets started before until
+func (a *sessionAggregates) take(until time.Time) []*sessionBucket {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var buckets []*sessionBucket
+	for minute, bucket := range a.buckets {
+		if minute.Before(until) {
+			buckets = append(buckets, bucket)
+			delete(a.buckets, minute)
+		}
+	}
+	return buckets
+}
+
+// RecordSession counts a request started at started, which ended with
+// status, towards the Release Health of given client. Recoverer records
+// every request it handles. Aggregates are sent once their minute is over,
+// and by Wait, only if the client has a release and its transport is an
+// EnvelopeTransport.
+func (client *Client) RecordSession(started time.Time, status SessionStatus) {
+	client.sessions.record(started, status)
+	if buckets := client.sessions.take(time.Now().UTC().Truncate(time.Minute)); len(buckets) > 0 {
+		client.wg.Add(1)
+		go func() {
+			defer client.wg.Done()
+			client.sendSessions(buckets)
+		}()
+	}
+}
+
+// flushSessions sends all aggregates, including the ones of the current minute
+func (client *Client) flushSessions() {
+	if buckets := client.sessions.take(time.Now().Add(time.Minute)); len(buckets) > 0 {
+		client.sendSessions(buckets)
+	}
+}
+
+func (client *Client) sendSessions(buckets []*sessionBucket) {
+	client.mu.RLock()
+	url, authHeader, transport := client.url, client.authHeader, client.Transport
+	release, environment := client.release, client.environment
+	client.mu.RUnlock()
+
+	t := findEnvelopeTransport(transport)
+	if t == nil || release == "" {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"aggregates": buckets,
+		"attrs":      map[string]string{"release": release, "environment": environment},
+	})
+	if err != nil {
+		client.Logger().Errorf("error marshaling sessions: %v", err)
+		return
+	}
+	body, err := envelope("", nil, envelopeItem{Type: "sessions", Payload: payload})
+	if err != nil {
+		client.Logger().Errorf("error building sessions envelope: %v", err)
+		return
+	}
+	if err := t.SendEnvelope(url, authHeader, body); err != nil {
+		client.Logger().Errorf("error sending sessions: %v", err)
+		client.internalError(err, nil)
+	}
+}
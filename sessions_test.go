@@ -0,0 +1,81 @@
+package raven
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeURL(t *testing.T) {
+	if url := envelopeURL("https://sentry.example.com/api/42/store/"); url != "https://sentry.example.com/api/42/envelope/" {
+		t.Errorf("incorrect envelope url %s", url)
+	}
+}
+
+func TestRecordSession(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/envelope/" || r.Header.Get("Content-Type") != envelopeContentType {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client, err := New(strings.Replace(server.URL, "http://", "http://public:secret@", 1) + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetRelease("1.0.0")
+
+	now := time.Now()
+	client.RecordSession(now, SessionExited)
+	client.RecordSession(now, SessionCrashed)
+	client.RecordSession(now, SessionExited)
+	// a request of a past minute flushes its bucket right away
+	client.RecordSession(now.Add(-2*time.Minute), SessionErrored)
+	client.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 envelopes, got %d", len(bodies))
+	}
+
+	counts := map[string]int{}
+	for _, body := range bodies {
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		if len(lines) != 3 || !strings.Contains(lines[1], `"type":"sessions"`) {
+			t.Fatalf("incorrect envelope %q", body)
+		}
+		var payload struct {
+			Aggregates []sessionBucket   `json:"aggregates"`
+			Attrs      map[string]string `json:"attrs"`
+		}
+		if err := json.Unmarshal([]byte(lines[2]), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Attrs["release"] != "1.0.0" {
+			t.Errorf("incorrect attrs %+v", payload.Attrs)
+		}
+		for _, bucket := range payload.Aggregates {
+			counts["exited"] += bucket.Exited
+			counts["errored"] += bucket.Errored
+			counts["crashed"] += bucket.Crashed
+		}
+	}
+	if counts["exited"] != 2 || counts["errored"] != 1 || counts["crashed"] != 1 {
+		t.Errorf("incorrect session counts %+v", counts)
+	}
+}
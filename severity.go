@@ -0,0 +1,80 @@
+package raven
+
+import "reflect"
+
+// errorSeverity maps an error value, or any error of a type, to a Severity
+type errorSeverity struct {
+	sentinel error
+	typ      reflect.Type
+	level    Severity
+}
+
+// SetErrorSeverity makes CaptureError report err, and errors caused by it,
+// with level, e.g. context.DeadlineExceeded with WARNING. An empty level
+// removes the mapping. A level tag passed to CaptureError takes precedence.
+func (client *Client) SetErrorSeverity(err error, level Severity) {
+	client.setErrorSeverity(errorSeverity{sentinel: err, level: level})
+}
+
+// SetErrorSeverity maps err to level for the default *Client
+func SetErrorSeverity(err error, level Severity) { DefaultClient.SetErrorSeverity(err, level) }
+
+// SetErrorTypeSeverity makes CaptureError report errors of the type of err,
+// and errors caused by one, with level, e.g. (*MyFatalError)(nil) with FATAL.
+// An empty level removes the mapping.
+func (client *Client) SetErrorTypeSeverity(err error, level Severity) {
+	client.setErrorSeverity(errorSeverity{typ: reflect.TypeOf(err), level: level})
+}
+
+// SetErrorTypeSeverity maps errors of the type of err to level for the default *Client
+func SetErrorTypeSeverity(err error, level Severity) { DefaultClient.SetErrorTypeSeverity(err, level) }
+
+func (client *Client) setErrorSeverity(mapping errorSeverity) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	severities := make([]errorSeverity, 0, len(client.errorSeverities)+1)
+	for _, existing := range client.errorSeverities {
+		if existing.sentinel != mapping.sentinel || existing.typ != mapping.typ {
+			severities = append(severities, existing)
+		}
+	}
+	if mapping.level != "" {
+		severities = append(severities, mapping)
+	}
+	client.errorSeverities = severities
+}
+
+// errorSeverity returns the level mapped to err or the first of its causes
+// having one, values taking precedence over types
+func (client *Client) errorSeverity(err error) Severity {
+	client.mu.RLock()
+	severities := client.errorSeverities
+	client.mu.RUnlock()
+	if len(severities) == 0 {
+		return ""
+	}
+
+	for err != nil {
+		typ := reflect.TypeOf(err)
+		if typ.Comparable() {
+			for _, mapping := range severities {
+				if mapping.sentinel != nil && mapping.sentinel == err {
+					return mapping.level
+				}
+			}
+		}
+		for _, mapping := range severities {
+			if mapping.typ == typ {
+				return mapping.level
+			}
+		}
+
+		cause, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fatalError struct{ reason string }
+
+func (e *fatalError) Error() string { return "fatal: " + e.reason }
+
+type uncomparableError []string
+
+func (e uncomparableError) Error() string { return "uncomparable" }
+
+func TestErrorSeverity(t *testing.T) {
+	transport := &packetTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	client.SetErrorSeverity(context.DeadlineExceeded, WARNING)
+	client.SetErrorTypeSeverity((*fatalError)(nil), FATAL)
+	client.SetErrorSeverity(context.Canceled, INFO)
+	client.SetErrorSeverity(context.Canceled, "")
+
+	client.CaptureErrorAndWait(WrapWithExtra(context.DeadlineExceeded, nil), nil)
+	client.CaptureErrorAndWait(&fatalError{"disk full"}, nil)
+	client.CaptureErrorAndWait(context.Canceled, nil)
+	client.CaptureErrorAndWait(uncomparableError{"a"}, nil)
+	client.CaptureErrorAndWait(&fatalError{"disk full"}, map[string]string{"level": "debug"})
+
+	expected := []Severity{WARNING, FATAL, ERROR, ERROR, DEBUG}
+	if len(transport.packets) != len(expected) {
+		t.Fatalf("expected %d packets, got %d", len(expected), len(transport.packets))
+	}
+	for i, level := range expected {
+		if transport.packets[i].Level != level {
+			t.Errorf("%d: incorrect level: got %s, want %s", i, transport.packets[i].Level, level)
+		}
+	}
+
+	if level := client.errorSeverity(errors.New("unmapped")); level != "" {
+		t.Errorf("expected no level for an unmapped error, got %s", level)
+	}
+}
@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// raiseSignal delivers sig to the process again, replaced in tests
+var raiseSignal = func(sig os.Signal) error {
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return process.Signal(sig)
+}
+
+// FlushOnSignal makes the process flush given client before it terminates
+// on one of signals, SIGTERM and SIGINT by default: on the first one, a
+// breadcrumb records the shutdown, events are sent within timeout and the
+// signal is raised again with its default handling restored, so that the
+// process terminates as it would have without raven. It returns a function
+// removing the handlers. Programs handling signals themselves should call
+// Wait or WaitContext on shutdown instead.
+func (client *Client) FlushOnSignal(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			client.AddBreadcrumb(DefaultBreadcrumb(WARNING, "process", "received signal "+sig.String()+", shutting down", nil))
+			client.waitTimeout(timeout)
+			signal.Stop(ch)
+			if err := raiseSignal(sig); err != nil {
+				// e.g. on Windows, which can't signal a process
+				os.Exit(1)
+			}
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+	}
+}
+
+// FlushOnSignal makes the process flush the default *Client before it terminates on one of signals
+func FlushOnSignal(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	return DefaultClient.FlushOnSignal(timeout, signals...)
+}
@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package raven
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFlushOnSignal(t *testing.T) {
+	raised := make(chan os.Signal, 1)
+	defer func(raise func(os.Signal) error) { raiseSignal = raise }(raiseSignal)
+	raiseSignal = func(sig os.Signal) error {
+		raised <- sig
+		return nil
+	}
+
+	client := newClient(nil)
+	transport := &blockingTransport{release: make(chan struct{})}
+	client.Transport = transport
+	client.CaptureMessage("before shutdown", nil)
+
+	stop := client.FlushOnSignal(time.Second, syscall.SIGUSR1)
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-raised:
+		t.Fatal("expected the signal to be raised again once flushed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(transport.release)
+
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("expected SIGUSR1 to be raised again, got %v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the signal to be raised again")
+	}
+
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if len(client.context.breadcrumbs) != 1 || client.context.breadcrumbs[0].Category != "process" {
+		t.Errorf("expected a shutdown breadcrumb, got %+v", client.context.breadcrumbs)
+	}
+}
@@ -0,0 +1,165 @@
+package raven
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Spool persists packets dropped because of bursts or an unavailable Sentry
+// to a directory, so that they can be delivered later with ReplaySpool.
+// Packets dropped on purpose, by sampling, ignore rules or BeforeSend, and
+// packets which can't be serialized are not spooled. Installed with
+// SetSpool, it also persists packets whose delivery failed with a network
+// or server error, which may succeed later.
+type Spool struct {
+	Dir string
+
+	// Logger receives errors writing to the spool directory
+	Logger Logger
+}
+
+// NewSpool creates a Spool writing to dir, creating the directory if needed
+func NewSpool(dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("raven: can't create spool directory: %v", err)
+	}
+	return &Spool{Dir: dir, Logger: noopLogger{}}, nil
+}
+
+// Handle writes a dropped packet to the spool directory, it is meant to be
+// used as Client.DropHandlerWithReason.
+func (s *Spool) Handle(dropped *DroppedPacket) {
+	switch dropped.Reason {
+	case DropSampled, DropIgnored, DropBeforeSendNil, DropSerializationError:
+		return
+	}
+	s.write(dropped.Packet.EventID, dropped.Payload)
+}
+
+// write stores the packet of eventID serialized by payload in the spool directory
+func (s *Spool) write(eventID string, payload func() ([]byte, error)) {
+	// the id names the spooled file
+	if err := EventID(eventID).Validate(); err != nil {
+		s.Logger.Errorf("can't spool packet %q: %v", eventID, err)
+		return
+	}
+	data, err := payload()
+	if err != nil {
+		s.Logger.Errorf("can't serialize dropped packet %s: %v", eventID, err)
+		return
+	}
+
+	// Write to a temporary file first, so replays never see partial packets
+	path := filepath.Join(s.Dir, eventID+".json")
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		s.Logger.Errorf("can't spool packet %s: %v", eventID, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		s.Logger.Errorf("can't spool packet %s: %v", eventID, err)
+	}
+}
+
+// files lists the spooled packets, oldest first, skipping files not named
+// after an event id
+func (s *Spool) files() ([]string, error) {
+	infos, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byModTime(infos))
+
+	var files []string
+	for _, info := range infos {
+		name := info.Name()
+		if !info.IsDir() && strings.HasSuffix(name, ".json") && EventID(strings.TrimSuffix(name, ".json")).Validate() == nil {
+			files = append(files, filepath.Join(s.Dir, name))
+		}
+	}
+	return files, nil
+}
+
+type byModTime []os.FileInfo
+
+func (f byModTime) Len() int           { return len(f) }
+func (f byModTime) Less(i, j int) bool { return f[i].ModTime().Before(f[j].ModTime()) }
+func (f byModTime) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// SetSpool makes given client write dropped packets, and packets whose
+// delivery failed with a retryable error, see TransportError, to dir,
+// replacing its DropHandlerWithReason. Spooled packets are delivered by
+// ReplaySpool.
+func (client *Client) SetSpool(dir string) error {
+	spool, err := NewSpool(dir)
+	if err != nil {
+		return err
+	}
+	spool.Logger = client.Logger()
+
+	client.mu.Lock()
+	client.spool = spool
+	client.mu.Unlock()
+	client.DropHandlerWithReason = spool.Handle
+	return nil
+}
+
+// SetSpool makes the default *Client write dropped packets to dir
+func SetSpool(dir string) error { return DefaultClient.SetSpool(dir) }
+
+// ReplaySpool re-enqueues the packets of the spool directory one at a time,
+// removing each of them once delivered. It stops at the first failed
+// delivery, leaving the remaining packets for a later replay, or when ctx is
+// done. It returns the number of delivered packets.
+func (client *Client) ReplaySpool(ctx context.Context) (int, error) {
+	client.mu.RLock()
+	spool := client.spool
+	client.mu.RUnlock()
+	if spool == nil {
+		return 0, nil
+	}
+
+	files, err := spool.files()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return replayed, err
+		}
+		packet := &Packet{EventID: strings.TrimSuffix(filepath.Base(file), ".json"), raw: data}
+
+		ch := make(chan error, 1)
+		client.wg.Add(1)
+		client.enqueue(packet, ch)
+		select {
+		case err = <-ch:
+		case <-ctx.Done():
+			return replayed, ctx.Err()
+		}
+		if err != nil {
+			return replayed, err
+		}
+
+		if err := os.Remove(file); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// ReplaySpool re-enqueues the spooled packets of the default *Client
+func ReplaySpool(ctx context.Context) (int, error) { return DefaultClient.ReplaySpool(ctx) }
@@ -0,0 +1,117 @@
+package raven
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpoolReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := &recordingTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	if err := client.SetSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := NewPacket("spooled", &Message{Message: "spooled"})
+	packet.Init("1")
+	client.drop(packet, DropQueueFull)
+	for _, reason := range []DropReason{DropSampled, DropIgnored, DropBeforeSendNil, DropSerializationError} {
+		discarded := NewPacket("discarded")
+		discarded.Init("1")
+		client.drop(discarded, reason)
+	}
+	escaped := NewPacket("escaped")
+	escaped.EventID = "../escaped"
+	client.drop(escaped, DropQueueFull)
+	if _, err := os.Stat(filepath.Join(dir, "..", "escaped.json")); !os.IsNotExist(err) {
+		t.Errorf("expected an invalid event id not to be spooled, got %v", err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != packet.EventID+".json" {
+		t.Fatalf("expected only the queue overflow to be spooled, got %v", files)
+	}
+
+	replayed, err := client.ReplaySpool(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 1 || len(transport.urls) != 1 {
+		t.Errorf("expected 1 replayed packet, got %d", replayed)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("delivered packets should be removed from the spool, got %v", files)
+	}
+}
+
+func TestReplaySpoolKeepsFailedPackets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := newClient(nil)
+	client.Transport = &recordingTransport{fail: map[string]bool{"": true}}
+	if err := client.SetSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	packet := NewPacket("spooled")
+	packet.Init("1")
+	client.drop(packet, DropCircuitOpen)
+
+	if _, err := client.ReplaySpool(context.Background()); err == nil {
+		t.Error("expected replay to fail")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("failed packets should stay in the spool, got %v", files)
+	}
+}
+
+func TestPacketRawJSON(t *testing.T) {
+	packet := &Packet{Message: "ignored", raw: []byte(`{"message":"raw"}`)}
+	data, err := packet.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"message":"raw"}` {
+		t.Errorf("incorrect json: %s", data)
+	}
+}
+
+func TestSpoolRetryableFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := &statusTransport{status: http.StatusServiceUnavailable}
+	client := newClient(nil)
+	client.Transport = transport
+	if err := client.SetSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	eventID, ch := client.Capture(NewPacket("unavailable"), nil)
+	<-ch
+	transport.status = http.StatusBadRequest
+	_, ch = client.Capture(NewPacket("rejected"), nil)
+	<-ch
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != string(eventID)+".json" {
+		t.Errorf("expected only the server error to be spooled, got %v", files)
+	}
+}
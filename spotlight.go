@@ -0,0 +1,119 @@
+package raven
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// SpotlightURL is the default address of the Sentry Spotlight sidecar
+const SpotlightURL = "http://localhost:8969/stream"
+
+// SpotlightTransport mirrors every packet to the Spotlight sidecar, so
+// events show up instantly during development, before handing it over to
+// the wrapped Transport. Failures to reach Spotlight are ignored.
+type SpotlightTransport struct {
+	// Transport delivers packets to Sentry, it may be nil to only mirror them
+	Transport Transport
+
+	// URL of the Spotlight sidecar stream endpoint
+	URL string
+
+	Client *http.Client
+}
+
+// NewSpotlightTransport creates a SpotlightTransport mirroring packets to spotlightURL
+func NewSpotlightTransport(transport Transport, spotlightURL string) *SpotlightTransport {
+	if spotlightURL == "" {
+		spotlightURL = SpotlightURL
+	}
+	return &SpotlightTransport{
+		Transport: transport,
+		URL:       spotlightURL,
+		Client:    &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Unwrap returns the transport delivering packets to Sentry
+func (t *SpotlightTransport) Unwrap() Transport { return t.Transport }
+
+// Send mirrors the packet to Spotlight and delivers it with the wrapped Transport
+func (t *SpotlightTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendWithContext(context.Background(), url, authHeader, packet)
+}
+
+// SendWithContext is Send bounded by ctx
+func (t *SpotlightTransport) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	t.mirror(ctx, packet)
+
+	if t.Transport == nil {
+		return nil
+	}
+	return AsTransportV2(t.Transport).SendWithContext(ctx, url, authHeader, packet)
+}
+
+func (t *SpotlightTransport) mirror(ctx context.Context, packet *Packet) error {
+	body, err := eventEnvelope(packet)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", envelopeContentType)
+
+	res, err := t.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("raven: spotlight returned http status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// SetSpotlight enables mirroring of all packets to the Spotlight sidecar
+// listening on spotlightURL, or on SpotlightURL when it is empty. Calling it
+// with enabled set to false restores the wrapped transport.
+func (client *Client) SetSpotlight(enabled bool, spotlightURL string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	current, isSpotlight := client.Transport.(*SpotlightTransport)
+	switch {
+	case enabled && isSpotlight:
+		client.Transport = NewSpotlightTransport(current.Transport, spotlightURL)
+	case enabled:
+		client.Transport = NewSpotlightTransport(client.Transport, spotlightURL)
+	case isSpotlight && current.Transport != nil:
+		client.Transport = current.Transport
+	}
+}
+
+// SetSpotlight enables mirroring of packets to Spotlight on the default *Client
+func SetSpotlight(enabled bool, spotlightURL string) {
+	DefaultClient.SetSpotlight(enabled, spotlightURL)
+}
+
+// spotlightFromEnv reads SENTRY_SPOTLIGHT, which is either a boolean or the sidecar url
+func spotlightFromEnv() (enabled bool, spotlightURL string) {
+	value := os.Getenv("SENTRY_SPOTLIGHT")
+	switch strings.ToLower(value) {
+	case "", "0", "false", "f", "n", "no", "off":
+		return false, ""
+	case "1", "true", "t", "y", "yes", "on":
+		return true, ""
+	}
+	return true, value
+}
@@ -0,0 +1,76 @@
+package raven
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpotlightTransport(t *testing.T) {
+	var body, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, contentType = string(b), r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	rt := &recordingTransport{}
+	transport := NewSpotlightTransport(rt, server.URL)
+	if err := transport.Send("https://example.com/api/1/store/", "", &Packet{Message: "foo", EventID: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if contentType != envelopeContentType {
+		t.Errorf("incorrect Content-Type: %s", contentType)
+	}
+	lines := strings.Split(body, "\n")
+	if len(lines) < 3 || !strings.Contains(lines[0], `"event_id":"abc"`) || !strings.Contains(lines[1], `"type":"event"`) {
+		t.Errorf("incorrect envelope: %q", body)
+	}
+	if len(rt.urls) != 1 {
+		t.Error("packet was not delivered to the wrapped transport")
+	}
+}
+
+func TestSetSpotlight(t *testing.T) {
+	client := newClient(nil)
+	original := client.Transport
+
+	client.SetSpotlight(true, "")
+	spotlight, ok := client.Transport.(*SpotlightTransport)
+	if !ok {
+		t.Fatalf("expected *SpotlightTransport, got %T", client.Transport)
+	}
+	if spotlight.URL != SpotlightURL || spotlight.Transport != original {
+		t.Errorf("incorrect spotlight transport: %+v", spotlight)
+	}
+
+	client.SetSpotlight(false, "")
+	if client.Transport != original {
+		t.Error("disabling spotlight should restore the original transport")
+	}
+}
+
+func TestSpotlightFromEnv(t *testing.T) {
+	defer os.Unsetenv("SENTRY_SPOTLIGHT")
+	tests := []struct {
+		value   string
+		enabled bool
+		url     string
+	}{
+		{"", false, ""},
+		{"false", false, ""},
+		{"true", true, ""},
+		{"http://localhost:9000/stream", true, "http://localhost:9000/stream"},
+	}
+	for _, test := range tests {
+		os.Setenv("SENTRY_SPOTLIGHT", test.value)
+		enabled, url := spotlightFromEnv()
+		if enabled != test.enabled || url != test.url {
+			t.Errorf("%q: got (%v, %q), want (%v, %q)", test.value, enabled, url, test.enabled, test.url)
+		}
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+
+	pkgErrors "github.com/pkg/errors"
 )
 
 // Stacktrace defines Sentry's spec compliant interface holding Stacktrace information - https://docs.sentry.io/development/sdk-dev/interfaces/stacktrace/
@@ -52,18 +54,47 @@ type StacktraceFrame struct {
 	InApp        bool     `json:"in_app"`
 }
 
+// stackTracer is implemented by errors carrying the stack where they were created
+type stackTracer interface {
+	StackTrace() []runtime.Frame
+}
+
+// pkgStackTracer is implemented by the errors of github.com/pkg/errors
+type pkgStackTracer interface {
+	StackTrace() pkgErrors.StackTrace
+}
+
+// errorStack returns the program counters of the stack carried by err, plus
+// one like those of runtime.Frame, false if err carries none
+func errorStack(err error) ([]uintptr, bool) {
+	switch err := err.(type) {
+	case stackTracer:
+		frames := err.StackTrace()
+		pcs := make([]uintptr, len(frames))
+		for i, f := range frames {
+			pcs[i] = f.PC
+		}
+		return pcs, true
+	case pkgStackTracer:
+		frames := err.StackTrace()
+		pcs := make([]uintptr, len(frames))
+		for i, f := range frames {
+			pcs[i] = uintptr(f)
+		}
+		return pcs, true
+	}
+	return nil, false
+}
+
 // GetOrNewStacktrace tries to get stacktrace from err as an interface of github.com/pkg/errors, or else NewStacktrace()
 func GetOrNewStacktrace(err error, skip int, context int, appPackagePrefixes []string) *Stacktrace {
-	type stackTracer interface {
-		StackTrace() []runtime.Frame
-	}
-	stacktrace, ok := err.(stackTracer)
+	stack, ok := errorStack(err)
 	if !ok {
 		return NewStacktrace(skip+1, context, appPackagePrefixes)
 	}
 	var frames []*StacktraceFrame
-	for _, f := range stacktrace.StackTrace() {
-		pc := uintptr(f.PC) - 1
+	for _, f := range stack {
+		pc := f - 1
 		fn := runtime.FuncForPC(pc)
 		var fName string
 		var file string
@@ -0,0 +1,154 @@
+package raven
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Syslog severities, the lower three bits of a syslog priority as defined by
+// log/syslog, which isn't available on every platform.
+const (
+	SyslogEmerg = iota
+	SyslogAlert
+	SyslogCrit
+	SyslogErr
+	SyslogWarning
+	SyslogNotice
+	SyslogInfo
+	SyslogDebug
+)
+
+// SyslogSeverity maps a syslog priority, e.g. a syslog.Priority, to a Severity
+func SyslogSeverity(priority int) Severity {
+	switch priority & 7 {
+	case SyslogEmerg, SyslogAlert, SyslogCrit:
+		return FATAL
+	case SyslogErr:
+		return ERROR
+	case SyslogWarning:
+		return WARNING
+	case SyslogNotice, SyslogInfo:
+		return INFO
+	}
+	return DEBUG
+}
+
+// SyslogLogger is implemented by *syslog.Writer
+type SyslogLogger interface {
+	Write(b []byte) (int, error)
+	Emerg(m string) error
+	Alert(m string) error
+	Crit(m string) error
+	Err(m string) error
+	Warning(m string) error
+	Notice(m string) error
+	Info(m string) error
+	Debug(m string) error
+}
+
+// SyslogWriter tees messages logged to syslog into Sentry, with a level
+// matching their severity, when they are at least as severe as Threshold.
+// It implements SyslogLogger, so daemons can swap it in for their
+// *syslog.Writer. Lines passed to Write may start with a "<N>" priority
+// prefix, as used by systemd, otherwise Priority applies.
+type SyslogWriter struct {
+	Client *Client
+
+	// Syslog receives all messages, it may be nil
+	Syslog SyslogLogger
+
+	// Priority of lines written without priority prefix
+	Priority int
+
+	// Threshold is the least severe priority captured, SyslogErr by default
+	Threshold int
+
+	Logger string // Logger name reported to Sentry
+}
+
+// NewSyslogWriter creates a SyslogWriter of the default *Client capturing err and above
+func NewSyslogWriter(syslog SyslogLogger, priority int) *SyslogWriter {
+	return &SyslogWriter{
+		Client:    DefaultClient,
+		Syslog:    syslog,
+		Priority:  priority,
+		Threshold: SyslogErr,
+	}
+}
+
+func (w *SyslogWriter) capture(priority int, message string) {
+	message = strings.TrimRight(message, "\n")
+	if priority&7 > w.Threshold || message == "" {
+		return
+	}
+	packet := NewPacket(message, &Message{message, nil})
+	packet.Level = SyslogSeverity(priority)
+	packet.Logger = w.Logger
+	w.Client.Capture(packet, map[string]string{"syslog.priority": strconv.Itoa(priority & 7)})
+}
+
+// Write captures p with its prefixed priority, or Priority, and forwards it to Syslog
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	priority, message := w.Priority, string(p)
+	if strings.HasPrefix(message, "<") {
+		if end := strings.Index(message, ">"); end > 1 {
+			if n, err := strconv.Atoi(message[1:end]); err == nil {
+				priority, message = n, message[end+1:]
+			}
+		}
+	}
+	w.capture(priority, message)
+
+	if w.Syslog == nil {
+		return len(p), nil
+	}
+	return w.Syslog.Write(p)
+}
+
+func (w *SyslogWriter) log(priority int, m string, forward func(SyslogLogger) error) error {
+	w.capture(priority, m)
+	if w.Syslog == nil {
+		return nil
+	}
+	return forward(w.Syslog)
+}
+
+// Emerg logs a message with severity emerg
+func (w *SyslogWriter) Emerg(m string) error {
+	return w.log(SyslogEmerg, m, func(s SyslogLogger) error { return s.Emerg(m) })
+}
+
+// Alert logs a message with severity alert
+func (w *SyslogWriter) Alert(m string) error {
+	return w.log(SyslogAlert, m, func(s SyslogLogger) error { return s.Alert(m) })
+}
+
+// Crit logs a message with severity crit
+func (w *SyslogWriter) Crit(m string) error {
+	return w.log(SyslogCrit, m, func(s SyslogLogger) error { return s.Crit(m) })
+}
+
+// Err logs a message with severity err
+func (w *SyslogWriter) Err(m string) error {
+	return w.log(SyslogErr, m, func(s SyslogLogger) error { return s.Err(m) })
+}
+
+// Warning logs a message with severity warning
+func (w *SyslogWriter) Warning(m string) error {
+	return w.log(SyslogWarning, m, func(s SyslogLogger) error { return s.Warning(m) })
+}
+
+// Notice logs a message with severity notice
+func (w *SyslogWriter) Notice(m string) error {
+	return w.log(SyslogNotice, m, func(s SyslogLogger) error { return s.Notice(m) })
+}
+
+// Info logs a message with severity info
+func (w *SyslogWriter) Info(m string) error {
+	return w.log(SyslogInfo, m, func(s SyslogLogger) error { return s.Info(m) })
+}
+
+// Debug logs a message with severity debug
+func (w *SyslogWriter) Debug(m string) error {
+	return w.log(SyslogDebug, m, func(s SyslogLogger) error { return s.Debug(m) })
+}
@@ -0,0 +1,38 @@
+package raven
+
+import "testing"
+
+func TestSyslogSeverity(t *testing.T) {
+	expected := []Severity{FATAL, FATAL, FATAL, ERROR, WARNING, INFO, INFO, DEBUG}
+	for priority, severity := range expected {
+		// facilities are ignored, e.g. LOG_DAEMON|LOG_ERR
+		if s := SyslogSeverity(3<<3 | priority); s != severity {
+			t.Errorf("priority %d: expected %s, got %s", priority, severity, s)
+		}
+	}
+}
+
+func TestSyslogWriter(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	w := &SyslogWriter{Client: client, Priority: SyslogInfo, Threshold: SyslogErr}
+	w.Info("started")
+	w.Crit("disk full")
+	w.Write([]byte("<3>connection lost\n"))
+	w.Write([]byte("request served\n"))
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(transport.packets))
+	}
+	if packet := transport.packets[0]; packet.Message != "disk full" || packet.Level != FATAL {
+		t.Errorf("incorrect packet %+v", packet)
+	}
+	if packet := transport.packets[1]; packet.Message != "connection lost" || packet.Level != ERROR {
+		t.Errorf("incorrect packet %+v", packet)
+	}
+}
@@ -0,0 +1,50 @@
+package raven
+
+// SetTag adds a tag to every packet captured by given client. It is safe to
+// call concurrently with captures, unlike modifying Client.Tags.
+func (client *Client) SetTag(key, value string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	tags := client.copyTags(1)
+	tags[key] = value
+	client.Tags = tags
+}
+
+// SetTag adds a tag to every packet captured by the default *Client
+func SetTag(key, value string) { DefaultClient.SetTag(key, value) }
+
+// RemoveTag removes a tag set by SetTag, or in Client.Tags, from given client
+func (client *Client) RemoveTag(key string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if _, ok := client.Tags[key]; !ok {
+		return
+	}
+	tags := client.copyTags(0)
+	delete(tags, key)
+	client.Tags = tags
+}
+
+// RemoveTag removes a tag from the default *Client
+func RemoveTag(key string) { DefaultClient.RemoveTag(key) }
+
+// TagsSnapshot returns a copy of the tags of given client
+func (client *Client) TagsSnapshot() map[string]string {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.copyTags(0)
+}
+
+// TagsSnapshot returns a copy of the tags of the default *Client
+func TagsSnapshot() map[string]string { return DefaultClient.TagsSnapshot() }
+
+// copyTags copies client.Tags with room for extra more, callers must hold
+// mu. The map is replaced rather than modified, so that it stays safe to
+// read for the code still holding the previous one.
+func (client *Client) copyTags(extra int) map[string]string {
+	tags := make(map[string]string, len(client.Tags)+extra)
+	for key, value := range client.Tags {
+		tags[key] = value
+	}
+	return tags
+}
@@ -0,0 +1,49 @@
+package raven
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetTag(t *testing.T) {
+	client := newClient(map[string]string{"initial": "true"})
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	previous := client.Tags
+	client.SetTag("region", "eu")
+	client.RemoveTag("initial")
+	client.RemoveTag("missing")
+	if len(previous) != 1 || previous["initial"] != "true" {
+		t.Errorf("expected the previous tags to be left untouched, got %v", previous)
+	}
+	if tags := client.TagsSnapshot(); len(tags) != 1 || tags["region"] != "eu" {
+		t.Errorf("incorrect tags %v", tags)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetTag("concurrent", "true")
+			client.RemoveTag("concurrent")
+		}()
+		go func() {
+			defer wg.Done()
+			client.CaptureMessage("concurrent", nil)
+		}()
+	}
+	wg.Wait()
+	client.Wait()
+
+	for _, packet := range transport.packets {
+		var region bool
+		for _, tag := range packet.Tags {
+			region = region || tag.Key == "region" && tag.Value == "eu"
+		}
+		if !region {
+			t.Errorf("expected the region tag on %+v", packet.Tags)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TaskScope is the isolated scope of a run of a task wrapped with WrapTask,
+// stored in the context of the run, see TaskScopeFromContext. Like the scope
+// of a request, it keeps the user, tags and breadcrumbs of the run apart from
+// the context of the client shared by the runs of a worker pool.
+type TaskScope struct {
+	eventScope
+
+	name   string
+	start  time.Time
+	trace  *requestTrace
+	parent string
+}
+
+// taskScopeKey stores the *TaskScope of a task run in its context
+type taskScopeKey struct{}
+
+// TaskScopeFromContext returns the scope of the task run ctx is the context
+// of, nil outside of a task wrapped with WrapTask.
+func TaskScopeFromContext(ctx context.Context) *TaskScope {
+	scope, _ := ctx.Value(taskScopeKey{}).(*TaskScope)
+	return scope
+}
+
+// WrapTask instruments fn, a task of a job or queue system named name, e.g.
+// the handler of a job type. Each run gets its own scope in its context,
+// records started and finished breadcrumbs, and captures the error fn
+// returns or its panic, which is recovered and returned as error, tagged
+// with the task name and timed from the start of the run. Unless tracing is
+// disabled, events carry the trace of the run, continuing the trace of a
+// request when ctx comes from one, and runs are profiled as transaction name
+// according to SetProfilesSampleRate.
+//
+//	pool.Submit(client.WrapTask("send-invoice", func(ctx context.Context) error {
+//		return sendInvoice(ctx, invoiceID)
+//	}))
+func (client *Client) WrapTask(name string, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) (err error) {
+		scope := &TaskScope{eventScope: eventScope{client: client, Tags: map[string]string{"task": name}}, name: name, start: time.Now()}
+		scope.trace, scope.parent = client.taskTrace(ctx)
+		ctx = context.WithValue(ctx, taskScopeKey{}, scope)
+		scope.AddBreadcrumb(DefaultBreadcrumb(INFO, "task", "started "+name, nil))
+
+		profile := client.StartProfile(name)
+		if profile != nil {
+			profile.op = "task"
+			if scope.trace != nil {
+				profile.traceID, profile.spanID = scope.trace.traceID, scope.trace.spanID
+			}
+		}
+		defer profile.Stop()
+
+		defer func() {
+			rval := recover()
+			if rval == nil {
+				scope.finish(err)
+				if err != nil {
+					scope.CaptureError(err, nil)
+				}
+				return
+			}
+			err = fmt.Errorf("raven: task panic: %v", rval)
+			scope.finish(err)
+			var packet *Packet
+			if rvalErr, ok := rval.(error); ok {
+				packet = NewPacket(rvalErr.Error(), append(scope.interfaces(), NewException(rvalErr, NewStacktrace(2, 3, client.includePaths)))...)
+			} else {
+				rvalStr := fmt.Sprint(rval)
+				packet = NewPacketWithExtra(rvalStr, panicValueExtra(rval), append(scope.interfaces(), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
+			}
+			client.Capture(scope.decorate(packet), scope.captureTags(nil))
+		}()
+
+		return fn(ctx)
+	}
+}
+
+// WrapTask instruments the task fn named name with the default *Client, see Client.WrapTask
+func WrapTask(name string, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return DefaultClient.WrapTask(name, fn)
+}
+
+// taskTrace starts the trace of a task run, continuing the trace of the
+// request of ctx if any, and returns the id of its parent span, nil when
+// tracing is disabled.
+func (client *Client) taskTrace(ctx context.Context) (*requestTrace, string) {
+	client.mu.RLock()
+	disabled := client.tracing.disabled
+	client.mu.RUnlock()
+	if disabled {
+		return nil, ""
+	}
+
+	if parent, ok := ctx.Value(traceKey{}).(*requestTrace); ok {
+		return &requestTrace{traceID: parent.traceID, spanID: randomID(16), sampled: parent.sampled, dsc: parent.dsc}, parent.spanID
+	}
+	return &requestTrace{traceID: randomID(32), spanID: randomID(16)}, ""
+}
+
+// CaptureError captures err like Client.CaptureError, with the user, tags
+// and breadcrumbs of the scope instead of the context of the client.
+func (s *TaskScope) CaptureError(err error, tags map[string]string) EventID {
+	packet := s.errorPacket(err, s.interfaces())
+	if packet == nil {
+		return ""
+	}
+	eventID, _ := s.client.Capture(s.decorate(packet), withErrorTraits(s.captureTags(tags), err))
+	return eventID
+}
+
+// finish records the finished breadcrumb of the run with its duration
+func (s *TaskScope) finish(err error) {
+	status, level := "ok", INFO
+	if err != nil {
+		status, level = "error", ERROR
+	}
+	s.AddBreadcrumb(DefaultBreadcrumb(level, "task", "finished "+s.name, map[string]interface{}{
+		"status":      status,
+		"duration_ms": float64(time.Since(s.start)) / float64(time.Millisecond),
+	}))
+}
+
+// interfaces returns copies of the user and breadcrumbs of the scope
+func (s *TaskScope) interfaces() []Interface {
+	var interfaces []Interface
+	user, breadcrumbs := s.snapshot()
+	if user != nil {
+		interfaces = append(interfaces, user)
+	}
+	if breadcrumbs != nil {
+		interfaces = append(interfaces, breadcrumbs)
+	}
+	return interfaces
+}
+
+// decorate sets the task name as transaction, the timing and the trace of the run on packet
+func (s *TaskScope) decorate(packet *Packet) *Packet {
+	packet.Transaction = s.name
+	packet.SetTiming(s.start, time.Since(s.start))
+	if s.trace != nil {
+		trace := map[string]interface{}{"trace_id": s.trace.traceID, "span_id": s.trace.spanID, "op": "task"}
+		if s.parent != "" {
+			trace["parent_span_id"] = s.parent
+		}
+		packet.Contexts = Contexts{"trace": trace}
+		packet.dsc = s.trace.dsc
+		if packet.dsc == nil {
+			packet.dsc = s.client.samplingContext(s.trace, s.name)
+		}
+	}
+	return packet
+}
@@ -0,0 +1,89 @@
+package raven
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapTask(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	task := client.WrapTask("send-invoice", func(ctx context.Context) error {
+		scope := TaskScopeFromContext(ctx)
+		scope.SetUser(&User{ID: "42"})
+		scope.SetTag("invoice", "7")
+		return errors.New("smtp unavailable")
+	})
+	if err := task(context.Background()); err == nil || err.Error() != "smtp unavailable" {
+		t.Errorf("expected the error of the task, got %v", err)
+	}
+	err := client.WrapTask("crash", func(ctx context.Context) error {
+		panic("crashed")
+	})(context.Background())
+	if err == nil || err.Error() != "raven: task panic: crashed" {
+		t.Errorf("expected the panic to be returned, got %v", err)
+	}
+	if err := client.WrapTask("ok", func(ctx context.Context) error { return nil })(context.Background()); err != nil {
+		t.Error(err)
+	}
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.packets))
+	}
+	failed := transport.packets[0]
+	if failed.Transaction != "send-invoice" || failed.StartTimestamp == nil || failed.Contexts["trace"] == nil {
+		t.Errorf("expected the transaction, timing and trace of the task, got %+v", failed)
+	}
+	tags := map[string]string{}
+	for _, tag := range failed.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["task"] != "send-invoice" || tags["invoice"] != "7" {
+		t.Errorf("incorrect tags %v", tags)
+	}
+	for _, inter := range failed.Interfaces {
+		switch inter := inter.(type) {
+		case *User:
+			if inter.ID != "42" {
+				t.Errorf("incorrect user %+v", inter)
+			}
+		case *Breadcrumbs:
+			if len(inter.Values) != 2 || inter.Values[0].Message != "started send-invoice" || inter.Values[1].Data["status"] != "error" {
+				t.Errorf("expected started and finished breadcrumbs, got %+v", inter.Values)
+			}
+		}
+	}
+	if crashed := transport.packets[1]; crashed.Message != "crashed" || crashed.Transaction != "crash" {
+		t.Errorf("incorrect panic event %+v", crashed)
+	}
+	if TaskScopeFromContext(context.Background()) != nil {
+		t.Error("expected no scope outside of a task")
+	}
+}
+
+func TestWrapTaskContinuesTrace(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	var requestTrace map[string]interface{}
+	handler := client.WrapHandler(nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestTrace = traceContext(r)
+		client.WrapTask("resize", func(ctx context.Context) error {
+			return errors.New("resize failed")
+		})(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	client.Wait()
+
+	trace := transport.packets[0].Contexts["trace"].(map[string]interface{})
+	if trace["trace_id"] != requestTrace["trace_id"] || trace["parent_span_id"] != requestTrace["span_id"] || trace["span_id"] == requestTrace["span_id"] {
+		t.Errorf("expected a span of the trace of the request %v, got %v", requestTrace, trace)
+	}
+}
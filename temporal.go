@@ -0,0 +1,61 @@
+package raven
+
+// TemporalInfo describes the workflow or activity being executed by a
+// Temporal or Cadence worker, the fields map to activity.Info and
+// workflow.Info of both SDKs. Interceptors of either SDK call
+// HandleTemporalActivity from ExecuteActivity, this package doesn't depend on them:
+//
+//	func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+//		info := activity.GetInfo(ctx)
+//		var result interface{}
+//		err := raven.HandleTemporalActivity(raven.TemporalInfo{
+//			WorkflowID: info.WorkflowExecution.ID,
+//			RunID:      info.WorkflowExecution.RunID,
+//			TaskQueue:  info.TaskQueue,
+//			Activity:   info.ActivityType.Name,
+//		}, func() (err error) {
+//			result, err = a.Next.ExecuteActivity(ctx, in)
+//			return err
+//		})
+//		return result, err
+//	}
+//
+// Call Wait after the worker stopped, so that failures captured during
+// shutdown are flushed.
+type TemporalInfo struct {
+	WorkflowID string
+	RunID      string
+	TaskQueue  string
+
+	// Workflow and Activity are the workflow and activity type names
+	Workflow string
+	Activity string
+}
+
+// Tags returns the workflow id, run id, task queue and type names as tags
+func (info TemporalInfo) Tags() map[string]string {
+	tags := map[string]string{
+		"temporal.workflow_id": info.WorkflowID,
+		"temporal.run_id":      info.RunID,
+		"temporal.task_queue":  info.TaskQueue,
+	}
+	if info.Workflow != "" {
+		tags["temporal.workflow"] = info.Workflow
+	}
+	if info.Activity != "" {
+		tags["temporal.activity"] = info.Activity
+	}
+	return tags
+}
+
+// HandleTemporalActivity runs an activity or workflow, capturing the failure
+// it returns and recovering a panic, which is returned as error so that the
+// SDK retries it like any other failure.
+func (client *Client) HandleTemporalActivity(info TemporalInfo, execute func() error) error {
+	return client.captureHandler(info.Tags(), execute)
+}
+
+// HandleTemporalActivity runs an activity or workflow with the default *Client
+func HandleTemporalActivity(info TemporalInfo, execute func() error) error {
+	return DefaultClient.HandleTemporalActivity(info, execute)
+}
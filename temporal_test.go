@@ -0,0 +1,34 @@
+package raven
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleTemporalActivity(t *testing.T) {
+	client := newClient(nil)
+	transport := &packetTransport{}
+	client.Transport = transport
+
+	info := TemporalInfo{WorkflowID: "order-1", RunID: "run-1", TaskQueue: "orders", Activity: "Charge"}
+	err := client.HandleTemporalActivity(info, func() error { panic("card declined") })
+	if err == nil || !strings.Contains(err.Error(), "card declined") {
+		t.Errorf("expected the panic as error, got %v", err)
+	}
+	client.Wait()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.packets) != 1 {
+		t.Fatalf("expected 1 packet, got %d", len(transport.packets))
+	}
+	tags := map[string]string{}
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	for key, value := range info.Tags() {
+		if tags[key] != value {
+			t.Errorf("expected tag %s=%s, got %+v", key, value, tags)
+		}
+	}
+}
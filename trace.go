@@ -0,0 +1,325 @@
+package raven
+
+import (
+	"context"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Headers propagating a trace between services
+const (
+	sentryTraceHeader = "sentry-trace"
+	baggageHeader     = "baggage"
+	baggagePrefix     = "sentry-"
+)
+
+type traceKey struct{}
+
+// DynamicSamplingContext is the state of a trace Sentry bases server-side
+// dynamic sampling on, e.g. trace_id, public_key, sample_rate, release,
+// environment and transaction - https://develop.sentry.dev/sdk/telemetry/traces/dynamic-sampling-context/
+// Its keys are the names of the sentry- members of the baggage header without prefix.
+type DynamicSamplingContext map[string]string
+
+// ParseBaggage returns the sentry- members of a baggage header
+func ParseBaggage(header string) DynamicSamplingContext {
+	dsc := DynamicSamplingContext{}
+	for _, member := range strings.Split(header, ",") {
+		// properties of a member, after ';', are ignored
+		if idx := strings.Index(member, ";"); idx != -1 {
+			member = member[:idx]
+		}
+		idx := strings.Index(member, "=")
+		if idx == -1 {
+			continue
+		}
+		key := strings.TrimSpace(member[:idx])
+		if !strings.HasPrefix(key, baggagePrefix) {
+			continue
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(member[idx+1:]))
+		if err != nil {
+			continue
+		}
+		dsc[strings.TrimPrefix(key, baggagePrefix)] = value
+	}
+	return dsc
+}
+
+// Baggage encodes dsc as a baggage header, keeping the members of other,
+// a baggage header of other vendors, which are not sentry- members.
+func (dsc DynamicSamplingContext) Baggage(other string) string {
+	var members []string
+	for _, member := range strings.Split(other, ",") {
+		member = strings.TrimSpace(member)
+		if member != "" && !strings.HasPrefix(member, baggagePrefix) {
+			members = append(members, member)
+		}
+	}
+	for _, key := range sortedKeys(dsc) {
+		members = append(members, baggagePrefix+key+"="+url.QueryEscape(dsc[key]))
+	}
+	return strings.Join(members, ",")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	// insertion sort, contexts only have a handful of keys
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+	return keys
+}
+
+// requestTrace is the trace a request handled by WrapHandler continues or starts
+type requestTrace struct {
+	traceID string
+	spanID  string
+	sampled string
+
+	// dsc received from the upstream service, frozen for the whole trace
+	dsc DynamicSamplingContext
+}
+
+// tracing is the tracing configuration of a client, see SetTracingEnabled,
+// SetTracesSampleRate and SetTracePropagationTargets
+type tracing struct {
+	disabled bool
+
+	// sampleRate decides whether traces started by the client are sampled,
+	// the decision is deferred to downstream services while it is unset
+	sampleRate    float32
+	hasSampleRate bool
+
+	// trace headers are only set on requests to urls matching a target, or
+	// all requests without targets
+	propagationTargets []*regexp.Regexp
+}
+
+// SetTracingEnabled enables or disables the tracing of requests handled by
+// Recoverer and the propagation of their trace, tracing is enabled by default
+func (client *Client) SetTracingEnabled(enabled bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.tracing.disabled = !enabled
+}
+
+// SetTracingEnabled enables or disables tracing of the default *Client
+func SetTracingEnabled(enabled bool) { DefaultClient.SetTracingEnabled(enabled) }
+
+// SetTracesSampleRate sets the share of traces started by given client that
+// are sampled, the decision is propagated in the sentry-trace header
+func (client *Client) SetTracesSampleRate(rate float32) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if rate < 0 || rate > 1 {
+		return ErrInvalidSampleRate
+	}
+	client.tracing.sampleRate, client.tracing.hasSampleRate = rate, true
+	return nil
+}
+
+// SetTracesSampleRate sets the share of traces started by the default *Client that are sampled
+func SetTracesSampleRate(rate float32) error { return DefaultClient.SetTracesSampleRate(rate) }
+
+// SetTracePropagationTargets restricts SetTraceHeaders to requests whose url
+// matches one of the regular expressions targets, e.g. of internal services.
+// Without targets the trace is propagated to all requests.
+func (client *Client) SetTracePropagationTargets(targets ...string) error {
+	var patterns []*regexp.Regexp
+	for _, target := range targets {
+		pattern, err := regexp.Compile(target)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.tracing.propagationTargets = patterns
+	return nil
+}
+
+// SetTracePropagationTargets restricts the trace propagation of the default *Client
+func SetTracePropagationTargets(targets ...string) error {
+	return DefaultClient.SetTracePropagationTargets(targets...)
+}
+
+// setTracingFromEnv reads SENTRY_ENABLE_TRACING, SENTRY_TRACES_SAMPLE_RATE
+// and SENTRY_TRACE_PROPAGATION_TARGETS, a comma separated list
+func (client *Client) setTracingFromEnv() {
+	if value := os.Getenv("SENTRY_ENABLE_TRACING"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			client.Logger().Errorf("incorrect SENTRY_ENABLE_TRACING: %v", err)
+		} else {
+			client.SetTracingEnabled(enabled)
+		}
+	}
+	if value := os.Getenv("SENTRY_TRACES_SAMPLE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 32)
+		if err == nil {
+			err = client.SetTracesSampleRate(float32(rate))
+		}
+		if err != nil {
+			client.Logger().Errorf("incorrect SENTRY_TRACES_SAMPLE_RATE: %v", err)
+		}
+	}
+	if value := os.Getenv("SENTRY_TRACE_PROPAGATION_TARGETS"); value != "" {
+		var targets []string
+		for _, target := range strings.Split(value, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				targets = append(targets, target)
+			}
+		}
+		if err := client.SetTracePropagationTargets(targets...); err != nil {
+			client.Logger().Errorf("incorrect SENTRY_TRACE_PROPAGATION_TARGETS: %v", err)
+		}
+	}
+}
+
+// withTrace continues the trace of the sentry-trace and baggage headers of r,
+// or starts a new one, and stores it in the context of the returned request,
+// unless tracing is disabled.
+func (client *Client) withTrace(r *http.Request) *http.Request {
+	client.mu.RLock()
+	tracing := client.tracing
+	client.mu.RUnlock()
+	if tracing.disabled {
+		return r
+	}
+
+	trace := &requestTrace{}
+	parts := strings.Split(r.Header.Get(sentryTraceHeader), "-")
+	if len(parts) >= 2 && len(parts[0]) == 32 && len(parts[1]) == 16 {
+		trace.traceID = parts[0]
+		if len(parts) > 2 {
+			trace.sampled = parts[2]
+		}
+		if dsc := ParseBaggage(r.Header.Get(baggageHeader)); len(dsc) > 0 {
+			trace.dsc = dsc
+		}
+	} else {
+		trace.traceID = randomID(32)
+		if tracing.hasSampleRate {
+			trace.sampled = "0"
+			if tracing.sampleRate > 0 && mrand.Float32() < tracing.sampleRate {
+				trace.sampled = "1"
+			}
+		}
+	}
+	trace.spanID = randomID(16)
+	return r.WithContext(context.WithValue(r.Context(), traceKey{}, trace))
+}
+
+// randomID returns n, at most 32, random hex digits, such as trace and span ids
+func randomID(n int) string {
+	id, err := uuid()
+	if err != nil {
+		return strings.Repeat("0", n)
+	}
+	return id[:n]
+}
+
+// samplingContext returns the dsc of a trace started by given client
+func (client *Client) samplingContext(trace *requestTrace, transaction string) DynamicSamplingContext {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	dsc := DynamicSamplingContext{"trace_id": trace.traceID}
+	if client.tracing.hasSampleRate {
+		dsc["sample_rate"] = strconv.FormatFloat(float64(client.tracing.sampleRate), 'f', -1, 32)
+		dsc["sampled"] = strconv.FormatBool(trace.sampled == "1")
+	}
+	if client.publicKey != "" {
+		dsc["public_key"] = client.publicKey
+	}
+	if client.release != "" {
+		dsc["release"] = client.release
+	}
+	if client.environment != "" {
+		dsc["environment"] = client.environment
+	}
+	if transaction != "" {
+		dsc["transaction"] = transaction
+	}
+	return dsc
+}
+
+// RequestSamplingContext returns the dynamic sampling context of the trace
+// of req, which must be handled by Recoverer: the one received in its
+// baggage header, or one of given client for a trace started by req.
+func (client *Client) RequestSamplingContext(req *http.Request) DynamicSamplingContext {
+	trace, ok := req.Context().Value(traceKey{}).(*requestTrace)
+	if !ok {
+		return nil
+	}
+	if trace.dsc != nil {
+		return trace.dsc
+	}
+	return client.samplingContext(trace, RequestTransaction(req))
+}
+
+// RequestSamplingContext returns the dynamic sampling context of req of the default *Client
+func RequestSamplingContext(req *http.Request) DynamicSamplingContext {
+	return DefaultClient.RequestSamplingContext(req)
+}
+
+// SetTraceHeaders sets the sentry-trace and baggage headers of out, a request
+// made while handling req, which must be handled by Recoverer, so that the
+// service receiving out continues the trace of req. Requests to urls not
+// matching SetTracePropagationTargets are left untouched.
+func (client *Client) SetTraceHeaders(req *http.Request, out *http.Request) {
+	trace, ok := req.Context().Value(traceKey{}).(*requestTrace)
+	if !ok || !client.propagatesTo(out.URL) {
+		return
+	}
+	sentryTrace := trace.traceID + "-" + trace.spanID
+	if trace.sampled != "" {
+		sentryTrace += "-" + trace.sampled
+	}
+	out.Header.Set(sentryTraceHeader, sentryTrace)
+	out.Header.Set(baggageHeader, client.RequestSamplingContext(req).Baggage(out.Header.Get(baggageHeader)))
+}
+
+// SetTraceHeaders sets the trace headers of out, a request made while handling req, with the default *Client
+func SetTraceHeaders(req *http.Request, out *http.Request) {
+	DefaultClient.SetTraceHeaders(req, out)
+}
+
+// propagatesTo reports whether trace headers are set on requests to u
+func (client *Client) propagatesTo(u *url.URL) bool {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	if len(client.tracing.propagationTargets) == 0 {
+		return true
+	}
+	for _, target := range client.tracing.propagationTargets {
+		if target.MatchString(u.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// traceContext returns the trace context of events captured during req
+func traceContext(req *http.Request) map[string]interface{} {
+	trace, ok := req.Context().Value(traceKey{}).(*requestTrace)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{"trace_id": trace.traceID, "span_id": trace.spanID}
+}
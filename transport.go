@@ -0,0 +1,546 @@
+package raven
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrUnsupportedTransport is returned when configuring the default
+// transport of a client, or an HTTPTransport, that was replaced by a custom one.
+var ErrUnsupportedTransport = errors.New("raven: transport is not an HTTPTransport backed by an *http.Transport")
+
+// ErrTransportInUse is returned when configuring the *http.Transport of an
+// HTTPTransport which already sent a request, its settings may be read by
+// pooled connections at any time.
+var ErrTransportInUse = errors.New("raven: the transport can only be configured before its first request")
+
+// Compression selects the Content-Encoding of packets sent by HTTPTransport
+type Compression string
+
+// Supported payload compressions, some proxies and self-hosted relays
+// mishandle raw deflate streams but accept gzip.
+const (
+	CompressionDeflate = Compression("deflate")
+	CompressionGzip    = Compression("gzip")
+	CompressionNone    = Compression("none")
+)
+
+const defaultCompressionThreshold = 1000
+
+// transportSettings are the fields of an HTTPTransport read by a single send
+type transportSettings struct {
+	logger               Logger
+	compression          Compression
+	compressionLevel     int
+	compressionThreshold int
+	requestCallback      func(*http.Request)
+	dumpRequests         bool
+}
+
+// settings takes a snapshot of the fields of t, so that a send isn't
+// affected by the setters of Client
+func (t *HTTPTransport) settings() transportSettings {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	logger := t.Logger
+	if logger == nil {
+		logger = noopLogger{}
+	}
+	return transportSettings{
+		logger:               logger,
+		compression:          t.Compression,
+		compressionLevel:     t.CompressionLevel,
+		compressionThreshold: t.CompressionThreshold,
+		requestCallback:      t.RequestCallback,
+		dumpRequests:         t.DumpRequests,
+	}
+}
+
+// use returns the settings of a send and marks the *http.Transport as in use
+func (t *HTTPTransport) use() transportSettings {
+	t.mu.RLock()
+	inUse := t.inUse
+	t.mu.RUnlock()
+	if !inUse {
+		t.update(func() { t.inUse = true })
+	}
+	return t.settings()
+}
+
+// update changes fields of t with set while no send reads them
+func (t *HTTPTransport) update(set func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	set()
+}
+
+// setupTransport returns the underlying *http.Transport to configure, it
+// fails with ErrTransportInUse after the first request. Callers must hold t.mu.
+func (t *HTTPTransport) setupTransport() (*http.Transport, error) {
+	if t.inUse {
+		return nil, ErrTransportInUse
+	}
+	return t.httpTransport()
+}
+
+// httpTransport returns the underlying *http.Transport, creating it when the
+// HTTPTransport has no client yet.
+func (t *HTTPTransport) httpTransport() (*http.Transport, error) {
+	if t.Client == nil {
+		t.Client = &http.Client{
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+			Timeout:   transportClientTimeout,
+		}
+	}
+	if t.Client.Transport == nil {
+		t.Client.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	transport, ok := t.Client.Transport.(*http.Transport)
+	if !ok {
+		return nil, ErrUnsupportedTransport
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	return transport, nil
+}
+
+// SetRootCAs replaces the certificate pool used to verify the Sentry server
+func (t *HTTPTransport) SetRootCAs(pool *x509.CertPool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	return nil
+}
+
+// LoadCAFile adds the PEM encoded certificates of file to the pool used to
+// verify the Sentry server, e.g. for self-hosted Sentry behind corporate PKI.
+func (t *HTTPTransport) LoadCAFile(file string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("raven: can't read CA file: %v", err)
+	}
+
+	pool := transport.TLSClientConfig.RootCAs
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("raven: no PEM certificates found in %s", file)
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	return nil
+}
+
+// LoadClientCertificate loads a PEM encoded certificate and key presented to
+// the Sentry server for mutual TLS.
+func (t *HTTPTransport) LoadClientCertificate(certFile, keyFile string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("raven: can't load client certificate: %v", err)
+	}
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	return nil
+}
+
+// SetTimeout limits the time a whole request to Sentry may take, it defaults to 30 seconds
+func (t *HTTPTransport) SetTimeout(timeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.setupTransport(); err != nil {
+		return err
+	}
+	t.Client.Timeout = timeout
+	return nil
+}
+
+// SetDialTimeout limits the time spent establishing a TCP connection to Sentry
+func (t *HTTPTransport) SetDialTimeout(timeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	return nil
+}
+
+// SetTLSHandshakeTimeout limits the time spent on the TLS handshake with Sentry
+func (t *HTTPTransport) SetTLSHandshakeTimeout(timeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.TLSHandshakeTimeout = timeout
+	return nil
+}
+
+// SetMaxIdleConns limits the number of idle keep-alive connections kept open
+func (t *HTTPTransport) SetMaxIdleConns(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.MaxIdleConns = n
+	return nil
+}
+
+// SetMaxIdleConnsPerHost limits the number of idle keep-alive connections kept open to Sentry
+func (t *HTTPTransport) SetMaxIdleConnsPerHost(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.MaxIdleConnsPerHost = n
+	return nil
+}
+
+// SetIdleConnTimeout sets how long an idle keep-alive connection is kept open
+func (t *HTTPTransport) SetIdleConnTimeout(timeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, err := t.setupTransport()
+	if err != nil {
+		return err
+	}
+	transport.IdleConnTimeout = timeout
+	return nil
+}
+
+// WarmUp sends a HEAD request to the host of url so that DNS resolution and
+// the TLS handshake happen ahead of time and the connection is kept alive for
+// the first packet, instead of inside its request timeout. The response
+// status is irrelevant, only network errors are returned. The *http.Transport
+// can't be configured anymore afterwards.
+func (t *HTTPTransport) WarmUp(url string) error {
+	if url == "" {
+		return nil
+	}
+	t.mu.Lock()
+	_, err := t.httpTransport()
+	t.inUse = true
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return fmt.Errorf("raven: can't create new request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := t.Do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Unwrapper is implemented by transports wrapping another transport, like
+// SpotlightTransport or CircuitBreakerTransport. The client looks up its
+// HTTPTransport and optional interfaces, e.g. EnvelopeTransport, through
+// the chain of wrapped transports.
+type Unwrapper interface {
+	Unwrap() Transport
+}
+
+// findTransport returns the first transport of the chain starting at t for
+// which match is true, nil if there is none
+func findTransport(t Transport, match func(Transport) bool) Transport {
+	for t != nil {
+		if match(t) {
+			return t
+		}
+		u, ok := t.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		t = u.Unwrap()
+	}
+	return nil
+}
+
+// findHTTPTransport returns the HTTPTransport t is or wraps, nil if there is none
+func findHTTPTransport(t Transport) *HTTPTransport {
+	found, _ := findTransport(t, func(t Transport) bool {
+		_, ok := t.(*HTTPTransport)
+		return ok
+	}).(*HTTPTransport)
+	return found
+}
+
+// findEnvelopeTransport returns the EnvelopeTransport t is or wraps, nil if there is none
+func findEnvelopeTransport(t Transport) EnvelopeTransport {
+	found, _ := findTransport(t, func(t Transport) bool {
+		_, ok := t.(EnvelopeTransport)
+		return ok
+	}).(EnvelopeTransport)
+	return found
+}
+
+// defaultTransport returns the HTTPTransport of given client, callers must hold client.mu
+func (client *Client) defaultTransport() (*HTTPTransport, error) {
+	t := findHTTPTransport(client.Transport)
+	if t == nil {
+		return nil, ErrUnsupportedTransport
+	}
+	return t, nil
+}
+
+// SetCAFile adds the certificates of file to the pool the default transport
+// of given client uses to verify the Sentry server. Like the other settings of
+// the *http.Transport, it fails with ErrTransportInUse after the first send.
+func (client *Client) SetCAFile(file string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	return t.LoadCAFile(file)
+}
+
+// SetClientCertificate configures the default transport of given client for mutual TLS
+func (client *Client) SetClientCertificate(certFile, keyFile string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	return t.LoadClientCertificate(certFile, keyFile)
+}
+
+// SetTransportTimeout limits the time a request of the default transport of given client may take
+func (client *Client) SetTransportTimeout(timeout time.Duration) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	return t.SetTimeout(timeout)
+}
+
+// SetCompression selects the payload compression of the default transport of given client
+func (client *Client) SetCompression(compression Compression) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	t.update(func() { t.Compression = compression })
+	return nil
+}
+
+// SetCompressionLevel sets the compression level of the default transport of
+// given client, e.g. zlib.BestSpeed for CPU-bound services.
+func (client *Client) SetCompressionLevel(level int) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	t.update(func() { t.CompressionLevel = level })
+	return nil
+}
+
+// SetCompressionThreshold sets the size in bytes above which the default
+// transport of given client compresses packets. Zero restores the default of
+// 1000 bytes, a negative threshold compresses every packet.
+func (client *Client) SetCompressionThreshold(threshold int) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, err := client.defaultTransport()
+	if err != nil {
+		return err
+	}
+	t.update(func() { t.CompressionThreshold = threshold })
+	return nil
+}
+
+// WarmUp opens a connection from the default transport of given client to
+// its DSN, see HTTPTransport.WarmUp. It is safe to run in a goroutine at startup.
+func (client *Client) WarmUp() error {
+	client.mu.RLock()
+	url := client.url
+	t, err := client.defaultTransport()
+	client.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return t.WarmUp(url)
+}
+
+// SetCAFile adds the certificates of file to the pool of the default *Client
+func SetCAFile(file string) error { return DefaultClient.SetCAFile(file) }
+
+// SetTransportTimeout limits the time a request of the default *Client may take
+func SetTransportTimeout(timeout time.Duration) error {
+	return DefaultClient.SetTransportTimeout(timeout)
+}
+
+// SetCompression selects the payload compression of the default *Client
+func SetCompression(compression Compression) error {
+	return DefaultClient.SetCompression(compression)
+}
+
+// SetCompressionLevel sets the compression level of the default *Client
+func SetCompressionLevel(level int) error { return DefaultClient.SetCompressionLevel(level) }
+
+// SetCompressionThreshold sets the compression threshold of the default *Client
+func SetCompressionThreshold(threshold int) error {
+	return DefaultClient.SetCompressionThreshold(threshold)
+}
+
+// WarmUp opens a connection from the default *Client to its DSN
+func WarmUp() error { return DefaultClient.WarmUp() }
+
+// SetClientCertificate configures the default *Client for mutual TLS
+func SetClientCertificate(certFile, keyFile string) error {
+	return DefaultClient.SetClientCertificate(certFile, keyFile)
+}
+
+// TransportV2 is a Transport whose sends honor the cancellation and deadline
+// of ctx. The client worker prefers it over Send, see AsTransportV2.
+type TransportV2 interface {
+	SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error
+}
+
+// AsTransportV2 returns t itself when it implements TransportV2, otherwise an
+// adapter which returns ctx.Err() as soon as ctx is done while the
+// underlying Send finishes in the background: its goroutine lives, and
+// holds the packet, for as long as Send blocks. Transports which may block
+// should implement TransportV2, as the wrapping transports of this package do.
+func AsTransportV2(t Transport) TransportV2 {
+	if v2, ok := t.(TransportV2); ok {
+		return v2
+	}
+	return transportAdapter{t}
+}
+
+type transportAdapter struct {
+	Transport
+}
+
+func (a transportAdapter) SendWithContext(ctx context.Context, url, authHeader string, packet *Packet) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Send(url, authHeader, packet)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Send still reads the packet, so it must not go back to the pool
+		// and be reused, the garbage collector frees it instead
+		packet.pooled = false
+		return ctx.Err()
+	}
+}
+
+// TransportError describes a failed delivery and whether retrying it later may succeed
+type TransportError struct {
+	// StatusCode of the Sentry response, zero for network errors
+	StatusCode int
+
+	// Retryable is set for network errors, rate limiting and server errors
+	Retryable bool
+
+	Err error
+}
+
+func (e *TransportError) Error() string { return e.Err.Error() }
+
+// Cause returns the underlying error
+func (e *TransportError) Cause() error { return e.Err }
+
+// retryable tells whether a failed send may succeed later, errors of
+// transports not returning a *TransportError are assumed to be
+func retryable(err error) bool {
+	switch err := err.(type) {
+	case *TransportError:
+		return err.Retryable
+	case *serializationError:
+		return false
+	}
+	return true
+}
+
+// SetSendTimeout sets a deadline for every single send of given client,
+// enforced through TransportV2. Zero disables it.
+func (client *Client) SetSendTimeout(timeout time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sendTimeout = timeout
+}
+
+// SetSendTimeout sets a deadline for every single send of the default *Client
+func SetSendTimeout(timeout time.Duration) { DefaultClient.SetSendTimeout(timeout) }
+
+// sendContext returns the context passed to TransportV2 by the worker
+func (client *Client) sendContext() (context.Context, context.CancelFunc) {
+	client.mu.RLock()
+	timeout := client.sendTimeout
+	client.mu.RUnlock()
+
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
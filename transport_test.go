@@ -0,0 +1,555 @@
+package raven
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHTTPTransportLoadCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	if err := newTransport(nil).Send(server.URL, "", &Packet{}); err == nil {
+		t.Fatal("expected the test server certificate to be rejected")
+	}
+	transport := newTransport(nil).(*HTTPTransport)
+	if err := transport.LoadCAFile(caFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Send(server.URL, "", &Packet{}); err != nil {
+		t.Errorf("expected the test server certificate to be trusted: %v", err)
+	}
+}
+
+func TestHTTPTransportLoadClientCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "raven"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := writePEM(t, dir, "cert.pem", "CERTIFICATE", certDER)
+	keyFile := writePEM(t, dir, "key.pem", "EC PRIVATE KEY", keyDER)
+
+	client := newClient(nil)
+	if err := client.SetClientCertificate(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig := client.Transport.(*HTTPTransport).Client.Transport.(*http.Transport).TLSClientConfig
+	if len(tlsConfig.Certificates) != 1 {
+		t.Error("client certificate was not configured")
+	}
+	if err := client.SetCAFile(keyFile); err == nil {
+		t.Error("expected an error loading a file without certificates")
+	}
+}
+
+func TestSetCAFileCustomTransport(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &recordingTransport{}
+	if err := client.SetCAFile("ca.pem"); err != ErrUnsupportedTransport {
+		t.Errorf("expected ErrUnsupportedTransport, got %v", err)
+	}
+}
+
+func TestWrappedDefaultTransport(t *testing.T) {
+	client := newClient(nil)
+	original := client.Transport.(*HTTPTransport)
+	client.SetSpotlight(true, "")
+	client.Transport = NewCircuitBreakerTransport(client.Transport, 5, time.Minute)
+
+	if err := client.SetCompression(CompressionGzip); err != nil || original.Compression != CompressionGzip {
+		t.Errorf("expected the wrapped transport to be configured, got %v", err)
+	}
+	client.SetDebugWriter(ioutil.Discard, DebugTrace)
+	if original.Logger == nil || !original.DumpRequests {
+		t.Error("expected the debug writer to reach the wrapped transport")
+	}
+	if findEnvelopeTransport(client.Transport) != original {
+		t.Error("expected envelopes to be sent through the wrapped transport")
+	}
+
+	client.AddRoute(RouteRule{Logger: "billing"}, "https://public@sentry.example.com/1")
+	client.AddRoute(RouteRule{Logger: "search"}, "https://public@sentry.example.com/2")
+	routing, ok := client.Transport.(*RoutingTransport)
+	if !ok || len(routing.routes) != 2 {
+		t.Fatalf("expected a single routing transport with 2 routes, got %#v", client.Transport)
+	}
+	client.Transport = NewSpotlightTransport(routing, "")
+	client.AddRoute(RouteRule{Logger: "auth"}, "https://public@sentry.example.com/3")
+	if len(routing.routes) != 3 {
+		t.Error("expected the route to be added to the wrapped routing transport")
+	}
+}
+
+func TestHTTPTransportTuning(t *testing.T) {
+	client := newClient(nil)
+	if err := client.SetTransportTimeout(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := client.Transport.(*HTTPTransport)
+	if err := transport.SetDialTimeout(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.SetTLSHandshakeTimeout(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.SetMaxIdleConns(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.SetMaxIdleConnsPerHost(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.SetIdleConnTimeout(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	httpTransport := transport.Client.Transport.(*http.Transport)
+	if transport.Client.Timeout != 5*time.Second {
+		t.Errorf("incorrect Timeout: %v", transport.Client.Timeout)
+	}
+	if httpTransport.DialContext == nil {
+		t.Error("dial timeout was not configured")
+	}
+	if httpTransport.TLSHandshakeTimeout != 2*time.Second {
+		t.Errorf("incorrect TLSHandshakeTimeout: %v", httpTransport.TLSHandshakeTimeout)
+	}
+	if httpTransport.MaxIdleConns != 4 {
+		t.Errorf("incorrect MaxIdleConns: %v", httpTransport.MaxIdleConns)
+	}
+	if httpTransport.MaxIdleConnsPerHost != 2 {
+		t.Errorf("incorrect MaxIdleConnsPerHost: %v", httpTransport.MaxIdleConnsPerHost)
+	}
+	if httpTransport.IdleConnTimeout != time.Minute {
+		t.Errorf("incorrect IdleConnTimeout: %v", httpTransport.IdleConnTimeout)
+	}
+}
+
+func TestSerializedPacketCompression(t *testing.T) {
+	packet := &Packet{Message: strings.Repeat("a", 2000)}
+	tests := []struct {
+		compression Compression
+		encoding    string
+		reader      func(io.Reader) (io.Reader, error)
+	}{
+		{"", "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{CompressionDeflate, "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{CompressionGzip, "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+	}
+	for _, test := range tests {
+		body, _, encoding, err := serializedPacket(packet, test.compression, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoding != test.encoding {
+			t.Errorf("incorrect Content-Encoding: got %s, want %s", encoding, test.encoding)
+		}
+		r, err := test.reader(body)
+		if err != nil {
+			t.Fatalf("%s: %v", test.encoding, err)
+		}
+		data, _ := ioutil.ReadAll(r)
+		if !strings.Contains(string(data), packet.Message) {
+			t.Errorf("%s: packet was not compressed correctly", test.encoding)
+		}
+	}
+
+	if _, _, _, err := serializedPacket(packet, Compression("br"), 0, 0); err == nil {
+		t.Error("expected an error for an unknown compression")
+	}
+}
+
+func TestSetCompression(t *testing.T) {
+	client := newClient(nil)
+	if err := client.SetCompression(CompressionGzip); err != nil {
+		t.Fatal(err)
+	}
+	if client.Transport.(*HTTPTransport).Compression != CompressionGzip {
+		t.Error("compression was not set on the default transport")
+	}
+}
+
+func TestSerializedPacketCompressionOptions(t *testing.T) {
+	packet := &Packet{Message: strings.Repeat("a", 2000)}
+
+	if _, contentType, encoding, _ := serializedPacket(packet, CompressionNone, 0, 0); encoding != "" || contentType != "application/json" {
+		t.Errorf("CompressionNone should send plain JSON, got %s %s", contentType, encoding)
+	}
+	if _, _, encoding, _ := serializedPacket(packet, CompressionDeflate, 0, 5000); encoding != "" {
+		t.Error("packets below the threshold should not be compressed")
+	}
+	if _, _, encoding, _ := serializedPacket(&Packet{Message: "a"}, CompressionGzip, gzip.BestSpeed, 1); encoding != "gzip" {
+		t.Error("packets above the threshold should be compressed")
+	}
+	if _, _, encoding, _ := serializedPacket(&Packet{}, CompressionDeflate, 0, -1); encoding != "deflate" {
+		t.Error("a negative threshold should compress every packet")
+	}
+	if _, _, _, err := serializedPacket(packet, CompressionDeflate, 42, 0); err == nil {
+		t.Error("expected an error for an invalid compression level")
+	}
+}
+
+func TestHTTPTransportStreamsBody(t *testing.T) {
+	var contentLength int64
+	var message string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var packet Packet
+		if err := json.NewDecoder(zr).Decode(&packet); err != nil {
+			t.Error(err)
+		}
+		message = packet.Message
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{Client: http.DefaultClient}
+	packet := &Packet{Message: strings.Repeat("a", 1<<20), Interfaces: []Interface{&Message{Message: "foo"}}}
+	if err := transport.Send(server.URL, "", packet); err != nil {
+		t.Fatal(err)
+	}
+	if contentLength != -1 {
+		t.Errorf("expected a chunked body, got Content-Length %d", contentLength)
+	}
+	if message != packet.Message {
+		t.Error("packet was not streamed correctly")
+	}
+}
+
+// failingRoundTripper fails right away while still writing the request body
+// in the background, as RoundTripper implementations may
+type failingRoundTripper struct{}
+
+func (failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	go func() {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}()
+	return nil, errors.New("request entity too large")
+}
+
+// slowInterface takes a while to encode and fails the test once released is set
+type slowInterface struct {
+	t        *testing.T
+	class    string
+	released *int32
+}
+
+func (v slowInterface) Class() string { return v.class }
+
+func (v slowInterface) MarshalJSON() ([]byte, error) {
+	time.Sleep(time.Millisecond)
+	if atomic.LoadInt32(v.released) != 0 {
+		v.t.Error("packet encoded after Send returned")
+	}
+	return []byte(strconv.Quote(strings.Repeat("a", 1000))), nil
+}
+
+func TestHTTPTransportFailedSendStopsEncoding(t *testing.T) {
+	var released int32
+	packet := &Packet{Message: "foo"}
+	for i := 0; i < 50; i++ {
+		packet.Interfaces = append(packet.Interfaces, slowInterface{t, strconv.Itoa(i), &released})
+	}
+
+	transport := &HTTPTransport{Client: &http.Client{Transport: failingRoundTripper{}}}
+	if err := transport.Send("http://example.com/api/1/store/", "", packet); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	atomic.StoreInt32(&released, 1)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestHTTPTransportSendsEnvelope(t *testing.T) {
+	var path, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{Client: http.DefaultClient, Compression: CompressionNone}
+	if err := transport.Send(server.URL+"/api/1/store/", "", &Packet{Message: "foo", EventID: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/1/store/" || contentType != "application/json" || !strings.HasPrefix(body, "{") {
+		t.Errorf("expected JSON posted to /api/1/store/ without a dynamic sampling context, got %s %s", contentType, path)
+	}
+
+	packet := &Packet{Message: "foo", EventID: "abc", dsc: DynamicSamplingContext{"trace_id": "771a43a4192642f0b136d5159a501700"}}
+	if err := transport.Send(server.URL+"/api/1/store/", "", packet); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/1/envelope/" || contentType != envelopeContentType {
+		t.Errorf("expected an envelope posted to /api/1/envelope/, got %s %s", contentType, path)
+	}
+	lines := strings.Split(body, "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], `"trace":{"trace_id":"771a43a4192642f0b136d5159a501700"}`) || lines[1] != `{"type":"event"}` || !strings.Contains(lines[2], `"message":"foo"`) {
+		t.Errorf("incorrect envelope %q", body)
+	}
+}
+
+func TestPacketWriteJSON(t *testing.T) {
+	packet := &Packet{
+		Message:    "<test>",
+		Interfaces: []Interface{&User{ID: "1"}, &Message{Message: "foo"}, nil, &Message{Message: "bar"}},
+	}
+	expected := `{"message":"\u003ctest\u003e","event_id":"","project":"","timestamp":"0001-01-01T00:00:00.00","level":"","logger":"","logentry":{"message":"bar"},"user":{"id":"1"}}`
+
+	buf := &bytes.Buffer{}
+	if err := packet.writeJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected {
+		t.Errorf("incorrect json; got %s, want %s", buf.String(), expected)
+	}
+}
+
+func TestHTTPTransportSettingsInUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := newClient(nil)
+	transport := client.Transport.(*HTTPTransport)
+	if err := client.SetDSN(strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// settings of the HTTPTransport may change while it is sending
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			client.Capture(&Packet{Message: "Test"}, nil)
+		}
+	}()
+	client.SetCompression(CompressionGzip)
+	client.SetCompressionThreshold(-1)
+	client.SetDebugWriter(ioutil.Discard, DebugTrace)
+	<-done
+	client.Wait()
+
+	// those of the *http.Transport can't once it is in use
+	if err := transport.SetMaxIdleConns(4); err != ErrTransportInUse {
+		t.Errorf("expected ErrTransportInUse, got %v", err)
+	}
+	if err := client.SetTransportTimeout(time.Second); err != ErrTransportInUse {
+		t.Errorf("expected ErrTransportInUse, got %v", err)
+	}
+}
+
+func TestClientWarmUp(t *testing.T) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+	}))
+	defer server.Close()
+
+	client := newClient(nil)
+	if err := client.SetDSN(strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WarmUp(); err != nil {
+		t.Fatal(err)
+	}
+	if method != "HEAD" || path != "/api/1/store/" {
+		t.Errorf("expected HEAD /api/1/store/, got %s %s", method, path)
+	}
+}
+
+func TestHTTPTransportRequestCallback(t *testing.T) {
+	var gatewayToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatewayToken = r.Header.Get("X-Gateway-Token")
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{
+		Client: http.DefaultClient,
+		RequestCallback: func(req *http.Request) {
+			req.Header.Set("X-Gateway-Token", "secret")
+		},
+	}
+	if err := transport.Send(server.URL, "", &Packet{}); err != nil {
+		t.Fatal(err)
+	}
+	if gatewayToken != "secret" {
+		t.Errorf("incorrect X-Gateway-Token: %q", gatewayToken)
+	}
+}
+
+type slowTransport struct {
+	delay time.Duration
+}
+
+func (t *slowTransport) Send(url, authHeader string, packet *Packet) error {
+	time.Sleep(t.delay)
+	return nil
+}
+
+func TestAsTransportV2(t *testing.T) {
+	httpTransport := &HTTPTransport{}
+	if AsTransportV2(httpTransport) != TransportV2(httpTransport) {
+		t.Error("HTTPTransport should be used as TransportV2 directly")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := AsTransportV2(&slowTransport{time.Second}).SendWithContext(ctx, "", "", &Packet{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestHTTPTransportError(t *testing.T) {
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	transport := &HTTPTransport{Client: http.DefaultClient}
+
+	for _, test := range []struct {
+		status    int
+		retryable bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusBadRequest, false},
+	} {
+		status = test.status
+		err := transport.Send(server.URL, "", &Packet{})
+		transportErr, ok := err.(*TransportError)
+		if !ok {
+			t.Fatalf("expected *TransportError, got %T", err)
+		}
+		if transportErr.StatusCode != test.status || transportErr.Retryable != test.retryable {
+			t.Errorf("incorrect error for status %d: %+v", test.status, transportErr)
+		}
+	}
+}
+
+func TestSetSendTimeout(t *testing.T) {
+	client := newClient(nil)
+	client.Transport = &slowTransport{time.Second}
+	client.SetSendTimeout(10 * time.Millisecond)
+
+	_, ch := client.Capture(NewPacket("foo"), nil)
+	if err := <-ch; err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// readingTransport reads the message of packets after blocking, like a slow
+// legacy transport serializing them
+type readingTransport struct {
+	release chan struct{}
+	read    chan string
+}
+
+func (t *readingTransport) Send(url, authHeader string, packet *Packet) error {
+	<-t.release
+	t.read <- packet.Message
+	return nil
+}
+
+func TestSetSendTimeoutKeepsPooledPacket(t *testing.T) {
+	client := newClient(nil)
+	transport := &readingTransport{release: make(chan struct{}), read: make(chan string, 1)}
+	client.Transport = transport
+	client.SetSendTimeout(10 * time.Millisecond)
+
+	packet := AcquirePacket("abandoned")
+	if _, ch := client.Capture(packet, nil); <-ch != context.DeadlineExceeded {
+		t.Fatal("expected the send to time out")
+	}
+	reused := AcquirePacket("reused")
+	close(transport.release)
+	if message := <-transport.read; message != "abandoned" {
+		t.Errorf("expected the abandoned send to keep its packet, got %q", message)
+	}
+	ReleasePacket(reused)
+}
+
+func TestWrappingTransportsHonorContext(t *testing.T) {
+	slow := &blockingTransport{release: make(chan struct{})}
+	defer close(slow.release)
+	failover, _ := NewFailoverTransport(slow, "https://public@sentry.example.com/2")
+	multi, _ := NewMultiTransport(slow)
+
+	for _, transport := range []TransportV2{
+		NewSpotlightTransport(slow, "http://127.0.0.1:1/stream"),
+		failover,
+		multi,
+		NewRoutingTransport(slow),
+		NewCircuitBreakerTransport(slow, 3, time.Minute),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := transport.SendWithContext(ctx, "https://sentry.example.com/api/1/store/", "", NewPacket("foo"))
+		cancel()
+		if _, ok := err.(MultiTransportError); !ok && err != context.DeadlineExceeded {
+			t.Errorf("%T: expected context.DeadlineExceeded, got %v", transport, err)
+		}
+	}
+}